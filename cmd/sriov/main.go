@@ -1,12 +1,18 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
 	"runtime"
 
 	"github.com/containernetworking/cni/pkg/skel"
 	"github.com/containernetworking/cni/pkg/version"
 
 	"github.com/k8snetworkplumbingwg/sriov-cni/pkg/cnicommands"
+	"github.com/k8snetworkplumbingwg/sriov-cni/pkg/config"
+	"github.com/k8snetworkplumbingwg/sriov-cni/pkg/logging"
 )
 
 func init() {
@@ -17,10 +23,71 @@ func init() {
 }
 
 func main() {
+	// The plugin is run by the container runtime with CNI_COMMAND set, the subcommands are run by hand or by a
+	// node startup unit
+	if len(os.Args) > 1 && os.Args[1] == "recover" {
+		os.Exit(recoverVFs(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		os.Exit(validateConf(os.Stdin, os.Stdout))
+	}
+
 	cniFuncs := skel.CNIFuncs{
-		Add:   cnicommands.CmdAdd,
-		Del:   cnicommands.CmdDel,
-		Check: cnicommands.CmdCheck,
+		Add:    cnicommands.CmdAdd,
+		Del:    cnicommands.CmdDel,
+		Check:  cnicommands.CmdCheck,
+		GC:     cnicommands.CmdGC,
+		Status: cnicommands.CmdStatus,
 	}
 	skel.PluginMainFuncs(cniFuncs, version.All, "")
 }
+
+// recoverVFs runs the recover subcommand and returns its exit code
+func recoverVFs(args []string) int {
+	flags := flag.NewFlagSet("recover", flag.ContinueOnError)
+	flags.StringVar(&config.DefaultCNIDir, "cni-dir", config.DefaultCNIDir, "directory of the cached NetConfs")
+	flags.StringVar(&config.DefaultDeviceInfoDir, "device-info-dir", config.DefaultDeviceInfoDir, "directory of the device information files")
+	logLevel := flags.String("log-level", "info", "log level: debug, info, warning or error")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	logging.Init(*logLevel, "", "", "", "")
+
+	recovered, err := cnicommands.Recover()
+	for _, cRefPath := range recovered {
+		fmt.Printf("recovered %s\n", cRefPath)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to recover VFs: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// validateConf runs the validate subcommand on the netconf read from stdin and returns its exit code. Unknown keys
+// fail the validation, as they are most likely misspelled settings.
+func validateConf(stdin io.Reader, stdout io.Writer) int {
+	bytes, err := io.ReadAll(stdin)
+	if err != nil {
+		fmt.Fprintf(stdout, "failed to read netconf: %v\n", err)
+		return 2
+	}
+
+	unknownKeys, err := config.UnknownKeys(bytes)
+	if err != nil {
+		fmt.Fprintf(stdout, "%v\n", err)
+		return 1
+	}
+	for _, msg := range unknownKeys {
+		fmt.Fprintln(stdout, msg)
+	}
+
+	if err := config.ValidateConf(bytes); err != nil {
+		fmt.Fprintf(stdout, "%v\n", err)
+		return 1
+	}
+	if len(unknownKeys) > 0 {
+		return 1
+	}
+	return 0
+}
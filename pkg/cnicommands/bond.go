@@ -0,0 +1,301 @@
+package cnicommands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/containernetworking/cni/pkg/skel"
+	"github.com/containernetworking/cni/pkg/types"
+	current "github.com/containernetworking/cni/pkg/types/100"
+	"github.com/containernetworking/plugins/pkg/ipam"
+	"github.com/containernetworking/plugins/pkg/ns"
+
+	"github.com/k8snetworkplumbingwg/sriov-cni/pkg/config"
+	"github.com/k8snetworkplumbingwg/sriov-cni/pkg/logging"
+	sriovtypes "github.com/k8snetworkplumbingwg/sriov-cni/pkg/types"
+	"github.com/k8snetworkplumbingwg/sriov-cni/pkg/utils"
+)
+
+// cmdAddBond sets up the VF of every link of a bond the same way as the VF of a single pod interface, enslaves their
+// netdevices to a bond created as the pod interface and runs the IPAM plugin on the bond.
+func cmdAddBond(args *skel.CmdArgs) (err error) {
+	bondConf, linkConfs, err := config.LoadBondConf(args.StdinData)
+	if err != nil {
+		return cniError(types.ErrInternal, "SRIOV-CNI failed to load netconf", err)
+	}
+
+	envArgs, err := getEnvArgs(args.Args)
+	if err != nil {
+		return cniError(types.ErrInvalidEnvironmentVariables, "SRIOV-CNI failed to parse args", err)
+	}
+
+	prevResult, prevIPs, err := loadPrevResult(args, bondConf)
+	if err != nil {
+		return err
+	}
+
+	netns, err := ns.GetNS(args.Netns)
+	if err != nil {
+		return cniError(types.ErrInternal, fmt.Sprintf("failed to open netns %q", args.Netns), err)
+	}
+	defer netns.Close()
+
+	bondConf.ContainerID = args.ContainerID
+	bondConf.IfName = args.IfName
+	bondConf.NetNS = args.Netns
+	bondConf.PodNamespace, bondConf.PodName, bondConf.PodUID = envArgs.pod()
+
+	// In a chain the bond is added to the result of the previous plugins
+	result := &current.Result{}
+	if prevResult != nil {
+		result = prevResult
+	}
+	bondIfIndex := len(result.Interfaces)
+	bondIf := &current.Interface{
+		Name:    args.IfName,
+		Sandbox: netns.Path(),
+	}
+	result.Interfaces = append(result.Interfaces, bondIf)
+
+	sm := newSriovManager()
+	linkIfNames := make([]string, 0, len(linkConfs))
+	pfsWithGeneratedMAC := make(map[string]bool)
+	for i, linkConf := range linkConfs {
+		linkIfName := bondConf.Bond.LinkIfName(args.IfName, i)
+		if err = setRuntimeConfig(linkConf, envArgs); err != nil {
+			return cniError(types.ErrInvalidNetworkConfig, "SRIOV-CNI invalid runtime config", err)
+		}
+		// The file of the meta plugin takes the device information of the bond, see newBondDeviceInfo,
+		// the links publish theirs in the default directory, one file per link
+		linkConf.RuntimeConfig.CNIDeviceInfoFile = ""
+		// The bond sets its MAC address on every link, which untrusted VFs refuse, so every link
+		// gets the MAC address generated for the bond, like a MAC address given by mac
+		if err = generateMAC(linkConf, envArgs, args.ContainerID, args.IfName); err != nil {
+			return cniError(types.ErrInvalidNetworkConfig, "SRIOV-CNI failed to generate MAC address", err)
+		}
+		// The links on one PF share the generated MAC address, it is only checked against
+		// the VFs of other pods when set on the first of them
+		if linkConf.MACGenerated {
+			linkConf.MACGenerated = !pfsWithGeneratedMAC[linkConf.Master]
+			pfsWithGeneratedMAC[linkConf.Master] = true
+		}
+		linkConf.ContainerID = args.ContainerID
+		linkConf.IfName = linkIfName
+		linkConf.NetNS = args.Netns
+		linkConf.PodNamespace, linkConf.PodName, linkConf.PodUID = envArgs.pod()
+		linkConf.BondIfName = args.IfName
+
+		logging.Debug("Set up bond link",
+			"func", "cmdAddBond",
+			"linkIfName", linkIfName,
+			"linkConf.DeviceID", linkConf.DeviceID)
+		if err = addVF(sm, linkConf, linkIfName, netns); err != nil {
+			return err
+		}
+		defer func() {
+			if err != nil {
+				undoAddVF(sm, linkConf, linkIfName, netns)
+			}
+		}()
+		linkIfNames = append(linkIfNames, linkIfName)
+
+		linkIface := &current.Interface{
+			Name:    linkIfName,
+			Mac:     config.GetMacAddressForResult(linkConf),
+			Sandbox: netns.Path(),
+		}
+		if linkConf.MTU != nil {
+			linkIface.Mtu = *linkConf.MTU
+		}
+		result.Interfaces = append(result.Interfaces, linkIface)
+	}
+
+	// a partially set up bond is deleted as well
+	defer func() {
+		if err != nil {
+			_ = sm.ReleaseBond(args.IfName, netns)
+		}
+	}()
+	mac, err := sm.SetupBond(bondConf, args.IfName, linkIfNames, netns)
+	if err != nil {
+		return cniError(types.ErrInternal, fmt.Sprintf("failed to set up bond %q", args.IfName), err)
+	}
+	bondIf.Mac = mac
+	bondIf.Mtu = result.Interfaces[bondIfIndex+1].Mtu
+
+	var bondIfIPs []*current.IPConfig
+	if bondConf.IPAM.Type != "" {
+		bondIfIPs, err = addIPAM(args, bondConf, netns, result, bondIfIndex)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err != nil {
+				_ = ipam.ExecDel(bondConf.IPAM.Type, args.StdinData)
+			}
+		}()
+	}
+
+	// take over the IPs a previous plugin allocated
+	if bondConf.UsePrevResultIPs {
+		bondIfIPs = prevIPs
+		if err = addPrevIPs(bondConf, netns, result, bondIfIndex, prevIPs); err != nil {
+			return cniError(types.ErrInternal, "failed to configure the IPs of prevResult", err)
+		}
+	}
+
+	for i, linkConf := range linkConfs {
+		if err = saveVF(args, linkConf, linkIfNames[i]); err != nil {
+			return err
+		}
+		defer func() {
+			if err != nil {
+				forgetVF(args, linkConf, linkIfNames[i])
+			}
+		}()
+	}
+
+	// Publish the device information of the bond attachment
+	bondDevInfoPath := deviceInfoPath(bondConf, args.ContainerID, args.IfName)
+	if err = utils.SaveDeviceInfo(bondDevInfoPath, newBondDeviceInfo(linkConfs)); err != nil {
+		return cniError(types.ErrIOFailure, "error saving device info", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = utils.CleanDeviceInfo(bondDevInfoPath)
+		}
+	}()
+
+	// Cache the NetConf of the bond for CmdDel, which finds the links from it
+	if err = utils.SaveNetConf(args.ContainerID, config.DefaultCNIDir, args.IfName, bondConf); err != nil {
+		return cniError(types.ErrIOFailure, "error saving NetConf", err)
+	}
+
+	if len(bondIfIPs) > 0 {
+		announceIPs(netns, args.IfName, bondIfIPs)
+	}
+
+	// the links follow the bond in the result
+	txRateLimiters := map[int]string{}
+	for i, linkConf := range linkConfs {
+		if linkConf.TxRateLimiter != "" {
+			txRateLimiters[bondIfIndex+1+i] = linkConf.TxRateLimiter
+		}
+	}
+	return printResult(result, bondConf.CNIVersion, txRateLimiters)
+}
+
+// forgetVF removes what saveVF saved for the VF
+func forgetVF(args *skel.CmdArgs, netConf *sriovtypes.NetConf, ifName string) {
+	_ = utils.CleanDeviceInfo(deviceInfoPath(netConf, args.ContainerID, ifName))
+	_ = utils.CleanCachedNetConf(cachedNetConfPath(args.ContainerID, ifName))
+	_ = utils.NewPCIAllocator(config.DefaultCNIDir).DeleteAllocatedPCI(netConf.DeviceID)
+}
+
+// cmdDelBond deletes the bond and releases the VFs of its links in reverse order of cmdAddBond
+func cmdDelBond(args *skel.CmdArgs, bondConf *sriovtypes.NetConf, cRefPath string) error {
+	if bondConf.IPAM.Type != "" {
+		if err := ipam.ExecDel(bondConf.IPAM.Type, args.StdinData); err != nil {
+			return err
+		}
+	}
+
+	if args.Netns != "" {
+		netns, err := ns.GetNS(args.Netns)
+		if err == nil {
+			defer netns.Close()
+			if err = newSriovManager().ReleaseBond(args.IfName, netns); err != nil {
+				return cniError(types.ErrInternal, fmt.Sprintf("cmdDel() error deleting bond %q", args.IfName), err)
+			}
+		} else if _, ok := err.(ns.NSPathNotExistErr); !ok {
+			return cniError(types.ErrInternal, fmt.Sprintf("failed to open netns %q", args.Netns), err)
+		}
+	}
+
+	for i := range bondConf.Bond.Links {
+		linkArgs := *args
+		linkArgs.IfName = bondConf.Bond.LinkIfName(args.IfName, i)
+		linkConf, linkRefPath, err := config.LoadConfFromCache(&linkArgs)
+		if err != nil {
+			// the link was released by a previous DEL
+			logging.Debug("Skipping bond link without a cached NetConf",
+				"func", "cmdDelBond",
+				"linkIfName", linkArgs.IfName)
+			continue
+		}
+
+		allocator := utils.NewPCIAllocator(config.DefaultCNIDir)
+		if err = allocator.Lock(linkConf.DeviceID); err != nil {
+			return cniError(types.ErrInternal, fmt.Sprintf("cmdDel() error obtaining lock for device [%s]", linkConf.DeviceID), err)
+		}
+		if err = delVF(&linkArgs, linkConf, linkRefPath); err != nil {
+			return err
+		}
+	}
+
+	if err := utils.CleanDeviceInfo(deviceInfoPath(bondConf, args.ContainerID, args.IfName)); err != nil {
+		return cniError(types.ErrIOFailure, "cmdDel() error removing device info", err)
+	}
+	_ = utils.CleanCachedNetConf(cRefPath)
+	return nil
+}
+
+// newBondDeviceInfo returns the device information of a bond attachment: the one of the VF of its first link, which
+// is the primary link in active-backup mode, with the pci addresses of the VFs of all links
+func newBondDeviceInfo(linkConfs []*sriovtypes.NetConf) *sriovtypes.DeviceInfo {
+	devInfo := newDeviceInfo(linkConfs[0])
+	if devInfo.Sriov == nil {
+		devInfo.Sriov = &sriovtypes.SriovDeviceInfo{PfName: linkConfs[0].Master, VfID: linkConfs[0].VFID}
+	}
+	for _, linkConf := range linkConfs {
+		devInfo.Sriov.BondLinks = append(devInfo.Sriov.BondLinks, linkConf.DeviceID)
+	}
+	return devInfo
+}
+
+// checkBondLinks checks that the VF of every link of the bond still has the configuration of its cached NetConf, and
+// that the bond is up in the pod netns with the configured mode and its links enslaved
+func checkBondLinks(args *skel.CmdArgs, bondConf *sriovtypes.NetConf) error {
+	sm := newSriovManager()
+	var drifted []string
+	linkIfNames := make([]string, 0, len(bondConf.Bond.Links))
+	for i := range bondConf.Bond.Links {
+		linkArgs := *args
+		linkArgs.IfName = bondConf.Bond.LinkIfName(args.IfName, i)
+		linkIfNames = append(linkIfNames, linkArgs.IfName)
+		linkConf, _, err := config.LoadConfFromCache(&linkArgs)
+		if err != nil {
+			return types.NewError(types.ErrUnknownContainer, "SRIOV-CNI failed to load cached netconf of bond link", err.Error())
+		}
+
+		linkDrifted, err := sm.CheckVFConfig(linkConf)
+		if err != nil {
+			return fmt.Errorf("failed to check VF configuration: %v", err)
+		}
+		for _, d := range linkDrifted {
+			drifted = append(drifted, fmt.Sprintf("%s: %s", linkArgs.IfName, d))
+		}
+	}
+
+	netns, err := ns.GetNS(args.Netns)
+	if err != nil {
+		return fmt.Errorf("failed to open netns %q: %v", args.Netns, err)
+	}
+	defer netns.Close()
+
+	bondDrifted, err := sm.CheckBond(bondConf, args.IfName, linkIfNames, netns)
+	if err != nil {
+		return err
+	}
+	for _, d := range bondDrifted {
+		drifted = append(drifted, fmt.Sprintf("%s: %s", args.IfName, d))
+	}
+
+	if len(drifted) > 0 {
+		return types.NewError(sriovtypes.ErrVfConfigDrift,
+			fmt.Sprintf("SRIOV-CNI VFs of bond %s configuration drifted from cached netconf", args.IfName),
+			strings.Join(drifted, "; "))
+	}
+
+	return nil
+}
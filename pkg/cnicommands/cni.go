@@ -1,14 +1,20 @@
 package cnicommands
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/containernetworking/cni/pkg/invoke"
 	"github.com/containernetworking/cni/pkg/skel"
 	"github.com/containernetworking/cni/pkg/types"
 	current "github.com/containernetworking/cni/pkg/types/100"
+	"github.com/containernetworking/plugins/pkg/ip"
 	"github.com/containernetworking/plugins/pkg/ipam"
 	"github.com/containernetworking/plugins/pkg/ns"
 	"github.com/vishvananda/netlink"
@@ -16,12 +22,28 @@ import (
 	"github.com/k8snetworkplumbingwg/sriov-cni/pkg/config"
 	"github.com/k8snetworkplumbingwg/sriov-cni/pkg/logging"
 	"github.com/k8snetworkplumbingwg/sriov-cni/pkg/sriov"
+	sriovtypes "github.com/k8snetworkplumbingwg/sriov-cni/pkg/types"
 	"github.com/k8snetworkplumbingwg/sriov-cni/pkg/utils"
 )
 
+// newSriovManager returns the manager the commands configure VFs with, tests replace it with a mock
+var newSriovManager = sriov.NewSriovManager
+
 type envArgs struct {
 	types.CommonArgs
-	MAC types.UnmarshallableString `json:"mac,omitempty"`
+	MAC               types.UnmarshallableString `json:"mac,omitempty"`
+	GUID              types.UnmarshallableString `json:"guid,omitempty"`
+	K8S_POD_NAMESPACE types.UnmarshallableString
+	K8S_POD_NAME      types.UnmarshallableString
+	K8S_POD_UID       types.UnmarshallableString
+}
+
+// pod returns the namespace, name and UID of the pod the runtime passed in CNI_ARGS, if any
+func (e *envArgs) pod() (namespace, name, uid string) {
+	if e == nil {
+		return "", "", ""
+	}
+	return string(e.K8S_POD_NAMESPACE), string(e.K8S_POD_NAME), string(e.K8S_POD_UID)
 }
 
 func getEnvArgs(envArgsString string) (*envArgs, error) {
@@ -36,186 +58,599 @@ func getEnvArgs(envArgsString string) (*envArgs, error) {
 	return nil, nil
 }
 
-func CmdAdd(args *skel.CmdArgs) error {
+// setLogging sets the global logging parameters for the attachment of args, including its pod if the runtime
+// passed one in CNI_ARGS
+func setLogging(args *skel.CmdArgs) error {
 	if err := config.SetLogging(args.StdinData, args.ContainerID, args.Netns, args.IfName); err != nil {
 		return err
 	}
+	// invalid CNI_ARGS are reported by the command, the log lines just go without the pod
+	if e, err := getEnvArgs(args.Args); err == nil {
+		logging.SetPod(e.pod())
+	}
+	return nil
+}
+
+// cniError returns err as a CNI error with the given message. The code of a CNI error wrapped by err is kept,
+// so that runtimes can tell e.g. a busy VF from an invalid configuration; code is used otherwise.
+func cniError(code uint, msg string, err error) *types.Error {
+	var cniErr *types.Error
+	if errors.As(err, &cniErr) {
+		code = cniErr.Code
+	}
+	return types.NewError(code, msg, err.Error())
+}
+
+func CmdAdd(args *skel.CmdArgs) error {
+	if err := setLogging(args); err != nil {
+		return err
+	}
 	logging.Debug("function called",
 		"func", "cmdAdd",
 		"args.Path", args.Path, "args.StdinData", string(args.StdinData), "args.Args", args.Args)
 
+	// json.Unmarshal drops unknown keys and applies keys that differ in case only, a misspelled setting would not be
+	// noticed otherwise. In strict mode LoadConf fails on them instead.
+	if unknownKeys, err := config.UnknownKeys(args.StdinData); err == nil {
+		for _, msg := range unknownKeys {
+			logging.Warning(msg, "func", "cmdAdd")
+		}
+	}
+
+	if config.IsBondConf(args.StdinData) {
+		return cmdAddBond(args)
+	}
+
 	netConf, err := config.LoadConf(args.StdinData)
 	if err != nil {
-		return fmt.Errorf("SRIOV-CNI failed to load netconf: %v", err)
+		return cniError(types.ErrInternal, "SRIOV-CNI failed to load netconf", err)
 	}
 
 	envArgs, err := getEnvArgs(args.Args)
 	if err != nil {
-		return fmt.Errorf("SRIOV-CNI failed to parse args: %v", err)
+		return cniError(types.ErrInvalidEnvironmentVariables, "SRIOV-CNI failed to parse args", err)
 	}
-
-	if envArgs != nil {
-		MAC := string(envArgs.MAC)
-		if MAC != "" {
-			netConf.MAC = MAC
-		}
+	if err = setRuntimeConfig(netConf, envArgs); err != nil {
+		return cniError(types.ErrInvalidNetworkConfig, "SRIOV-CNI invalid runtime config", err)
 	}
-
-	// RuntimeConfig takes preference than envArgs.
-	// This maintains compatibility of using envArgs
-	// for MAC config.
-	if netConf.RuntimeConfig.Mac != "" {
-		netConf.MAC = netConf.RuntimeConfig.Mac
+	if err = generateMAC(netConf, envArgs, args.ContainerID, args.IfName); err != nil {
+		return cniError(types.ErrInvalidNetworkConfig, "SRIOV-CNI failed to generate MAC address", err)
 	}
 
-	// Always use lower case for mac address
-	netConf.MAC = strings.ToLower(netConf.MAC)
+	prevResult, prevIPs, err := loadPrevResult(args, netConf)
+	if err != nil {
+		return err
+	}
 
 	netns, err := ns.GetNS(args.Netns)
 	if err != nil {
-		return fmt.Errorf("failed to open netns %q: %v", args.Netns, err)
+		return cniError(types.ErrInternal, fmt.Sprintf("failed to open netns %q", args.Netns), err)
 	}
 	defer netns.Close()
 
-	sm := sriov.NewSriovManager()
-	err = sm.FillOriginalVfInfo(netConf)
-	if err != nil {
-		return fmt.Errorf("failed to get original vf information: %v", err)
+	netConf.ContainerID = args.ContainerID
+	netConf.IfName = args.IfName
+	netConf.NetNS = args.Netns
+	netConf.PodNamespace, netConf.PodName, netConf.PodUID = envArgs.pod()
+
+	sm := newSriovManager()
+	if err = addVF(sm, netConf, args.IfName, netns); err != nil {
+		return err
 	}
 	defer func() {
 		if err != nil {
-			err := netns.Do(func(_ ns.NetNS) error {
-				_, err := netlink.LinkByName(args.IfName)
-				return err
-			})
-			if err == nil {
-				_ = sm.ReleaseVF(netConf, args.IfName, netns)
-			}
-			// Reset the VF if failure occurs before the netconf is cached
-			_ = sm.ResetVFConfig(netConf)
+			undoAddVF(sm, netConf, args.IfName, netns)
 		}
 	}()
-	if err := sm.ApplyVFConfig(netConf); err != nil {
-		return fmt.Errorf("SRIOV-CNI failed to configure VF %q", err)
-	}
 
+	// In a chain the VF is added to the result of the previous plugins
 	result := &current.Result{}
-	result.Interfaces = []*current.Interface{{
+	if prevResult != nil {
+		result = prevResult
+	}
+	podIfIndex := len(result.Interfaces)
+	podIf := &current.Interface{
 		Name:    args.IfName,
 		Sandbox: netns.Path(),
-	}}
-
-	if !netConf.DPDKMode {
-		err = sm.SetupVF(netConf, args.IfName, netns)
+	}
+	result.Interfaces = append(result.Interfaces, podIf)
 
-		if err != nil {
-			return fmt.Errorf("failed to set up pod interface %q from the device %q: %v", args.IfName, netConf.Master, err)
-		}
+	// report the VF representor as a host interface
+	if netConf.Representor != "" {
+		result.Interfaces = append(result.Interfaces, &current.Interface{Name: netConf.Representor})
 	}
 
-	result.Interfaces[0].Mac = config.GetMacAddressForResult(netConf)
+	podIf.Mac = config.GetMacAddressForResult(netConf)
+	// report the vhost-vdpa character device the pod has to open
+	if netConf.VdpaPath != "" {
+		podIf.SocketPath = netConf.VdpaPath
+	}
 	// check if we are able to find MTU for the virtual function
 	if netConf.MTU != nil {
-		result.Interfaces[0].Mtu = *netConf.MTU
+		podIf.Mtu = *netConf.MTU
 	}
 
-	doAnnounce := false
+	var podIfIPs []*current.IPConfig
 
 	// run the IPAM plugin
 	if netConf.IPAM.Type != "" {
-		var r types.Result
-		r, err = ipam.ExecAdd(netConf.IPAM.Type, args.StdinData)
+		podIfIPs, err = addIPAM(args, netConf, netns, result, podIfIndex)
 		if err != nil {
-			return fmt.Errorf("failed to set up IPAM plugin type %q from the device %q: %v", netConf.IPAM.Type, netConf.Master, err)
+			return err
 		}
-
 		defer func() {
 			if err != nil {
 				_ = ipam.ExecDel(netConf.IPAM.Type, args.StdinData)
 			}
 		}()
+	}
 
-		// Convert the IPAM result into the current Result type
-		var newResult *current.Result
-		newResult, err = current.NewResultFromResult(r)
+	// take over the IPs a previous plugin allocated
+	if netConf.UsePrevResultIPs {
+		podIfIPs = prevIPs
+		if err = addPrevIPs(netConf, netns, result, podIfIndex, prevIPs); err != nil {
+			return cniError(types.ErrInternal, "failed to configure the IPs of prevResult", err)
+		}
+	}
+
+	// create the VLAN sub-interfaces in the result and run their IPAM plugins
+	if len(netConf.Vlans) > 0 {
+		defer func() {
+			if err != nil {
+				_ = releaseVlanIPAM(args, netConf)
+			}
+		}()
+		if err = addVlanInterfaces(args, netConf, netns, result, podIf); err != nil {
+			return cniError(types.ErrInternal, "failed to set up VLAN sub-interfaces", err)
+		}
+	}
+
+	if err = saveVF(args, netConf, args.IfName); err != nil {
+		return err
+	}
+
+	if len(podIfIPs) > 0 && netConf.HasPodNetdev() {
+		announceIPs(netns, args.IfName, podIfIPs)
+	}
+
+	txRateLimiters := map[int]string{}
+	if netConf.TxRateLimiter != "" {
+		txRateLimiters[podIfIndex] = netConf.TxRateLimiter
+	}
+	return printResult(result, netConf.CNIVersion, txRateLimiters)
+}
+
+// printResult prints result in cniVersion like types.PrintResult, reporting the mechanism enforcing the tx rate of
+// the VF of an interface as "tx-rate-limiter" on the interfaces of result given by txRateLimiters. Results of
+// versions without interfaces are printed as they are.
+func printResult(result *current.Result, cniVersion string, txRateLimiters map[int]string) error {
+	if len(txRateLimiters) == 0 {
+		return types.PrintResult(result, cniVersion)
+	}
+
+	versionedResult, err := result.GetAsVersion(cniVersion)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(versionedResult)
+	if err != nil {
+		return err
+	}
+	out := map[string]any{}
+	if err = json.Unmarshal(data, &out); err != nil {
+		return err
+	}
+	interfaces, ok := out["interfaces"].([]any)
+	if !ok {
+		return versionedResult.Print()
+	}
+	for i, txRateLimiter := range txRateLimiters {
+		if i >= len(interfaces) {
+			continue
+		}
+		if iface, ok := interfaces[i].(map[string]any); ok {
+			iface["tx-rate-limiter"] = txRateLimiter
+		}
+	}
+
+	data, err = json.MarshalIndent(out, "", "    ")
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+// setRuntimeConfig sets the MAC address and GUID of the VF requested through the CNI args or the runtime config.
+// It fails if the resulting MAC address can not be set on the VF.
+func setRuntimeConfig(netConf *sriovtypes.NetConf, envArgs *envArgs) error {
+	if envArgs != nil {
+		MAC := string(envArgs.MAC)
+		if MAC != "" {
+			netConf.MAC = MAC
+		}
+		GUID := string(envArgs.GUID)
+		if GUID != "" {
+			netConf.GUID = GUID
+		}
+	}
+
+	// RuntimeConfig takes preference than envArgs.
+	// This maintains compatibility of using envArgs
+	// for MAC config.
+	if netConf.RuntimeConfig.Mac != "" {
+		netConf.MAC = netConf.RuntimeConfig.Mac
+	}
+
+	if netConf.RuntimeConfig.GUID != "" {
+		netConf.GUID = netConf.RuntimeConfig.GUID
+	}
+
+	// LoadConf only checked the MAC of the netconf, the one of envArgs may take its place
+	if netConf.MAC != "" {
+		mac, err := config.NormalizeMAC(netConf.MAC, netConf.LocalMACOnly)
 		if err != nil {
 			return err
 		}
-
-		if len(newResult.IPs) == 0 {
-			err = errors.New("IPAM plugin returned missing IP config")
+		netConf.MAC = mac
+	}
+	// the same goes for the GUID
+	if netConf.GUID != "" {
+		guid, err := config.NormalizeGUID(netConf.GUID)
+		if err != nil {
 			return err
 		}
+		netConf.GUID = guid
+	}
+	return nil
+}
 
-		newResult.Interfaces = result.Interfaces
+// generateMAC sets the MAC address of the VF derived from the identity of the attachment when macGeneration is
+// configured and no MAC address was requested
+func generateMAC(netConf *sriovtypes.NetConf, envArgs *envArgs, containerID, ifName string) error {
+	if netConf.MACGeneration == nil || netConf.MAC != "" {
+		return nil
+	}
 
-		for _, ipc := range newResult.IPs {
-			// All addresses apply to the container interface (move from host)
-			ipc.Interface = current.Int(0)
-		}
+	podNamespace, podName, _ := envArgs.pod()
+	mac, err := config.GenerateMAC(netConf.MACGeneration, netConf.Name, containerID, podNamespace, podName, ifName)
+	if err != nil {
+		return err
+	}
+	logging.Debug("Generated MAC address",
+		"func", "generateMAC",
+		"source", netConf.MACGeneration.Source,
+		"mac", mac)
+	netConf.MAC = mac
+	netConf.MACGenerated = true
+	return nil
+}
 
-		if !netConf.DPDKMode {
-			err = netns.Do(func(_ ns.NetNS) error {
-				return ipam.ConfigureIface(args.IfName, newResult)
-			})
+// addVF configures the VF of netConf and, if the pod gets a netdevice, moves it into netns as ifName.
+// The VF is returned to its original state if this fails.
+func addVF(sm sriov.Manager, netConf *sriovtypes.NetConf, ifName string, netns ns.NetNS) (err error) {
+	if netConf.VdpaType != "" {
+		if err = sm.SetupVdpaDevice(netConf); err != nil {
+			return cniError(types.ErrInternal, "failed to set up vDPA device", err)
+		}
+		defer func() {
 			if err != nil {
-				return err
+				_ = sm.ReleaseVdpaDevice(netConf)
+			}
+		}()
+	}
+
+	err = sm.FillOriginalVfInfo(netConf)
+	if err != nil {
+		return cniError(types.ErrInternal, "failed to get original vf information", err)
+	}
+
+	// Cache NetConf and mark the VF as in use before the VF is changed, so that the VF can be recovered
+	// if the plugin does not get to finish ADD, see Recover, and is not handed out again meanwhile
+	if err = utils.SaveNetConf(netConf.ContainerID, config.DefaultCNIDir, ifName, netConf); err != nil {
+		return cniError(types.ErrIOFailure, "error saving NetConf", err)
+	}
+	defer func() {
+		if err != nil {
+			releaseAddedVF(sm, netConf, ifName, netns)
+		}
+	}()
+	logging.Debug("Mark the PCI address as in use",
+		"func", "cmdAdd",
+		"config.DefaultCNIDir", config.DefaultCNIDir,
+		"netConf.DeviceID", netConf.DeviceID)
+	allocator := utils.NewPCIAllocator(config.DefaultCNIDir)
+	if err = allocator.SaveAllocation(netConf.DeviceID, newPCIAllocation(netConf)); err != nil {
+		return cniError(types.ErrIOFailure, fmt.Sprintf("error saving the pci allocation for vf pci address %s", netConf.DeviceID), err)
+	}
+
+	if err = sm.ApplyVFConfig(netConf); err != nil {
+		return cniError(types.ErrInternal, "SRIOV-CNI failed to configure VF", err)
+	}
+
+	if netConf.HasPodNetdev() {
+		err = sm.SetupVF(netConf, ifName, netns)
+
+		if err != nil {
+			return cniError(types.ErrInternal, fmt.Sprintf("failed to set up pod interface %q from the device %q", ifName, netConf.Master), err)
+		}
+	}
+
+	return nil
+}
+
+// undoAddVF returns a VF set up by addVF to its original state, when a later step of ADD fails
+func undoAddVF(sm sriov.Manager, netConf *sriovtypes.NetConf, ifName string, netns ns.NetNS) {
+	releaseAddedVF(sm, netConf, ifName, netns)
+	if netConf.VdpaType != "" {
+		_ = sm.ReleaseVdpaDevice(netConf)
+	}
+}
+
+// releaseAddedVF takes the VF out of netns, resets its configuration and removes its cached NetConf and PCI allocation
+func releaseAddedVF(sm sriov.Manager, netConf *sriovtypes.NetConf, ifName string, netns ns.NetNS) {
+	err := netns.Do(func(_ ns.NetNS) error {
+		_, err := netlink.LinkByName(ifName)
+		return err
+	})
+	if err == nil {
+		_ = sm.ReleaseVF(netConf, ifName, netns)
+	}
+	_ = sm.ResetVFConfig(netConf)
+	_ = utils.NewPCIAllocator(config.DefaultCNIDir).DeleteAllocatedPCI(netConf.DeviceID)
+	_ = utils.CleanCachedNetConf(cachedNetConfPath(netConf.ContainerID, ifName))
+}
+
+// newPCIAllocation returns the allocation of the VF of netConf to its attachment
+func newPCIAllocation(netConf *sriovtypes.NetConf) *utils.PCIAllocationInfo {
+	return &utils.PCIAllocationInfo{
+		NetNS:        netConf.NetNS,
+		ContainerID:  netConf.ContainerID,
+		IfName:       netConf.IfName,
+		PodNamespace: netConf.PodNamespace,
+		PodName:      netConf.PodName,
+		PodUID:       netConf.PodUID,
+	}
+}
+
+// allocatedElsewhere returns true if the VF of netConf is allocated to another netns than the one of netConf, i.e.
+// the VF was handed out again after the netns of netConf was destroyed. Such a VF must not be released on behalf of
+// netConf. The caller has to hold the lock of the VF.
+func allocatedElsewhere(allocator *utils.PCIAllocator, netConf *sriovtypes.NetConf) bool {
+	// NetConfs cached by older versions of the plugin do not record their netns
+	if netConf.NetNS == "" {
+		return false
+	}
+	allocation, err := allocator.ReadAllocation(netConf.DeviceID)
+	if err != nil {
+		return false
+	}
+	return allocation.NetNS != netConf.NetNS
+}
+
+// deviceInfoPath returns the path of the device information of the attachment, the file the meta plugin passed in the
+// runtime config if any
+func deviceInfoPath(netConf *sriovtypes.NetConf, containerID, ifName string) string {
+	if netConf.RuntimeConfig.CNIDeviceInfoFile != "" {
+		return netConf.RuntimeConfig.CNIDeviceInfoFile
+	}
+	return utils.DeviceInfoPath(config.DefaultDeviceInfoDir, netConf.Name, containerID, ifName)
+}
+
+// cachedNetConfPath returns the path of the NetConf cached for the attachment
+func cachedNetConfPath(containerID, ifName string) string {
+	return filepath.Join(config.DefaultCNIDir, strings.Join([]string{containerID, ifName}, "-"))
+}
+
+// loadPrevResult returns the result of the previous plugins of a chain, or nil if the plugin is not chained, along
+// with the IPs of the result to configure on the VF
+func loadPrevResult(args *skel.CmdArgs, netConf *sriovtypes.NetConf) (*current.Result, []*current.IPConfig, error) {
+	prevResult, err := config.LoadPrevResult(args.StdinData)
+	if err != nil {
+		return nil, nil, cniError(types.ErrDecodingFailure, "SRIOV-CNI failed to load prevResult", err)
+	}
+	if !netConf.UsePrevResultIPs {
+		return prevResult, nil, nil
+	}
+
+	var prevIPs []*current.IPConfig
+	if prevResult != nil {
+		for _, ipc := range prevResult.IPs {
+			if ipc.Interface == nil {
+				prevIPs = append(prevIPs, ipc)
 			}
-			doAnnounce = true
 		}
-		result = newResult
 	}
+	if len(prevIPs) == 0 {
+		return nil, nil, types.NewError(types.ErrInvalidNetworkConfig, "SRIOV-CNI usePrevResultIPs requires a prevResult with IPs not bound to an interface", "")
+	}
+	return prevResult, prevIPs, nil
+}
+
+// addIPAM runs the IPAM plugin and adds the addresses and routes it returns to result, for the pod interface at
+// podIfIndex of result. The addresses are configured on the pod interface, if the pod has a netdevice. It returns
+// the added addresses.
+func addIPAM(args *skel.CmdArgs, netConf *sriovtypes.NetConf, netns ns.NetNS, result *current.Result, podIfIndex int) (ips []*current.IPConfig, err error) {
+	r, err := ipam.ExecAdd(netConf.IPAM.Type, args.StdinData)
+	if err != nil {
+		return nil, cniError(types.ErrInternal, fmt.Sprintf("failed to set up IPAM plugin type %q from the device %q", netConf.IPAM.Type, netConf.Master), err)
+	}
+
+	defer func() {
+		if err != nil {
+			_ = ipam.ExecDel(netConf.IPAM.Type, args.StdinData)
+		}
+	}()
+
+	// Convert the IPAM result into the current Result type
+	ipamResult, err := current.NewResultFromResult(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ipamResult.IPs) == 0 {
+		return nil, errors.New("IPAM plugin returned missing IP config")
+	}
+
+	for _, ipc := range ipamResult.IPs {
+		// All addresses apply to the container interface (move from host)
+		ipc.Interface = current.Int(podIfIndex)
+	}
+
+	if netConf.HasPodNetdev() {
+		if err = configureIface(netns, result.Interfaces[podIfIndex], ipamResult.IPs, ipamResult.Routes); err != nil {
+			return nil, err
+		}
+	}
+
+	result.IPs = append(result.IPs, ipamResult.IPs...)
+	result.Routes = append(result.Routes, ipamResult.Routes...)
+	if result.DNS.IsEmpty() {
+		result.DNS = ipamResult.DNS
+	}
+
+	return ipamResult.IPs, nil
+}
+
+// addPrevIPs binds prevIPs, IPs of result allocated by a previous plugin, to the pod interface at podIfIndex of
+// result and configures them on it, if the pod has a netdevice
+func addPrevIPs(netConf *sriovtypes.NetConf, netns ns.NetNS, result *current.Result, podIfIndex int, prevIPs []*current.IPConfig) error {
+	for _, ipc := range prevIPs {
+		ipc.Interface = current.Int(podIfIndex)
+	}
+	if !netConf.HasPodNetdev() {
+		return nil
+	}
+	// the routes of the previous plugins may belong to their interfaces, leave them alone
+	return configureIface(netns, result.Interfaces[podIfIndex], prevIPs, nil)
+}
+
+// configureIface configures ips and routes on the pod interface iface. The other interfaces and addresses of a
+// chained result are left alone.
+func configureIface(netns ns.NetNS, iface *current.Interface, ips []*current.IPConfig, routes []*types.Route) error {
+	ifResult := &current.Result{
+		Interfaces: []*current.Interface{iface},
+		Routes:     routes,
+	}
+	for _, ipc := range ips {
+		ifIPC := *ipc
+		ifIPC.Interface = current.Int(0)
+		ifResult.IPs = append(ifResult.IPs, &ifIPC)
+	}
+
+	return netns.Do(func(_ ns.NetNS) error {
+		return ipam.ConfigureIface(iface.Name, ifResult)
+	})
+}
+
+// saveVF publishes the device information of the VF and caches the final netConf for CmdDel
+func saveVF(args *skel.CmdArgs, netConf *sriovtypes.NetConf, ifName string) (err error) {
+	// Publish the device information of the VF
+	devInfoPath := deviceInfoPath(netConf, args.ContainerID, ifName)
+	logging.Debug("Save device info",
+		"func", "cmdAdd",
+		"devInfoPath", devInfoPath,
+		"netConf.DeviceID", netConf.DeviceID)
+	if err = utils.SaveDeviceInfo(devInfoPath, newDeviceInfo(netConf)); err != nil {
+		return cniError(types.ErrIOFailure, "error saving device info", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = utils.CleanDeviceInfo(devInfoPath)
+		}
+	}()
 
 	// Cache NetConf for CmdDel
 	logging.Debug("Cache NetConf for CmdDel",
 		"func", "cmdAdd",
 		"config.DefaultCNIDir", config.DefaultCNIDir,
 		"netConf", netConf)
-	if err = utils.SaveNetConf(args.ContainerID, config.DefaultCNIDir, args.IfName, netConf); err != nil {
-		return fmt.Errorf("error saving NetConf %q", err)
+	if err = utils.SaveNetConf(args.ContainerID, config.DefaultCNIDir, ifName, netConf); err != nil {
+		return cniError(types.ErrIOFailure, "error saving NetConf", err)
 	}
 
-	// Mark the pci address as in use.
-	logging.Debug("Mark the PCI address as in use",
-		"func", "cmdAdd",
-		"config.DefaultCNIDir", config.DefaultCNIDir,
-		"netConf.DeviceID", netConf.DeviceID)
-	allocator := utils.NewPCIAllocator(config.DefaultCNIDir)
-	if err = allocator.SaveAllocatedPCI(netConf.DeviceID, args.Netns); err != nil {
-		return fmt.Errorf("error saving the pci allocation for vf pci address %s: %v", netConf.DeviceID, err)
-	}
-
-	if doAnnounce {
-		_ = netns.Do(func(_ ns.NetNS) error {
-			/* After IPAM configuration is done, the following needs to handle the case of an IP address being reused by a different pods.
-			 * This is achieved by sending Gratuitous ARPs and/or Unsolicited Neighbor Advertisements unconditionally.
-			 * Although we set arp_notify and ndisc_notify unconditionally on the interface (please see EnableArpAndNdiscNotify()), the kernel
-			 * only sends GARPs/Unsolicited NA when the interface goes from down to up, or when the link-layer address changes on the interfaces.
-			 * These scenarios are perfectly valid and recommended to be enabled for optimal network performance.
-			 * However for our specific case, which the kernel is unaware of, is the reuse of IP addresses across pods where each pod has a different
-			 * link-layer address for it's SRIOV interface. The ARP/Neighbor cache residing in neighbors would be invalid if an IP address is reused.
-			 * In order to update the cache, the GARP/Unsolicited NA packets should be sent for performance reasons. Otherwise, the neighbors
-			 * may be sending packets with the incorrect link-layer address. Eventually, most network stacks would send ARPs and/or Neighbor
-			 * Solicitation packets when the connection is unreachable. This would correct the invalid cache; however this may take a significant
-			 * amount of time to complete.
-			 */
-
-			/* The interface might not yet have carrier. Wait for it for a short time. */
-			hasCarrier := utils.WaitForCarrier(args.IfName, 200*time.Millisecond)
-
-			/* The error is ignored here because enabling this feature is only a performance enhancement. */
-			err := utils.AnnounceIPs(args.IfName, result.IPs)
-
-			logging.Debug("announcing IPs", "hasCarrier", hasCarrier, "IPs", result.IPs, "announceError", err)
-			return nil
-		})
+	return nil
+}
+
+// announceIPs sends gratuitous ARPs and unsolicited neighbor advertisements for the addresses of the pod interface
+func announceIPs(netns ns.NetNS, ifName string, ips []*current.IPConfig) {
+	_ = netns.Do(func(_ ns.NetNS) error {
+		/* After IPAM configuration is done, the following needs to handle the case of an IP address being reused by a different pods.
+		 * This is achieved by sending Gratuitous ARPs and/or Unsolicited Neighbor Advertisements unconditionally.
+		 * Although we set arp_notify and ndisc_notify unconditionally on the interface (please see EnableArpAndNdiscNotify()), the kernel
+		 * only sends GARPs/Unsolicited NA when the interface goes from down to up, or when the link-layer address changes on the interfaces.
+		 * These scenarios are perfectly valid and recommended to be enabled for optimal network performance.
+		 * However for our specific case, which the kernel is unaware of, is the reuse of IP addresses across pods where each pod has a different
+		 * link-layer address for it's SRIOV interface. The ARP/Neighbor cache residing in neighbors would be invalid if an IP address is reused.
+		 * In order to update the cache, the GARP/Unsolicited NA packets should be sent for performance reasons. Otherwise, the neighbors
+		 * may be sending packets with the incorrect link-layer address. Eventually, most network stacks would send ARPs and/or Neighbor
+		 * Solicitation packets when the connection is unreachable. This would correct the invalid cache; however this may take a significant
+		 * amount of time to complete.
+		 */
+
+		/* The interface might not yet have carrier. Wait for it for a short time. */
+		hasCarrier := utils.WaitForCarrier(ifName, 200*time.Millisecond)
+
+		/* The error is ignored here because enabling this feature is only a performance enhancement. */
+		err := utils.AnnounceIPs(ifName, ips)
+
+		logging.Debug("announcing IPs", "hasCarrier", hasCarrier, "IPs", ips, "announceError", err)
+		return nil
+	})
+}
+
+// newDeviceInfo returns the device information of the VF described by netConf
+func newDeviceInfo(netConf *sriovtypes.NetConf) *sriovtypes.DeviceInfo {
+	if netConf.IsSF() {
+		// the PF pci address is informational only, leave it out if it can't be found
+		pfPciAddress, _ := utils.GetSfPfPciAddress(netConf.DeviceID)
+		return &sriovtypes.DeviceInfo{
+			Type:    sriovtypes.DeviceInfoTypeAux,
+			Version: sriovtypes.DeviceInfoVersion,
+			Aux: &sriovtypes.AuxDeviceInfo{
+				DeviceID:     netConf.DeviceID,
+				PfPciAddress: pfPciAddress,
+				PfName:       netConf.Master,
+				SfNum:        *netConf.SFNum,
+			},
+		}
 	}
 
-	return types.PrintResult(result, netConf.CNIVersion)
+	// the PF pci address is informational only, leave it out if it can't be found
+	pfPciAddress, _ := utils.GetPfPciAddress(netConf.DeviceID)
+
+	devInfo := &sriovtypes.DeviceInfo{
+		Type:    sriovtypes.DeviceInfoTypePCI,
+		Version: sriovtypes.DeviceInfoVersion,
+		Pci: &sriovtypes.PciDeviceInfo{
+			PciAddress:   netConf.DeviceID,
+			PfPciAddress: pfPciAddress,
+			RdmaDevice:   netConf.RdmaDevice,
+			Representor:  netConf.Representor,
+		},
+		Sriov: &sriovtypes.SriovDeviceInfo{
+			PfName:      netConf.Master,
+			VfID:        netConf.VFID,
+			Dpdk:        netConf.DPDKMode,
+			TxRateLimit: netConf.TxRateLimiter,
+		},
+	}
+
+	if netConf.VdpaDevice != "" {
+		devInfo.Type = sriovtypes.DeviceInfoTypeVDPA
+		devInfo.Vdpa = &sriovtypes.VdpaDeviceInfo{
+			ParentDevice: netConf.VdpaDevice,
+			Driver:       netConf.VdpaType,
+			Path:         netConf.VdpaPath,
+			PciAddress:   netConf.DeviceID,
+		}
+	}
+
+	return devInfo
 }
 
 func CmdDel(args *skel.CmdArgs) error {
-	if err := config.SetLogging(args.StdinData, args.ContainerID, args.Netns, args.IfName); err != nil {
+	if err := setLogging(args); err != nil {
 		return err
 	}
 	logging.Debug("function called",
@@ -237,28 +672,47 @@ func CmdDel(args *skel.CmdArgs) error {
 		return nil
 	}
 
+	if netConf.Bond != nil {
+		return cmdDelBond(args, netConf, cRefPath)
+	}
+
 	allocator := utils.NewPCIAllocator(config.DefaultCNIDir)
 
 	err = allocator.Lock(netConf.DeviceID)
 	if err != nil {
-		return fmt.Errorf("cmdDel() error obtaining lock for device [%s]: %w", netConf.DeviceID, err)
+		return cniError(types.ErrInternal, fmt.Sprintf("cmdDel() error obtaining lock for device [%s]", netConf.DeviceID), err)
 	}
 
 	logging.Debug("Acquired device lock",
 		"func", "cmdDel",
 		"DeviceID", netConf.DeviceID)
 
+	if netConf.IPAM.Type != "" {
+		err = ipam.ExecDel(netConf.IPAM.Type, args.StdinData)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = releaseVlanIPAM(args, netConf); err != nil {
+		return err
+	}
+
+	return delVF(args, netConf, cRefPath)
+}
+
+// delVF releases the VF of netConf, which has to be locked by the caller: it resets the VF configuration, returns
+// its netdevice from the pod netns, marks the VF as released and removes the cached NetConf at cRefPath
+func delVF(args *skel.CmdArgs, netConf *sriovtypes.NetConf, cRefPath string) (err error) {
+	// a failed DEL is retried by the runtime, which needs the cached NetConf
 	defer func() {
 		if err == nil && cRefPath != "" {
 			_ = utils.CleanCachedNetConf(cRefPath)
 		}
 	}()
 
-	if netConf.IPAM.Type != "" {
-		err = ipam.ExecDel(netConf.IPAM.Type, args.StdinData)
-		if err != nil {
-			return err
-		}
+	if err = utils.CleanDeviceInfo(deviceInfoPath(netConf, args.ContainerID, args.IfName)); err != nil {
+		return cniError(types.ErrIOFailure, "cmdDel() error removing device info", err)
 	}
 
 	// https://github.com/kubernetes/kubernetes/pull/35240
@@ -266,12 +720,23 @@ func CmdDel(args *skel.CmdArgs) error {
 		return nil
 	}
 
+	allocator := utils.NewPCIAllocator(config.DefaultCNIDir)
+	if allocatedElsewhere(allocator, netConf) {
+		logging.Info("Leaving the VF untouched as it is allocated to another netns",
+			"func", "cmdDel",
+			"netConf.DeviceID", netConf.DeviceID,
+			"netConf.NetNS", netConf.NetNS)
+		return nil
+	}
+
 	// Verify VF ID existence.
-	if _, err := utils.GetVfid(netConf.DeviceID, netConf.Master); err != nil {
-		return fmt.Errorf("cmdDel() error obtaining VF ID: %q", err)
+	if !netConf.IsSF() {
+		if _, err := utils.GetVfid(netConf.DeviceID, netConf.Master); err != nil {
+			return cniError(sriovtypes.ErrPfNotFound, "cmdDel() error obtaining VF ID", err)
+		}
 	}
 
-	sm := sriov.NewSriovManager()
+	sm := newSriovManager()
 
 	logging.Debug("Reset VF configuration",
 		"func", "cmdDel",
@@ -281,10 +746,10 @@ func CmdDel(args *skel.CmdArgs) error {
 	   reset netdev VF with trust off. So, reset VF MAC address via PF first.
 	*/
 	if err := sm.ResetVFConfig(netConf); err != nil {
-		return fmt.Errorf("cmdDel() error reseting VF: %q", err)
+		return cniError(types.ErrInternal, "cmdDel() error reseting VF", err)
 	}
 
-	if !netConf.DPDKMode {
+	if netConf.HasPodNetdev() {
 		netns, err := ns.GetNS(args.Netns)
 		if err != nil {
 			// according to:
@@ -293,26 +758,37 @@ func CmdDel(args *skel.CmdArgs) error {
 			// plugin should silently return with success after releasing
 			// IPAM resources
 			_, ok := err.(ns.NSPathNotExistErr)
-			if ok {
-				logging.Debug("Exiting as the network namespace does not exists anymore",
+			if !ok {
+				return cniError(types.ErrInternal, fmt.Sprintf("failed to open netns %q", args.Netns), err)
+			}
+
+			logging.Debug("Restoring the VF in the init netns as the network namespace does not exists anymore",
+				"func", "cmdDel",
+				"netConf.DeviceID", netConf.DeviceID,
+				"args.Netns", args.Netns)
+			// DEL has to succeed without the netns
+			if err := restoreVFInInitNS(sm, netConf); err != nil {
+				logging.Warning("Failed to restore the VF in the init netns",
 					"func", "cmdDel",
 					"netConf.DeviceID", netConf.DeviceID,
-					"args.Netns", args.Netns)
-				return nil
+					"error", err)
 			}
+		} else {
+			defer netns.Close()
 
-			return fmt.Errorf("failed to open netns %q: %v", args.Netns, err)
+			logging.Debug("Release the VF",
+				"func", "cmdDel",
+				"netConf.DeviceID", netConf.DeviceID,
+				"args.Netns", args.Netns,
+				"args.IfName", args.IfName)
+			if err := sm.ReleaseVF(netConf, args.IfName, netns); err != nil {
+				return err
+			}
 		}
-		defer netns.Close()
+	}
 
-		logging.Debug("Release the VF",
-			"func", "cmdDel",
-			"netConf.DeviceID", netConf.DeviceID,
-			"args.Netns", args.Netns,
-			"args.IfName", args.IfName)
-		if err := sm.ReleaseVF(netConf, args.IfName, netns); err != nil {
-			return err
-		}
+	if err := sm.ReleaseVdpaDevice(netConf); err != nil {
+		return cniError(types.ErrInternal, "cmdDel() error releasing vDPA device", err)
 	}
 
 	// Mark the pci address as released
@@ -320,13 +796,325 @@ func CmdDel(args *skel.CmdArgs) error {
 		"func", "cmdDel",
 		"config.DefaultCNIDir", config.DefaultCNIDir,
 		"netConf.DeviceID", netConf.DeviceID)
-	if err = allocator.DeleteAllocatedPCI(netConf.DeviceID); err != nil {
-		return fmt.Errorf("error cleaning the pci allocation for vf pci address %s: %v", netConf.DeviceID, err)
+	// the allocation of a destroyed netns may have been dropped already, see PCIAllocator.IsAllocated
+	err = allocator.DeleteAllocatedPCI(netConf.DeviceID)
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+	}
+	if err != nil {
+		return cniError(types.ErrIOFailure, fmt.Sprintf("error cleaning the pci allocation for vf pci address %s", netConf.DeviceID), err)
 	}
 
 	return nil
 }
 
-func CmdCheck(_ *skel.CmdArgs) error {
+// restoreVFInInitNS restores the VF netdevice that went back to the init netns under its pod interface name when
+// the netns was destroyed
+func restoreVFInInitNS(sm sriov.Manager, netConf *sriovtypes.NetConf) error {
+	var errs []error
+	// The host name of a virtio vDPA netdevice is not the one of the VF netdevice
+	if netConf.VdpaType != sriovtypes.VdpaTypeVirtio {
+		if err := sm.RestoreHostIFName(netConf); err != nil {
+			errs = append(errs, fmt.Errorf("error restoring host name of VF %s: %v", netConf.DeviceID, err))
+		}
+	}
+	if err := sm.RestoreRepresentor(netConf); err != nil {
+		errs = append(errs, fmt.Errorf("error restoring representor of VF %s: %v", netConf.DeviceID, err))
+	}
+	return errors.Join(errs...)
+}
+
+func CmdCheck(args *skel.CmdArgs) error {
+	if err := setLogging(args); err != nil {
+		return err
+	}
+	logging.Debug("function called",
+		"func", "cmdCheck",
+		"args.Path", args.Path, "args.StdinData", string(args.StdinData), "args.Args", args.Args)
+
+	netConf, _, err := config.LoadConfFromCache(args)
+	if err != nil {
+		return types.NewError(types.ErrUnknownContainer, "SRIOV-CNI failed to load cached netconf", err.Error())
+	}
+
+	prevResult, err := config.LoadPrevResult(args.StdinData)
+	if err != nil {
+		return types.NewError(types.ErrDecodingFailure, "SRIOV-CNI failed to load prevResult", err.Error())
+	}
+	if prevResult == nil {
+		return types.NewError(types.ErrInvalidNetworkConfig, "SRIOV-CNI required prevResult missing", "")
+	}
+
+	if netConf.IPAM.Type != "" {
+		if err := ipam.ExecCheck(netConf.IPAM.Type, args.StdinData); err != nil {
+			return err
+		}
+	}
+
+	if netConf.Bond != nil {
+		return checkBondLinks(args, netConf)
+	}
+
+	sm := newSriovManager()
+
+	drifted, err := sm.CheckVFConfig(netConf)
+	if err != nil {
+		return fmt.Errorf("failed to check VF configuration: %v", err)
+	}
+
+	if netConf.HasPodNetdev() {
+		podIfIndex := -1
+		for idx, intf := range prevResult.Interfaces {
+			if intf.Name == args.IfName && intf.Sandbox == args.Netns {
+				podIfIndex = idx
+				break
+			}
+		}
+		if podIfIndex < 0 {
+			return types.NewError(types.ErrInvalidNetworkConfig,
+				fmt.Sprintf("SRIOV-CNI interface %s in netns %s not found in prevResult", args.IfName, args.Netns), "")
+		}
+
+		netns, err := ns.GetNS(args.Netns)
+		if err != nil {
+			return fmt.Errorf("failed to open netns %q: %v", args.Netns, err)
+		}
+		defer netns.Close()
+
+		podIfDrifted, err := sm.CheckVF(prevResult.Interfaces[podIfIndex], netns)
+		if err != nil {
+			return err
+		}
+		drifted = append(drifted, podIfDrifted...)
+
+		var podIfIPs []*current.IPConfig
+		for _, ipc := range prevResult.IPs {
+			if ipc.Interface != nil && *ipc.Interface == podIfIndex {
+				podIfIPs = append(podIfIPs, ipc)
+			}
+		}
+
+		err = netns.Do(func(_ ns.NetNS) error {
+			return ip.ValidateExpectedInterfaceIPs(args.IfName, podIfIPs)
+		})
+		if err != nil {
+			drifted = append(drifted, fmt.Sprintf("ips: %v", err))
+		}
+	}
+
+	if len(drifted) > 0 {
+		return types.NewError(sriovtypes.ErrVfConfigDrift,
+			fmt.Sprintf("SRIOV-CNI VF %s configuration drifted from cached netconf", netConf.DeviceID),
+			strings.Join(drifted, "; "))
+	}
+
+	return nil
+}
+
+func CmdGC(args *skel.CmdArgs) error {
+	if err := setLogging(args); err != nil {
+		return err
+	}
+	logging.Debug("function called",
+		"func", "cmdGC",
+		"args.Path", args.Path, "args.StdinData", string(args.StdinData), "args.Args", args.Args)
+
+	gcConf := &types.NetConf{}
+	if err := json.Unmarshal(args.StdinData, gcConf); err != nil {
+		return types.NewError(types.ErrDecodingFailure, "SRIOV-CNI failed to load netconf", err.Error())
+	}
+
+	validAttachments := make(map[string]bool, len(gcConf.ValidAttachments))
+	for _, attachment := range gcConf.ValidAttachments {
+		validAttachments[strings.Join([]string{attachment.ContainerID, attachment.IfName}, "-")] = true
+	}
+
+	cachedConfs, err := config.LoadConfsFromCache()
+	if err != nil {
+		return err
+	}
+
+	// A VF is still in use if any attachment we are not collecting references it,
+	// e.g. it was allocated to a new pod after the stale one lost its netns.
+	staleConfs := make(map[string]*sriovtypes.NetConf)
+	inUseDevices := make(map[string]bool)
+	var vlanAttachments []types.GCAttachment
+	for cRefPath, netConf := range cachedConfs {
+		// the links of a bond are valid as long as the bond is
+		attachment := filepath.Base(cRefPath)
+		if netConf.BondIfName != "" {
+			attachment = strings.Join([]string{netConf.ContainerID, netConf.BondIfName}, "-")
+		}
+		if netConf.Name == gcConf.Name && !validAttachments[attachment] {
+			staleConfs[cRefPath] = netConf
+			continue
+		}
+		inUseDevices[netConf.DeviceID] = true
+		if netConf.Name == gcConf.Name {
+			for i := range netConf.Vlans {
+				vlanAttachments = append(vlanAttachments, types.GCAttachment{
+					ContainerID: netConf.ContainerID,
+					IfName:      netConf.Vlans[i].IfName(netConf.IfName),
+				})
+			}
+		}
+	}
+
+	var errs []error
+	for cRefPath, netConf := range staleConfs {
+		logging.Info("Garbage collecting stale attachment",
+			"func", "cmdGC",
+			"cRefPath", cRefPath,
+			"netConf.DeviceID", netConf.DeviceID)
+		if err := releaseStaleAttachment(netConf, cRefPath, inUseDevices[netConf.DeviceID]); err != nil {
+			errs = append(errs, err)
+		}
+		// Several stale attachments may reference the same VF, release it only once
+		inUseDevices[netConf.DeviceID] = true
+	}
+
+	if gcConf.IPAM.Type != "" {
+		gcStdinData, err := vlanGCConf(args.StdinData, gcConf, vlanAttachments)
+		if err != nil {
+			errs = append(errs, err)
+		} else if err := invoke.DelegateGC(context.TODO(), gcConf.IPAM.Type, gcStdinData, nil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// releaseStaleAttachment returns the VF of an attachment the runtime no longer knows about to its original state
+// and removes the attachment cache and PCI allocation. The VF itself is left untouched if it is in use by another
+// attachment, or allocated to another netns.
+func releaseStaleAttachment(netConf *sriovtypes.NetConf, cRefPath string, inUse bool) error {
+	// a bond has no VF of its own, its links are released as stale attachments of their own
+	if netConf.Bond != nil {
+		if err := utils.CleanDeviceInfo(deviceInfoPath(netConf, netConf.ContainerID, netConf.IfName)); err != nil {
+			return err
+		}
+		return utils.CleanCachedNetConf(cRefPath)
+	}
+
+	allocator := utils.NewPCIAllocator(config.DefaultCNIDir)
+	if !inUse {
+		if err := allocator.Lock(netConf.DeviceID); err != nil {
+			return fmt.Errorf("error obtaining lock for device [%s]: %w", netConf.DeviceID, err)
+		}
+		inUse = allocatedElsewhere(allocator, netConf)
+	}
+
+	if !inUse {
+		sm := newSriovManager()
+		if err := sm.ResetVFConfig(netConf); err != nil {
+			return fmt.Errorf("error resetting VF %s: %v", netConf.DeviceID, err)
+		}
+
+		// The netdev is either still in a leaked pod netns or, if that netns is gone,
+		// back in the init netns under its pod interface name.
+		released := false
+		if netConf.HasPodNetdev() && netConf.NetNS != "" && netConf.IfName != "" {
+			if netns, err := ns.GetNS(netConf.NetNS); err == nil {
+				err = sm.ReleaseVF(netConf, netConf.IfName, netns)
+				netns.Close()
+				if err != nil {
+					logging.Warning("Failed to release VF from netns",
+						"func", "releaseStaleAttachment",
+						"netConf.NetNS", netConf.NetNS,
+						"error", err)
+				} else {
+					released = true
+				}
+			}
+		}
+
+		if !released {
+			if err := restoreVFInInitNS(sm, netConf); err != nil {
+				return err
+			}
+		}
+
+		if err := sm.ReleaseVdpaDevice(netConf); err != nil {
+			return fmt.Errorf("error releasing vDPA device of VF %s: %v", netConf.DeviceID, err)
+		}
+
+		if err := allocator.DeleteAllocatedPCI(netConf.DeviceID); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("error cleaning the pci allocation for vf pci address %s: %v", netConf.DeviceID, err)
+		}
+	}
+
+	if err := utils.CleanDeviceInfo(deviceInfoPath(netConf, netConf.ContainerID, netConf.IfName)); err != nil {
+		return err
+	}
+
+	return utils.CleanCachedNetConf(cRefPath)
+}
+
+func CmdStatus(args *skel.CmdArgs) error {
+	if err := setLogging(args); err != nil {
+		return err
+	}
+	logging.Debug("function called",
+		"func", "cmdStatus",
+		"args.Path", args.Path, "args.StdinData", string(args.StdinData), "args.Args", args.Args)
+
+	netConf := &sriovtypes.NetConf{}
+	if err := json.Unmarshal(args.StdinData, netConf); err != nil {
+		return types.NewError(types.ErrDecodingFailure, "SRIOV-CNI failed to load netconf", err.Error())
+	}
+
+	if err := utils.CheckDirWritable(config.DefaultCNIDir); err != nil {
+		return types.NewError(sriovtypes.ErrPluginNotAvailable,
+			fmt.Sprintf("SRIOV-CNI data directory %s is not writable", config.DefaultCNIDir), err.Error())
+	}
+
+	// the IPAM plugins of the pod interface and of its VLAN sub-interfaces
+	ipamTypes := []string{netConf.IPAM.Type}
+	for i := range netConf.Vlans {
+		ipamTypes = append(ipamTypes, netConf.Vlans[i].IPAMType())
+	}
+	for _, ipamType := range ipamTypes {
+		if ipamType == "" {
+			continue
+		}
+		if _, err := invoke.FindInPath(ipamType, filepath.SplitList(args.Path)); err != nil {
+			return types.NewError(sriovtypes.ErrPluginNotAvailable,
+				fmt.Sprintf("SRIOV-CNI IPAM plugin %s not found", ipamType), err.Error())
+		}
+	}
+
+	// the PFs of the VF, or of the links of a bond
+	pfNames := statusPFNames(netConf.DeviceID, netConf.Master, netConf.PFNames)
+	if netConf.Bond != nil {
+		for _, link := range netConf.Bond.Links {
+			pfNames = append(pfNames, statusPFNames(link.DeviceID, link.Master, link.PFNames)...)
+		}
+	}
+	for _, pfName := range pfNames {
+		numVfs, err := utils.GetSriovNumVfs(pfName)
+		if err != nil {
+			return types.NewError(sriovtypes.ErrPluginNotAvailable,
+				fmt.Sprintf("SRIOV-CNI failed to get the number of VFs of PF %s", pfName), err.Error())
+		}
+		if numVfs == 0 {
+			return types.NewError(sriovtypes.ErrPluginNotAvailable,
+				fmt.Sprintf("SRIOV-CNI PF %s has no VFs configured", pfName), "")
+		}
+	}
+
 	return nil
 }
+
+// statusPFNames returns the PFs a VF is selected from, the PF of the VF given by deviceID if it can be found
+func statusPFNames(deviceID, master string, pfNames []string) []string {
+	if master != "" {
+		return append(pfNames, master)
+	}
+	if deviceID != "" {
+		if pfName, err := utils.GetPfName(deviceID); err == nil {
+			return append(pfNames, pfName)
+		}
+	}
+	return pfNames
+}
@@ -0,0 +1,896 @@
+package cnicommands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/containernetworking/cni/pkg/skel"
+	"github.com/containernetworking/cni/pkg/types"
+	current "github.com/containernetworking/cni/pkg/types/100"
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/containernetworking/plugins/pkg/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+	"github.com/vishvananda/netlink"
+
+	"github.com/k8snetworkplumbingwg/sriov-cni/pkg/config"
+	sriovtypes "github.com/k8snetworkplumbingwg/sriov-cni/pkg/types"
+	"github.com/k8snetworkplumbingwg/sriov-cni/pkg/utils"
+)
+
+// cacheAttachment caches the NetConf of an attachment of the VF 0000:af:06.0 of the network name and allocates the
+// VF to netnsPath, as ADD does
+func cacheAttachment(name, containerID, ifName, netnsPath string) *sriovtypes.NetConf {
+	netConf := &sriovtypes.NetConf{}
+	netConf.CNIVersion = "1.0.0"
+	netConf.Name = name
+	netConf.Type = "sriov"
+	netConf.DeviceID = "0000:af:06.0"
+	netConf.Master = "enp175s0f1"
+	netConf.OrigVfState.HostIFName = "enp175s6"
+	netConf.ContainerID = containerID
+	netConf.IfName = ifName
+	netConf.NetNS = netnsPath
+	Expect(utils.SaveNetConf(containerID, config.DefaultCNIDir, ifName, netConf)).To(Succeed())
+	Expect(utils.NewPCIAllocator(config.DefaultCNIDir).SaveAllocation(netConf.DeviceID, newPCIAllocation(netConf))).To(Succeed())
+	return netConf
+}
+
+// expectCNIError checks that err is a CNI error with the given code
+func expectCNIError(err error, code uint) {
+	var cniErr *types.Error
+	Expect(errors.As(err, &cniErr)).To(BeTrue(), "not a CNI error: %v", err)
+	Expect(cniErr.Code).To(Equal(code), "unexpected code of %v", err)
+}
+
+// expectReleasedInInitNS sets up the mock to expect the VF of an attachment whose netns is gone to be released once
+func expectReleasedInInitNS() {
+	sm.On("ResetVFConfig", mock.Anything).Return(nil).Once()
+	sm.On("RestoreHostIFName", mock.Anything).Return(nil).Once()
+	sm.On("RestoreRepresentor", mock.Anything).Return(nil).Once()
+	sm.On("ReleaseVdpaDevice", mock.Anything).Return(nil).Once()
+}
+
+// linkAddrs returns the IPv4 addresses of the link ifName of netns
+func linkAddrs(netns ns.NetNS, ifName string) []string {
+	var addrs []string
+	err := netns.Do(func(_ ns.NetNS) error {
+		link, err := netlink.LinkByName(ifName)
+		if err != nil {
+			return err
+		}
+		linkAddrs, err := netlink.AddrList(link, netlink.FAMILY_V4)
+		for _, addr := range linkAddrs {
+			addrs = append(addrs, addr.IPNet.String())
+		}
+		return err
+	})
+	Expect(err).NotTo(HaveOccurred())
+	return addrs
+}
+
+var _ = Describe("CNI commands", func() {
+	Context("Checking CmdCheck function", func() {
+		var netns ns.NetNS
+		var args *skel.CmdArgs
+
+		// checkArgs returns the CHECK args of the attachment net1 of netns with the given prevResult
+		checkArgs := func(prevResult string) *skel.CmdArgs {
+			conf := `{"cniVersion": "1.0.0", "name": "sriov-net", "type": "sriov", "deviceID": "0000:af:06.0"`
+			if prevResult != "" {
+				conf += `, "prevResult": ` + prevResult
+			}
+			return &skel.CmdArgs{
+				ContainerID: "cid1",
+				Netns:       netns.Path(),
+				IfName:      "net1",
+				StdinData:   []byte(conf + "}"),
+			}
+		}
+
+		BeforeEach(func() {
+			netns = newTestNS()
+			addPodIf(netns, "net1")
+			err := netns.Do(func(_ ns.NetNS) error {
+				link, err := netlink.LinkByName("net1")
+				if err != nil {
+					return err
+				}
+				addr, err := netlink.ParseAddr("10.1.0.5/24")
+				if err != nil {
+					return err
+				}
+				if err := netlink.AddrAdd(link, addr); err != nil {
+					return err
+				}
+				return netlink.LinkSetUp(link)
+			})
+			Expect(err).NotTo(HaveOccurred())
+			cacheAttachment("sriov-net", "cid1", "net1", netns.Path())
+
+			args = checkArgs(fmt.Sprintf(`{"cniVersion": "1.0.0", "interfaces": [{"name": "net1", "sandbox": %q}],
+				"ips": [{"address": "10.1.0.5/24", "interface": 0}]}`, netns.Path()))
+		})
+
+		It("Should succeed if the VF and the pod interface match the cached NetConf", func() {
+			sm.On("CheckVFConfig", mock.Anything).Return(nil, nil)
+			sm.On("CheckVF", mock.Anything, mock.Anything).Return(nil, nil)
+
+			Expect(testutils.CmdCheckWithArgs(args, func() error { return CmdCheck(args) })).To(Succeed())
+		})
+
+		It("Should report the drift of the VF and the pod interface", func() {
+			sm.On("CheckVFConfig", mock.Anything).Return([]string{"vlan: expected 100, found 0"}, nil)
+			sm.On("CheckVF", mock.Anything, mock.Anything).Return([]string{"mtu: expected 9000, found 1500"}, nil)
+
+			err := testutils.CmdCheckWithArgs(args, func() error { return CmdCheck(args) })
+			expectCNIError(err, sriovtypes.ErrVfConfigDrift)
+			Expect(err.(*types.Error).Details).To(Equal("vlan: expected 100, found 0; mtu: expected 9000, found 1500"))
+		})
+
+		It("Should report an address of prevResult missing on the pod interface", func() {
+			sm.On("CheckVFConfig", mock.Anything).Return(nil, nil)
+			sm.On("CheckVF", mock.Anything, mock.Anything).Return(nil, nil)
+			args = checkArgs(fmt.Sprintf(`{"cniVersion": "1.0.0", "interfaces": [{"name": "net1", "sandbox": %q}],
+				"ips": [{"address": "10.1.0.6/24", "interface": 0}]}`, netns.Path()))
+
+			err := testutils.CmdCheckWithArgs(args, func() error { return CmdCheck(args) })
+			expectCNIError(err, sriovtypes.ErrVfConfigDrift)
+			Expect(err.(*types.Error).Details).To(HavePrefix("ips: "))
+		})
+
+		It("Should fail if the VF configuration can not be read", func() {
+			sm.On("CheckVFConfig", mock.Anything).Return(nil, errors.New("PF not found"))
+
+			err := testutils.CmdCheckWithArgs(args, func() error { return CmdCheck(args) })
+			Expect(err).To(MatchError(ContainSubstring("PF not found")))
+		})
+
+		It("Should fail without a cached NetConf", func() {
+			args.IfName = "net2"
+
+			err := testutils.CmdCheckWithArgs(args, func() error { return CmdCheck(args) })
+			expectCNIError(err, types.ErrUnknownContainer)
+		})
+
+		It("Should fail without prevResult", func() {
+			args = checkArgs("")
+
+			err := testutils.CmdCheckWithArgs(args, func() error { return CmdCheck(args) })
+			expectCNIError(err, types.ErrInvalidNetworkConfig)
+		})
+
+		It("Should fail if the pod interface is not in prevResult", func() {
+			sm.On("CheckVFConfig", mock.Anything).Return(nil, nil)
+			args = checkArgs(`{"cniVersion": "1.0.0", "interfaces": [{"name": "net1", "sandbox": "/var/run/netns/other"}]}`)
+
+			err := testutils.CmdCheckWithArgs(args, func() error { return CmdCheck(args) })
+			expectCNIError(err, types.ErrInvalidNetworkConfig)
+		})
+
+		It("Should report the drift of the links of a bond", func() {
+			bondConf := &sriovtypes.NetConf{}
+			bondConf.CNIVersion = "1.0.0"
+			bondConf.Name = "sriov-net"
+			bondConf.Bond = &sriovtypes.BondConf{
+				Mode:  sriovtypes.BondModeActiveBackup,
+				Links: []sriovtypes.BondLink{{DeviceID: "0000:af:06.0"}, {DeviceID: "0000:af:06.1"}},
+			}
+			Expect(utils.SaveNetConf("cid1", config.DefaultCNIDir, "bond0", bondConf)).To(Succeed())
+			for i, deviceID := range []string{"0000:af:06.0", "0000:af:06.1"} {
+				linkConf := &sriovtypes.NetConf{}
+				linkConf.DeviceID = deviceID
+				Expect(utils.SaveNetConf("cid1", config.DefaultCNIDir, bondConf.Bond.LinkIfName("bond0", i), linkConf)).To(Succeed())
+			}
+			sm.On("CheckVFConfig", mock.MatchedBy(func(c *sriovtypes.NetConf) bool { return c.DeviceID == "0000:af:06.0" })).Return(nil, nil)
+			sm.On("CheckVFConfig", mock.MatchedBy(func(c *sriovtypes.NetConf) bool { return c.DeviceID == "0000:af:06.1" })).
+				Return([]string{"trust: expected on, found off"}, nil)
+			sm.On("CheckBond", mock.Anything, "bond0", []string{"bond0_0", "bond0_1"}, mock.Anything).
+				Return([]string{"state: expected up, found down"}, nil)
+			args.IfName = "bond0"
+
+			err := testutils.CmdCheckWithArgs(args, func() error { return CmdCheck(args) })
+			expectCNIError(err, sriovtypes.ErrVfConfigDrift)
+			Expect(err.(*types.Error).Details).To(Equal("bond0_1: trust: expected on, found off; bond0: state: expected up, found down"))
+		})
+	})
+
+	Context("Checking CmdGC function", func() {
+		var netns ns.NetNS
+		var allocationPath string
+
+		// gcArgs returns the GC args of the network sriov-net with the given valid attachments
+		gcArgs := func(ipamType string, validAttachments ...types.GCAttachment) *skel.CmdArgs {
+			conf := map[string]any{
+				"cniVersion":                "1.1.0",
+				"name":                      "sriov-net",
+				"type":                      "sriov",
+				"cni.dev/valid-attachments": validAttachments,
+			}
+			if ipamType != "" {
+				conf["ipam"] = map[string]any{"type": ipamType}
+			}
+			stdinData, err := json.Marshal(conf)
+			Expect(err).NotTo(HaveOccurred())
+			return &skel.CmdArgs{StdinData: stdinData}
+		}
+
+		BeforeEach(func() {
+			netns = newTestNS()
+			allocationPath = filepath.Join(config.DefaultCNIDir, "pci", "0000:af:06.0")
+		})
+
+		It("Should release the VF of a stale attachment", func() {
+			netConf := cacheAttachment("sriov-net", "cid1", "net1", "/var/run/netns/gone")
+			devInfoPath := deviceInfoPath(netConf, "cid1", "net1")
+			Expect(utils.SaveDeviceInfo(devInfoPath, newDeviceInfo(netConf))).To(Succeed())
+			expectReleasedInInitNS()
+
+			Expect(CmdGC(gcArgs(""))).To(Succeed())
+			Expect(cachedNetConfPath("cid1", "net1")).ToNot(BeAnExistingFile())
+			Expect(allocationPath).ToNot(BeAnExistingFile())
+			Expect(devInfoPath).ToNot(BeAnExistingFile())
+		})
+
+		It("Should keep the valid attachments", func() {
+			cacheAttachment("sriov-net", "cid2", "net1", netns.Path())
+
+			Expect(CmdGC(gcArgs("", types.GCAttachment{ContainerID: "cid2", IfName: "net1"}))).To(Succeed())
+			Expect(cachedNetConfPath("cid2", "net1")).To(BeAnExistingFile())
+			Expect(allocationPath).To(BeAnExistingFile())
+		})
+
+		It("Should keep the attachments of other networks", func() {
+			cacheAttachment("other-net", "cid1", "net1", "/var/run/netns/gone")
+
+			Expect(CmdGC(gcArgs(""))).To(Succeed())
+			Expect(cachedNetConfPath("cid1", "net1")).To(BeAnExistingFile())
+		})
+
+		It("Should keep the links of a valid bond", func() {
+			linkConf := cacheAttachment("sriov-net", "cid1", "bond0_0", netns.Path())
+			linkConf.BondIfName = "bond0"
+			Expect(utils.SaveNetConf("cid1", config.DefaultCNIDir, "bond0_0", linkConf)).To(Succeed())
+
+			Expect(CmdGC(gcArgs("", types.GCAttachment{ContainerID: "cid1", IfName: "bond0"}))).To(Succeed())
+			Expect(cachedNetConfPath("cid1", "bond0_0")).To(BeAnExistingFile())
+		})
+
+		It("Should not release a VF in use by a valid attachment", func() {
+			cacheAttachment("sriov-net", "cid1", "net1", "/var/run/netns/gone")
+			cacheAttachment("sriov-net", "cid2", "net1", netns.Path())
+
+			Expect(CmdGC(gcArgs("", types.GCAttachment{ContainerID: "cid2", IfName: "net1"}))).To(Succeed())
+			Expect(cachedNetConfPath("cid1", "net1")).ToNot(BeAnExistingFile())
+			Expect(cachedNetConfPath("cid2", "net1")).To(BeAnExistingFile())
+			Expect(allocationPath).To(BeAnExistingFile())
+		})
+
+		It("Should release a VF referenced by several stale attachments once", func() {
+			cacheAttachment("sriov-net", "cid1", "net1", "/var/run/netns/gone")
+			cacheAttachment("sriov-net", "cid2", "net1", "/var/run/netns/gone")
+			expectReleasedInInitNS()
+
+			Expect(CmdGC(gcArgs(""))).To(Succeed())
+			Expect(cachedNetConfPath("cid1", "net1")).ToNot(BeAnExistingFile())
+			Expect(cachedNetConfPath("cid2", "net1")).ToNot(BeAnExistingFile())
+		})
+
+		It("Should not release a VF allocated to another netns", func() {
+			cacheAttachment("sriov-net", "cid1", "net1", "/var/run/netns/gone")
+			// the VF was handed out to a pod of another runtime or network meanwhile
+			Expect(utils.NewPCIAllocator(config.DefaultCNIDir).SaveAllocatedPCI("0000:af:06.0", netns.Path())).To(Succeed())
+
+			Expect(CmdGC(gcArgs(""))).To(Succeed())
+			Expect(cachedNetConfPath("cid1", "net1")).ToNot(BeAnExistingFile())
+			allocation, err := utils.NewPCIAllocator(config.DefaultCNIDir).ReadAllocation("0000:af:06.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allocation.NetNS).To(Equal(netns.Path()))
+		})
+
+		It("Should keep a stale attachment whose VF can not be reset", func() {
+			cacheAttachment("sriov-net", "cid1", "net1", "/var/run/netns/gone")
+			sm.On("ResetVFConfig", mock.Anything).Return(errors.New("netlink failure"))
+
+			Expect(CmdGC(gcArgs(""))).To(MatchError(ContainSubstring("netlink failure")))
+			Expect(cachedNetConfPath("cid1", "net1")).To(BeAnExistingFile())
+		})
+
+		It("Should run the garbage collection of the IPAM plugin", func() {
+			ipamDir := installFakeIPAM()
+
+			Expect(CmdGC(gcArgs("fake-ipam", types.GCAttachment{ContainerID: "cid2", IfName: "net1"}))).To(Succeed())
+			stdin, err := os.ReadFile(filepath.Join(ipamDir, "stdin-GC"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stdin).To(ContainSubstring(`"cni.dev/valid-attachments":[{"containerID":"cid2","ifname":"net1"}]`))
+		})
+
+		It("Should keep the addresses of the VLAN sub-interfaces of the valid attachments", func() {
+			ipamDir := installFakeIPAM()
+			netConf := cacheAttachment("sriov-net", "cid2", "net1", netns.Path())
+			netConf.Vlans = []sriovtypes.VlanConf{{ID: 100, IPAM: []byte(`{"type": "fake-ipam"}`)}}
+			Expect(utils.SaveNetConf("cid2", config.DefaultCNIDir, "net1", netConf)).To(Succeed())
+
+			Expect(CmdGC(gcArgs("fake-ipam", types.GCAttachment{ContainerID: "cid2", IfName: "net1"}))).To(Succeed())
+			stdin, err := os.ReadFile(filepath.Join(ipamDir, "stdin-GC"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stdin).To(ContainSubstring(`"cni.dev/valid-attachments":[{"containerID":"cid2","ifname":"net1"},` +
+				`{"containerID":"cid2","ifname":"net1.100"}]`))
+		})
+	})
+
+	Context("Checking CmdStatus function", func() {
+		// statusArgs returns the STATUS args of a network with the given configuration keys
+		statusArgs := func(keys string) *skel.CmdArgs {
+			return &skel.CmdArgs{
+				StdinData: []byte(`{"cniVersion": "1.1.0", "name": "sriov-net", "type": "sriov"` + keys + `}`),
+			}
+		}
+
+		It("Should succeed if the PF of the VF has VFs configured", func() {
+			Expect(CmdStatus(statusArgs(`, "deviceID": "0000:af:06.0"`))).To(Succeed())
+		})
+
+		It("Should succeed if the PFs have VFs configured", func() {
+			Expect(CmdStatus(statusArgs(`, "pfNames": ["enp175s0f1"]`))).To(Succeed())
+		})
+
+		It("Should succeed if the IPAM plugins are installed", func() {
+			args := statusArgs(`, "master": "enp175s0f1", "ipam": {"type": "fake-ipam"},
+				"vlans": [{"id": 100, "ipam": {"type": "fake-ipam"}}, {"id": 200}]`)
+			args.Path = installFakeIPAM()
+
+			Expect(CmdStatus(args)).To(Succeed())
+		})
+
+		It("Should succeed if the PFs of the links of a bond have VFs configured", func() {
+			args := statusArgs(`, "bond": {"mode": "802.3ad", "links": [{"deviceID": "0000:af:06.0"}, {"pfNames": ["enp175s0f1"]}]}`)
+
+			Expect(CmdStatus(args)).To(Succeed())
+		})
+
+		It("Should fail if the PF has no VFs configured", func() {
+			expectCNIError(CmdStatus(statusArgs(`, "master": "ens1"`)), sriovtypes.ErrPluginNotAvailable)
+		})
+
+		It("Should fail if the PF does not exist", func() {
+			expectCNIError(CmdStatus(statusArgs(`, "pfNames": ["enp175s0f1", "missing"]`)), sriovtypes.ErrPluginNotAvailable)
+		})
+
+		It("Should fail if the PF of a link of a bond has no VFs configured", func() {
+			args := statusArgs(`, "bond": {"mode": "802.3ad", "links": [{"deviceID": "0000:af:06.0"}, {"master": "ens1", "vfIndex": 0}]}`)
+
+			expectCNIError(CmdStatus(args), sriovtypes.ErrPluginNotAvailable)
+		})
+
+		It("Should fail if the IPAM plugin is not installed", func() {
+			args := statusArgs(`, "master": "enp175s0f1", "ipam": {"type": "fake-ipam"}`)
+			args.Path = GinkgoT().TempDir()
+
+			expectCNIError(CmdStatus(args), sriovtypes.ErrPluginNotAvailable)
+		})
+
+		It("Should look up the IPAM plugin in the plugin path of the args only", func() {
+			installFakeIPAM()
+			args := statusArgs(`, "master": "enp175s0f1", "ipam": {"type": "fake-ipam"}`)
+			args.Path = GinkgoT().TempDir()
+
+			expectCNIError(CmdStatus(args), sriovtypes.ErrPluginNotAvailable)
+		})
+
+		It("Should fail if the IPAM plugin of a VLAN sub-interface is not installed", func() {
+			args := statusArgs(`, "master": "enp175s0f1", "ipam": {"type": "fake-ipam"},
+				"vlans": [{"id": 100, "ipam": {"type": "missing-ipam"}}]`)
+			args.Path = installFakeIPAM()
+
+			err := CmdStatus(args)
+			expectCNIError(err, sriovtypes.ErrPluginNotAvailable)
+			Expect(err).To(MatchError(ContainSubstring("missing-ipam")))
+		})
+
+		It("Should fail if the data directory is not writable", func() {
+			file := filepath.Join(GinkgoT().TempDir(), "file")
+			Expect(os.WriteFile(file, nil, 0o600)).To(Succeed())
+			config.DefaultCNIDir = filepath.Join(file, "sriov")
+
+			expectCNIError(CmdStatus(statusArgs(`, "master": "enp175s0f1"`)), sriovtypes.ErrPluginNotAvailable)
+		})
+
+		It("Should fail with an invalid configuration", func() {
+			Expect(CmdStatus(statusArgs(`, "master": 1`))).To(MatchError(ContainSubstring("failed to load netconf")))
+		})
+	})
+
+	Context("Checking CmdDel function", func() {
+		var netns ns.NetNS
+		var allocationPath string
+
+		// delArgs returns the DEL args of the attachment net1 of the container cid1 in the netns at netnsPath
+		delArgs := func(netnsPath string) *skel.CmdArgs {
+			return &skel.CmdArgs{
+				ContainerID: "cid1",
+				Netns:       netnsPath,
+				IfName:      "net1",
+				StdinData:   []byte(`{"cniVersion": "1.0.0", "name": "sriov-net", "type": "sriov", "deviceID": "0000:af:06.0"}`),
+			}
+		}
+
+		BeforeEach(func() {
+			netns = newTestNS()
+			allocationPath = filepath.Join(config.DefaultCNIDir, "pci", "0000:af:06.0")
+		})
+
+		It("Should release the VF from the pod netns", func() {
+			cacheAttachment("sriov-net", "cid1", "net1", netns.Path())
+			sm.On("ResetVFConfig", mock.Anything).Return(nil)
+			sm.On("ReleaseVF", mock.Anything, "net1", mock.Anything).Return(nil)
+			sm.On("ReleaseVdpaDevice", mock.Anything).Return(nil)
+			args := delArgs(netns.Path())
+
+			Expect(testutils.CmdDelWithArgs(args, func() error { return CmdDel(args) })).To(Succeed())
+			Expect(cachedNetConfPath("cid1", "net1")).ToNot(BeAnExistingFile())
+			Expect(allocationPath).ToNot(BeAnExistingFile())
+		})
+
+		It("Should restore the VF in the init netns if the netns is gone", func() {
+			cacheAttachment("sriov-net", "cid1", "net1", "/var/run/netns/gone")
+			expectReleasedInInitNS()
+			args := delArgs("/var/run/netns/gone")
+
+			Expect(testutils.CmdDelWithArgs(args, func() error { return CmdDel(args) })).To(Succeed())
+			Expect(cachedNetConfPath("cid1", "net1")).ToNot(BeAnExistingFile())
+			Expect(allocationPath).ToNot(BeAnExistingFile())
+		})
+
+		It("Should succeed if the VF can not be restored in the init netns", func() {
+			cacheAttachment("sriov-net", "cid1", "net1", "/var/run/netns/gone")
+			sm.On("ResetVFConfig", mock.Anything).Return(nil)
+			sm.On("RestoreHostIFName", mock.Anything).Return(errors.New("link not found"))
+			sm.On("RestoreRepresentor", mock.Anything).Return(nil)
+			sm.On("ReleaseVdpaDevice", mock.Anything).Return(nil)
+			args := delArgs("/var/run/netns/gone")
+
+			Expect(testutils.CmdDelWithArgs(args, func() error { return CmdDel(args) })).To(Succeed())
+			Expect(allocationPath).ToNot(BeAnExistingFile())
+		})
+
+		It("Should not release a VF allocated to another netns", func() {
+			cacheAttachment("sriov-net", "cid1", "net1", "/var/run/netns/gone")
+			Expect(utils.NewPCIAllocator(config.DefaultCNIDir).SaveAllocatedPCI("0000:af:06.0", netns.Path())).To(Succeed())
+			args := delArgs("/var/run/netns/gone")
+
+			Expect(testutils.CmdDelWithArgs(args, func() error { return CmdDel(args) })).To(Succeed())
+			Expect(cachedNetConfPath("cid1", "net1")).ToNot(BeAnExistingFile())
+			allocation, err := utils.NewPCIAllocator(config.DefaultCNIDir).ReadAllocation("0000:af:06.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allocation.NetNS).To(Equal(netns.Path()))
+		})
+
+		It("Should keep the cached NetConf if the VF can not be reset", func() {
+			cacheAttachment("sriov-net", "cid1", "net1", netns.Path())
+			sm.On("ResetVFConfig", mock.Anything).Return(errors.New("netlink failure"))
+			args := delArgs(netns.Path())
+
+			err := testutils.CmdDelWithArgs(args, func() error { return CmdDel(args) })
+			expectCNIError(err, types.ErrInternal)
+			Expect(cachedNetConfPath("cid1", "net1")).To(BeAnExistingFile())
+			Expect(allocationPath).To(BeAnExistingFile())
+		})
+
+		It("Should succeed without a cached NetConf", func() {
+			args := delArgs(netns.Path())
+
+			Expect(testutils.CmdDelWithArgs(args, func() error { return CmdDel(args) })).To(Succeed())
+		})
+
+		It("Should remove the device information of a bond", func() {
+			devInfoFile := filepath.Join(GinkgoT().TempDir(), "bond0.json")
+			Expect(os.WriteFile(devInfoFile, []byte("{}"), 0o600)).To(Succeed())
+			bondConf := &sriovtypes.NetConf{}
+			bondConf.CNIVersion = "1.0.0"
+			bondConf.Name = "sriov-net"
+			bondConf.RuntimeConfig.CNIDeviceInfoFile = devInfoFile
+			bondConf.Bond = &sriovtypes.BondConf{
+				Mode:  sriovtypes.BondModeActiveBackup,
+				Links: []sriovtypes.BondLink{{DeviceID: "0000:af:06.0"}, {DeviceID: "0000:af:06.1"}},
+			}
+			Expect(utils.SaveNetConf("cid1", config.DefaultCNIDir, "bond0", bondConf)).To(Succeed())
+			// the second link was released by a previous DEL
+			cacheAttachment("sriov-net", "cid1", "bond0_0", netns.Path())
+			sm.On("ReleaseBond", "bond0", mock.Anything).Return(nil)
+			sm.On("ResetVFConfig", mock.Anything).Return(nil)
+			sm.On("ReleaseVF", mock.Anything, "bond0_0", mock.Anything).Return(nil)
+			sm.On("ReleaseVdpaDevice", mock.Anything).Return(nil)
+			args := delArgs(netns.Path())
+			args.IfName = "bond0"
+
+			Expect(testutils.CmdDelWithArgs(args, func() error { return CmdDel(args) })).To(Succeed())
+			Expect(devInfoFile).ToNot(BeAnExistingFile())
+			Expect(cachedNetConfPath("cid1", "bond0")).ToNot(BeAnExistingFile())
+			Expect(cachedNetConfPath("cid1", "bond0_0")).ToNot(BeAnExistingFile())
+		})
+
+		It("Should release the addresses of the VLAN sub-interfaces", func() {
+			ipamDir := installFakeIPAM()
+			netConf := cacheAttachment("sriov-net", "cid1", "net1", netns.Path())
+			netConf.Vlans = []sriovtypes.VlanConf{{ID: 100, IPAM: []byte(`{"type": "fake-ipam"}`)}, {ID: 200}}
+			Expect(utils.SaveNetConf("cid1", config.DefaultCNIDir, "net1", netConf)).To(Succeed())
+			sm.On("ResetVFConfig", mock.Anything).Return(nil)
+			sm.On("ReleaseVF", mock.Anything, "net1", mock.Anything).Return(nil)
+			sm.On("ReleaseVdpaDevice", mock.Anything).Return(nil)
+			args := delArgs(netns.Path())
+			args.Path = ipamDir
+
+			Expect(testutils.CmdDelWithArgs(args, func() error { return CmdDel(args) })).To(Succeed())
+			calls, err := os.ReadFile(filepath.Join(ipamDir, "calls"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(calls)).To(Equal("DEL net1.100\n"))
+		})
+
+		It("Should keep the cached NetConf if the addresses of the VLAN sub-interfaces can not be released", func() {
+			netConf := cacheAttachment("sriov-net", "cid1", "net1", netns.Path())
+			netConf.Vlans = []sriovtypes.VlanConf{{ID: 100, IPAM: []byte(`{"type": "missing-ipam"}`)}}
+			Expect(utils.SaveNetConf("cid1", config.DefaultCNIDir, "net1", netConf)).To(Succeed())
+			args := delArgs(netns.Path())
+			args.Path = GinkgoT().TempDir()
+
+			err := testutils.CmdDelWithArgs(args, func() error { return CmdDel(args) })
+			Expect(err).To(MatchError(ContainSubstring("net1.100")))
+			Expect(cachedNetConfPath("cid1", "net1")).To(BeAnExistingFile())
+		})
+	})
+
+	Context("Checking Recover function", func() {
+		var netns ns.NetNS
+		var allocationPath string
+
+		BeforeEach(func() {
+			netns = newTestNS()
+			allocationPath = filepath.Join(config.DefaultCNIDir, "pci", "0000:af:06.0")
+		})
+
+		It("Should release the VF of an attachment whose netns is gone", func() {
+			cacheAttachment("sriov-net", "cid1", "net1", "/var/run/netns/gone")
+			expectReleasedInInitNS()
+
+			recovered, err := Recover()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recovered).To(Equal([]string{cachedNetConfPath("cid1", "net1")}))
+			Expect(cachedNetConfPath("cid1", "net1")).ToNot(BeAnExistingFile())
+			Expect(allocationPath).ToNot(BeAnExistingFile())
+		})
+
+		It("Should keep the attachments with a live netns", func() {
+			cacheAttachment("sriov-net", "cid1", "net1", netns.Path())
+
+			recovered, err := Recover()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recovered).To(BeEmpty())
+			Expect(cachedNetConfPath("cid1", "net1")).To(BeAnExistingFile())
+			Expect(allocationPath).To(BeAnExistingFile())
+		})
+
+		It("Should not release a VF in use by an attachment with a live netns", func() {
+			cacheAttachment("sriov-net", "cid1", "net1", "/var/run/netns/gone")
+			cacheAttachment("sriov-net", "cid2", "net1", netns.Path())
+
+			recovered, err := Recover()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recovered).To(Equal([]string{cachedNetConfPath("cid1", "net1")}))
+			Expect(cachedNetConfPath("cid2", "net1")).To(BeAnExistingFile())
+			Expect(allocationPath).To(BeAnExistingFile())
+		})
+
+		It("Should not release a VF allocated to another netns", func() {
+			cacheAttachment("sriov-net", "cid1", "net1", "/var/run/netns/gone")
+			Expect(utils.NewPCIAllocator(config.DefaultCNIDir).SaveAllocatedPCI("0000:af:06.0", netns.Path())).To(Succeed())
+
+			recovered, err := Recover()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recovered).To(Equal([]string{cachedNetConfPath("cid1", "net1")}))
+			Expect(allocationPath).To(BeAnExistingFile())
+		})
+
+		It("Should keep the attachments whose VF can not be released", func() {
+			cacheAttachment("sriov-net", "cid1", "net1", "/var/run/netns/gone")
+			sm.On("ResetVFConfig", mock.Anything).Return(errors.New("netlink failure"))
+
+			recovered, err := Recover()
+			Expect(err).To(MatchError(ContainSubstring("netlink failure")))
+			Expect(recovered).To(BeEmpty())
+			Expect(cachedNetConfPath("cid1", "net1")).To(BeAnExistingFile())
+		})
+
+		It("Should release the VF of an ADD interrupted after the VF was changed", func() {
+			args := &skel.CmdArgs{
+				ContainerID: "cid1",
+				Netns:       netns.Path(),
+				IfName:      "net1",
+				StdinData:   []byte(`{"cniVersion": "1.0.0", "name": "sriov-net", "type": "sriov", "deviceID": "0000:af:06.0"}`),
+			}
+			// the files of the attachment as they are on disk while the VF is moved to the pod netns
+			files := []string{
+				cachedNetConfPath("cid1", "net1"),
+				allocationPath,
+				allocationPath + ".json",
+			}
+			snapshot := map[string][]byte{}
+			sm.On("FillOriginalVfInfo", mock.Anything).Return(nil)
+			sm.On("ApplyVFConfig", mock.Anything).Return(nil)
+			sm.On("SetupVF", mock.Anything, "net1", mock.Anything).Run(func(_ mock.Arguments) {
+				for _, file := range files {
+					data, err := os.ReadFile(file)
+					Expect(err).NotTo(HaveOccurred())
+					snapshot[file] = data
+				}
+			}).Return(errors.New("netlink failure"))
+			sm.On("ResetVFConfig", mock.Anything).Return(nil).Once()
+
+			_, _, err := testutils.CmdAddWithArgs(args, func() error { return CmdAdd(args) })
+			expectCNIError(err, types.ErrInternal)
+			Expect(snapshot).To(HaveLen(len(files)))
+			// a failed ADD releases the VF itself
+			Expect(cachedNetConfPath("cid1", "net1")).ToNot(BeAnExistingFile())
+			Expect(allocationPath).ToNot(BeAnExistingFile())
+
+			// the plugin was killed in SetupVF instead and the node rebooted, restore the files in a data directory
+			// whose VF locks are not held by this process
+			cniDir := GinkgoT().TempDir()
+			for file, data := range snapshot {
+				rel, err := filepath.Rel(config.DefaultCNIDir, file)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(os.MkdirAll(filepath.Dir(filepath.Join(cniDir, rel)), 0o700)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(cniDir, rel), data, 0o600)).To(Succeed())
+			}
+			config.DefaultCNIDir = cniDir
+			Expect(netns.Close()).To(Succeed())
+			Expect(testutils.UnmountNS(netns)).To(Succeed())
+			expectReleasedInInitNS()
+
+			recovered, err := Recover()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recovered).To(Equal([]string{cachedNetConfPath("cid1", "net1")}))
+			Expect(cachedNetConfPath("cid1", "net1")).ToNot(BeAnExistingFile())
+			Expect(filepath.Join(cniDir, "pci", "0000:af:06.0")).ToNot(BeAnExistingFile())
+		})
+	})
+
+	Context("Checking CmdAdd function", func() {
+		var netns ns.NetNS
+
+		// addArgs returns the ADD args of the attachment net1 of netns with the given configuration keys
+		addArgs := func(keys string) *skel.CmdArgs {
+			return &skel.CmdArgs{
+				ContainerID: "cid1",
+				Netns:       netns.Path(),
+				IfName:      "net1",
+				StdinData: []byte(`{"cniVersion": "1.0.0", "name": "sriov-net", "type": "sriov",
+					"deviceID": "0000:af:06.0"` + keys + `}`),
+			}
+		}
+
+		// cmdAdd runs CmdAdd with args and returns its result
+		cmdAdd := func(args *skel.CmdArgs) (*current.Result, error) {
+			r, _, err := testutils.CmdAddWithArgs(args, func() error { return CmdAdd(args) })
+			if err != nil {
+				return nil, err
+			}
+			return current.NewResultFromResult(r)
+		}
+
+		// expectSetup sets up the mock to expect the VF to be moved to netns as net1
+		expectSetup := func() {
+			sm.On("FillOriginalVfInfo", mock.Anything).Return(nil)
+			sm.On("ApplyVFConfig", mock.Anything).Return(nil)
+			sm.On("SetupVF", mock.Anything, "net1", mock.Anything).Run(func(_ mock.Arguments) {
+				addPodIf(netns, "net1")
+			}).Return(nil)
+		}
+
+		BeforeEach(func() {
+			netns = newTestNS()
+		})
+
+		It("Should append the pod interface to prevResult", func() {
+			expectSetup()
+			args := addArgs(`, "prevResult": {"cniVersion": "1.0.0", "interfaces": [{"name": "eth0"}],
+				"ips": [{"address": "10.0.0.2/24", "interface": 0}]}`)
+
+			result, err := cmdAdd(args)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Interfaces).To(HaveLen(2))
+			Expect(result.Interfaces[0].Name).To(Equal("eth0"))
+			Expect(result.Interfaces[1].Name).To(Equal("net1"))
+			Expect(result.Interfaces[1].Sandbox).To(Equal(netns.Path()))
+			Expect(result.IPs).To(HaveLen(1))
+			Expect(result.IPs[0].Address.String()).To(Equal("10.0.0.2/24"))
+			Expect(*result.IPs[0].Interface).To(Equal(0))
+			// the addresses of the previous plugins are theirs to configure
+			Expect(linkAddrs(netns, "net1")).To(BeEmpty())
+			Expect(cachedNetConfPath("cid1", "net1")).To(BeAnExistingFile())
+		})
+
+		It("Should add the addresses of the IPAM plugin to prevResult", func() {
+			installFakeIPAM()
+			expectSetup()
+			args := addArgs(`, "ipam": {"type": "fake-ipam"}, "prevResult": {"cniVersion": "1.0.0",
+				"interfaces": [{"name": "eth0"}], "ips": [{"address": "10.0.0.2/24", "interface": 0}]}`)
+
+			result, err := cmdAdd(args)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.IPs).To(HaveLen(2))
+			Expect(result.IPs[1].Address.String()).To(Equal("10.1.0.5/24"))
+			Expect(*result.IPs[1].Interface).To(Equal(1))
+			Expect(linkAddrs(netns, "net1")).To(ConsistOf("10.1.0.5/24"))
+		})
+
+		It("Should configure the unbound addresses of prevResult on the pod interface with usePrevResultIPs", func() {
+			expectSetup()
+			args := addArgs(`, "usePrevResultIPs": true, "prevResult": {"cniVersion": "1.0.0",
+				"interfaces": [{"name": "eth0"}], "ips": [{"address": "10.0.0.2/24", "interface": 0},
+				{"address": "10.0.1.2/24"}]}`)
+
+			result, err := cmdAdd(args)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.IPs).To(HaveLen(2))
+			Expect(*result.IPs[0].Interface).To(Equal(0))
+			Expect(result.IPs[1].Address.String()).To(Equal("10.0.1.2/24"))
+			Expect(*result.IPs[1].Interface).To(Equal(1))
+			Expect(linkAddrs(netns, "net1")).To(ConsistOf("10.0.1.2/24"))
+		})
+
+		It("Should fail with usePrevResultIPs if prevResult has no unbound addresses", func() {
+			args := addArgs(`, "usePrevResultIPs": true, "prevResult": {"cniVersion": "1.0.0",
+				"interfaces": [{"name": "eth0"}], "ips": [{"address": "10.0.0.2/24", "interface": 0}]}`)
+
+			_, err := cmdAdd(args)
+			expectCNIError(err, types.ErrInvalidNetworkConfig)
+		})
+
+		It("Should fail with usePrevResultIPs without prevResult", func() {
+			_, err := cmdAdd(addArgs(`, "usePrevResultIPs": true`))
+			expectCNIError(err, types.ErrInvalidNetworkConfig)
+		})
+
+		It("Should fail with an invalid prevResult", func() {
+			_, err := cmdAdd(addArgs(`, "prevResult": {"cniVersion": "1.0.0", "ips": [{"address": "10.0.0.2"}]}`))
+			expectCNIError(err, types.ErrDecodingFailure)
+		})
+
+		It("Should report the tx rate limiter on the pod interface", func() {
+			sm.On("FillOriginalVfInfo", mock.Anything).Return(nil)
+			sm.On("ApplyVFConfig", mock.Anything).Run(func(args mock.Arguments) {
+				args.Get(0).(*sriovtypes.NetConf).TxRateLimiter = sriovtypes.TxRateModeSoftware
+			}).Return(nil)
+			sm.On("SetupVF", mock.Anything, "net1", mock.Anything).Run(func(_ mock.Arguments) {
+				addPodIf(netns, "net1")
+			}).Return(nil)
+			args := addArgs(`, "max_tx_rate": 100, "txRateMode": "software", "prevResult": {"cniVersion": "1.0.0",
+				"interfaces": [{"name": "eth0"}]}`)
+
+			_, out, err := testutils.CmdAddWithArgs(args, func() error { return CmdAdd(args) })
+			Expect(err).NotTo(HaveOccurred())
+			result := struct {
+				Interfaces []map[string]any `json:"interfaces"`
+			}{}
+			Expect(json.Unmarshal(out, &result)).To(Succeed())
+			Expect(result.Interfaces).To(HaveLen(2))
+			Expect(result.Interfaces[0]).NotTo(HaveKey("tx-rate-limiter"))
+			Expect(result.Interfaces[1]).To(HaveKeyWithValue("name", "net1"))
+			Expect(result.Interfaces[1]).To(HaveKeyWithValue("tx-rate-limiter", "software"))
+		})
+
+		It("Should not report a tx rate limiter without a tx rate", func() {
+			expectSetup()
+			args := addArgs("")
+
+			_, out, err := testutils.CmdAddWithArgs(args, func() error { return CmdAdd(args) })
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(out)).NotTo(ContainSubstring("tx-rate-limiter"))
+		})
+
+		It("Should write the device information of a bond to the file of the runtime config", func() {
+			devInfoFile := filepath.Join(GinkgoT().TempDir(), "devinfo", "bond0.json")
+			sm.On("FillOriginalVfInfo", mock.Anything).Return(nil)
+			sm.On("ApplyVFConfig", mock.Anything).Return(nil)
+			sm.On("SetupVF", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+				addPodIf(netns, args.String(1))
+			}).Return(nil)
+			sm.On("SetupBond", mock.Anything, "bond0", []string{"bond0_0", "bond0_1"}, mock.Anything).Return("e4:11:22:33:44:55", nil)
+			args := &skel.CmdArgs{
+				ContainerID: "cid1",
+				Netns:       netns.Path(),
+				IfName:      "bond0",
+				StdinData: []byte(`{"cniVersion": "1.0.0", "name": "sriov-net", "type": "sriov",
+					"runtimeConfig": {"CNIDeviceInfoFile": "` + devInfoFile + `"},
+					"bond": {"mode": "active-backup", "links": [{"deviceID": "0000:af:06.0"}, {"deviceID": "0000:af:06.1"}]}}`),
+			}
+
+			_, err := cmdAdd(args)
+			Expect(err).NotTo(HaveOccurred())
+			data, err := os.ReadFile(devInfoFile)
+			Expect(err).NotTo(HaveOccurred())
+			devInfo := &sriovtypes.DeviceInfo{}
+			Expect(json.Unmarshal(data, devInfo)).To(Succeed())
+			Expect(devInfo.Pci.PciAddress).To(Equal("0000:af:06.0"))
+			Expect(devInfo.Sriov.BondLinks).To(Equal([]string{"0000:af:06.0", "0000:af:06.1"}))
+			// the links publish theirs in the default directory
+			for _, linkIfName := range []string{"bond0_0", "bond0_1"} {
+				Expect(utils.DeviceInfoPath(config.DefaultDeviceInfoDir, "sriov-net", "cid1", linkIfName)).To(BeAnExistingFile())
+			}
+		})
+
+		It("Should release the VF if the addresses of prevResult can not be configured", func() {
+			sm.On("FillOriginalVfInfo", mock.Anything).Return(nil)
+			sm.On("ApplyVFConfig", mock.Anything).Return(nil)
+			// the pod interface does not show up in netns
+			sm.On("SetupVF", mock.Anything, "net1", mock.Anything).Return(nil)
+			sm.On("ResetVFConfig", mock.Anything).Return(nil)
+			args := addArgs(`, "usePrevResultIPs": true, "prevResult": {"cniVersion": "1.0.0",
+				"ips": [{"address": "10.0.1.2/24"}]}`)
+
+			_, err := cmdAdd(args)
+			expectCNIError(err, types.ErrInternal)
+			Expect(cachedNetConfPath("cid1", "net1")).ToNot(BeAnExistingFile())
+			Expect(filepath.Join(config.DefaultCNIDir, "pci", "0000:af:06.0")).ToNot(BeAnExistingFile())
+		})
+
+		It("Should run the IPAM plugins of the VLAN sub-interfaces", func() {
+			ipamDir := installFakeIPAM()
+			sm.On("FillOriginalVfInfo", mock.Anything).Return(nil)
+			sm.On("ApplyVFConfig", mock.Anything).Return(nil)
+			sm.On("SetupVF", mock.Anything, "net1", mock.Anything).Run(func(_ mock.Arguments) {
+				addPodIf(netns, "net1")
+				addPodIf(netns, "net1.100")
+				addPodIf(netns, "net1.200")
+			}).Return(nil)
+			args := addArgs(`, "ipam": {"type": "fake-ipam"}, "vlans": [
+				{"id": 100, "ipam": {"type": "fake-ipam", "subnet": "10.2.0.0/24"}}, {"id": 200}]`)
+			args.Path = ipamDir
+
+			result, err := cmdAdd(args)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Interfaces).To(HaveLen(3))
+			Expect(result.Interfaces[1].Name).To(Equal("net1.100"))
+			Expect(result.Interfaces[1].Sandbox).To(Equal(netns.Path()))
+			Expect(result.Interfaces[2].Name).To(Equal("net1.200"))
+			Expect(result.IPs).To(HaveLen(2))
+			Expect(result.IPs[0].Address.String()).To(Equal("10.1.0.5/24"))
+			Expect(*result.IPs[0].Interface).To(Equal(0))
+			Expect(result.IPs[1].Address.String()).To(Equal("10.2.0.5/24"))
+			Expect(*result.IPs[1].Interface).To(Equal(1))
+			Expect(linkAddrs(netns, "net1")).To(ConsistOf("10.1.0.5/24"))
+			Expect(linkAddrs(netns, "net1.100")).To(ConsistOf("10.2.0.5/24"))
+			Expect(linkAddrs(netns, "net1.200")).To(BeEmpty())
+
+			calls, err := os.ReadFile(filepath.Join(ipamDir, "calls"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(calls)).To(Equal("ADD net1\nADD net1.100\n"))
+			// the sub-interface is run with its own ipam block
+			stdin, err := os.ReadFile(filepath.Join(ipamDir, "stdin-ADD"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stdin).To(ContainSubstring(`"subnet":"10.2.0.0/24"`))
+		})
+
+		It("Should release the addresses and the VF if the IPAM plugin of a VLAN sub-interface fails", func() {
+			ipamDir := installFakeIPAM()
+			expectSetup()
+			sm.On("ReleaseVF", mock.Anything, "net1", mock.Anything).Return(nil)
+			sm.On("ResetVFConfig", mock.Anything).Return(nil)
+			args := addArgs(`, "ipam": {"type": "fake-ipam"}, "vlans": [{"id": 100, "ipam": {"type": "missing-ipam"}}]`)
+			args.Path = ipamDir
+
+			_, err := cmdAdd(args)
+			expectCNIError(err, types.ErrInternal)
+			Expect(err).To(MatchError(ContainSubstring("net1.100")))
+			calls, err := os.ReadFile(filepath.Join(ipamDir, "calls"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(calls)).To(Equal("ADD net1\nDEL net1\n"))
+			Expect(cachedNetConfPath("cid1", "net1")).ToNot(BeAnExistingFile())
+		})
+	})
+})
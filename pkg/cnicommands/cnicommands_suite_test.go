@@ -0,0 +1,98 @@
+package cnicommands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/containernetworking/plugins/pkg/testutils"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+
+	"github.com/k8snetworkplumbingwg/sriov-cni/pkg/config"
+	"github.com/k8snetworkplumbingwg/sriov-cni/pkg/sriov"
+	"github.com/k8snetworkplumbingwg/sriov-cni/pkg/sriov/mocks"
+	"github.com/k8snetworkplumbingwg/sriov-cni/pkg/utils"
+)
+
+func TestCnicommands(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Cnicommands Suite")
+}
+
+var _ = BeforeSuite(func() {
+	// create test sys tree
+	err := utils.CreateTmpSysFs()
+	Expect(err).Should(Succeed())
+})
+
+var _ = AfterSuite(func() {
+	err := utils.RemoveTmpSysFs()
+	Expect(err).Should(Succeed())
+})
+
+// sm is the mocked manager the commands configure VFs with
+var sm *mocks.Manager
+
+var _ = BeforeEach(func() {
+	DeferCleanup(func(cniDir, deviceInfoDir string) {
+		config.DefaultCNIDir = cniDir
+		config.DefaultDeviceInfoDir = deviceInfoDir
+	}, config.DefaultCNIDir, config.DefaultDeviceInfoDir)
+	config.DefaultCNIDir = GinkgoT().TempDir()
+	config.DefaultDeviceInfoDir = GinkgoT().TempDir()
+
+	sm = mocks.NewManager(GinkgoT())
+	DeferCleanup(func(f func() sriov.Manager) { newSriovManager = f }, newSriovManager)
+	newSriovManager = func() sriov.Manager { return sm }
+})
+
+// newTestNS returns a new netns that is removed after the test
+func newTestNS() ns.NetNS {
+	netns, err := testutils.NewNS()
+	Expect(err).NotTo(HaveOccurred())
+	DeferCleanup(func() {
+		_ = netns.Close()
+		_ = testutils.UnmountNS(netns)
+	})
+	return netns
+}
+
+// addPodIf adds a netdevice named ifName to netns, standing in for the VF netdevice SetupVF moves there
+func addPodIf(netns ns.NetNS, ifName string) {
+	err := netns.Do(func(_ ns.NetNS) error {
+		return netlink.LinkAdd(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{Name: ifName},
+			PeerName:  ifName + "-p",
+		})
+	})
+	Expect(err).NotTo(HaveOccurred())
+}
+
+// fakeIPAM is an IPAM plugin script that logs its calls and returns an address depending on the interface, 10.1.0.5/24
+// for the pod interface and 10.2.0.5/24 for a VLAN sub-interface
+const fakeIPAM = `#!/bin/sh
+dir=$(dirname "$0")
+echo "$CNI_COMMAND $CNI_IFNAME" >> "$dir/calls"
+cat > "$dir/stdin-$CNI_COMMAND"
+if [ "$CNI_COMMAND" = ADD ]; then
+	case "$CNI_IFNAME" in
+	*.*) address=10.2.0.5/24 ;;
+	*) address=10.1.0.5/24 ;;
+	esac
+	echo "{\"cniVersion\": \"1.0.0\", \"ips\": [{\"address\": \"$address\"}]}"
+fi
+`
+
+// installFakeIPAM installs fakeIPAM as the IPAM plugin "fake-ipam" and returns its directory, which holds the log of
+// its calls, "calls", and the stdin of its last call of every command, e.g. "stdin-GC"
+func installFakeIPAM() string {
+	dir := GinkgoT().TempDir()
+	Expect(os.WriteFile(filepath.Join(dir, "fake-ipam"), []byte(fakeIPAM), 0o700)).To(Succeed()) //nolint:gosec
+	// testutils passes PATH as CNI_PATH
+	GinkgoT().Setenv("PATH", dir+string(filepath.ListSeparator)+os.Getenv("PATH"))
+	GinkgoT().Setenv("CNI_PATH", dir)
+	return dir
+}
@@ -0,0 +1,72 @@
+package cnicommands
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+
+	"github.com/k8snetworkplumbingwg/sriov-cni/pkg/config"
+	"github.com/k8snetworkplumbingwg/sriov-cni/pkg/logging"
+)
+
+// Recover returns the VFs of cached attachments whose netns is gone to their original state, e.g. after a node
+// reboot, or after the plugin crashed before DEL could release them. It returns the cache paths of the recovered
+// attachments. The IP addresses of the attachments are left to the garbage collection of the IPAM plugin.
+func Recover() ([]string, error) {
+	cachedConfs, err := config.LoadConfsFromCache()
+	if err != nil {
+		return nil, err
+	}
+
+	// A VF is still in use if an attachment with a live netns references it,
+	// e.g. it was allocated to a new pod after the netns of the old one was destroyed.
+	var stale []string
+	inUseDevices := make(map[string]bool)
+	for cRefPath, netConf := range cachedConfs {
+		if netNSExists(netConf.NetNS) {
+			inUseDevices[netConf.DeviceID] = true
+			continue
+		}
+		stale = append(stale, cRefPath)
+	}
+	sort.Strings(stale)
+
+	var recovered []string
+	var errs []error
+	for _, cRefPath := range stale {
+		netConf := cachedConfs[cRefPath]
+		logging.Info("Recovering VF of attachment without netns",
+			"func", "Recover",
+			"cRefPath", cRefPath,
+			"netConf.DeviceID", netConf.DeviceID,
+			"netConf.NetNS", netConf.NetNS,
+			"netConf.PodNamespace", netConf.PodNamespace,
+			"netConf.PodName", netConf.PodName)
+		if err := releaseStaleAttachment(netConf, cRefPath, inUseDevices[netConf.DeviceID]); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		// Several attachments may reference the same VF, reset it only once
+		inUseDevices[netConf.DeviceID] = true
+		recovered = append(recovered, cRefPath)
+	}
+
+	return recovered, errors.Join(errs...)
+}
+
+// netNSExists returns false if the netns at path is known to be gone. NetConfs cached by older versions of the
+// plugin do not record their netns, these are assumed to be alive.
+func netNSExists(path string) bool {
+	if path == "" {
+		return true
+	}
+	netns, err := ns.GetNS(path)
+	if err != nil {
+		var notExist ns.NSPathNotExistErr
+		var notNS ns.NSPathNotNSErr
+		return !errors.As(err, &notExist) && !errors.As(err, &notNS)
+	}
+	netns.Close()
+	return true
+}
@@ -0,0 +1,137 @@
+package cnicommands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/containernetworking/cni/pkg/invoke"
+	"github.com/containernetworking/cni/pkg/skel"
+	"github.com/containernetworking/cni/pkg/types"
+	current "github.com/containernetworking/cni/pkg/types/100"
+	"github.com/containernetworking/plugins/pkg/ns"
+
+	"github.com/k8snetworkplumbingwg/sriov-cni/pkg/logging"
+	sriovtypes "github.com/k8snetworkplumbingwg/sriov-cni/pkg/types"
+)
+
+// vlanIPAMConf returns the network configuration the IPAM plugin of a VLAN sub-interface is run with,
+// that is the network configuration with the ipam block of the sub-interface
+func vlanIPAMConf(stdinData []byte, vlan *sriovtypes.VlanConf) ([]byte, error) {
+	conf := map[string]any{}
+	if err := json.Unmarshal(stdinData, &conf); err != nil {
+		return nil, fmt.Errorf("failed to parse network configuration: %v", err)
+	}
+	conf["ipam"] = vlan.IPAM
+	return json.Marshal(conf)
+}
+
+// execVlanIPAM runs the IPAM plugin of a VLAN sub-interface. The plugin is given the name of the sub-interface
+// as CNI_IFNAME, so that it keeps the allocations of the sub-interfaces and the pod interface apart.
+func execVlanIPAM(command string, args *skel.CmdArgs, vlan *sriovtypes.VlanConf, ifName string) (*current.Result, error) {
+	stdinData, err := vlanIPAMConf(args.StdinData, vlan)
+	if err != nil {
+		return nil, err
+	}
+
+	pluginPath, err := invoke.FindInPath(vlan.IPAMType(), filepath.SplitList(args.Path))
+	if err != nil {
+		return nil, err
+	}
+
+	pluginArgs := &invoke.Args{
+		Command:       command,
+		ContainerID:   args.ContainerID,
+		NetNS:         args.Netns,
+		PluginArgsStr: args.Args,
+		IfName:        ifName,
+		Path:          args.Path,
+	}
+	if command != "ADD" {
+		return nil, invoke.ExecPluginWithoutResult(context.TODO(), pluginPath, stdinData, pluginArgs, nil)
+	}
+
+	r, err := invoke.ExecPluginWithResult(context.TODO(), pluginPath, stdinData, pluginArgs, nil)
+	if err != nil {
+		return nil, err
+	}
+	return current.NewResultFromResult(r)
+}
+
+// addVlanInterfaces adds the VLAN sub-interfaces of the pod interface podIf to result. The addresses returned by
+// their IPAM plugins are configured on them and added to result as well.
+func addVlanInterfaces(args *skel.CmdArgs, netConf *sriovtypes.NetConf, netns ns.NetNS, result *current.Result, podIf *current.Interface) error {
+	for i := range netConf.Vlans {
+		vlan := &netConf.Vlans[i]
+		iface := &current.Interface{
+			Name:    vlan.IfName(args.IfName),
+			Mac:     podIf.Mac,
+			Sandbox: netns.Path(),
+		}
+		result.Interfaces = append(result.Interfaces, iface)
+		ifIndex := len(result.Interfaces) - 1
+
+		if vlan.IPAMType() == "" {
+			continue
+		}
+
+		logging.Debug("Run IPAM plugin of VLAN sub-interface",
+			"func", "addVlanInterfaces",
+			"ifName", iface.Name,
+			"ipam", vlan.IPAMType())
+		vlanResult, err := execVlanIPAM("ADD", args, vlan, iface.Name)
+		if err != nil {
+			return fmt.Errorf("failed to set up IPAM plugin type %q of VLAN sub-interface %s: %v", vlan.IPAMType(), iface.Name, err)
+		}
+		if len(vlanResult.IPs) == 0 {
+			return fmt.Errorf("IPAM plugin of VLAN sub-interface %s returned missing IP config", iface.Name)
+		}
+
+		// configure the sub-interface with its own addresses and routes only
+		if err = configureIface(netns, iface, vlanResult.IPs, vlanResult.Routes); err != nil {
+			return fmt.Errorf("failed to configure VLAN sub-interface %s: %v", iface.Name, err)
+		}
+
+		for _, ipc := range vlanResult.IPs {
+			ipc.Interface = current.Int(ifIndex)
+		}
+		result.IPs = append(result.IPs, vlanResult.IPs...)
+		result.Routes = append(result.Routes, vlanResult.Routes...)
+	}
+
+	return nil
+}
+
+// releaseVlanIPAM releases the addresses of the VLAN sub-interfaces of the pod interface
+func releaseVlanIPAM(args *skel.CmdArgs, netConf *sriovtypes.NetConf) error {
+	var errs []error
+	for i := range netConf.Vlans {
+		vlan := &netConf.Vlans[i]
+		if vlan.IPAMType() == "" {
+			continue
+		}
+		ifName := vlan.IfName(args.IfName)
+		if _, err := execVlanIPAM("DEL", args, vlan, ifName); err != nil {
+			errs = append(errs, fmt.Errorf("failed to release IPAM of VLAN sub-interface %s: %v", ifName, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// vlanGCConf returns the network configuration the IPAM plugin is garbage collected with. The VLAN sub-interfaces of
+// the valid attachments are valid attachments too, otherwise an IPAM plugin shared with the pod interface would
+// release their addresses.
+func vlanGCConf(stdinData []byte, gcConf *types.NetConf, vlanAttachments []types.GCAttachment) ([]byte, error) {
+	if len(vlanAttachments) == 0 {
+		return stdinData, nil
+	}
+
+	conf := map[string]any{}
+	if err := json.Unmarshal(stdinData, &conf); err != nil {
+		return nil, fmt.Errorf("failed to parse network configuration: %v", err)
+	}
+	conf["cni.dev/valid-attachments"] = append(gcConf.ValidAttachments, vlanAttachments...)
+	return json.Marshal(conf)
+}
@@ -1,12 +1,20 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"net"
+	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/containernetworking/cni/pkg/skel"
+	"github.com/containernetworking/cni/pkg/types"
+	current "github.com/containernetworking/cni/pkg/types/100"
+	"github.com/containernetworking/cni/pkg/version"
 
 	"github.com/k8snetworkplumbingwg/sriov-cni/pkg/logging"
 	sriovtypes "github.com/k8snetworkplumbingwg/sriov-cni/pkg/types"
@@ -16,6 +24,8 @@ import (
 var (
 	// DefaultCNIDir used for caching NetConf
 	DefaultCNIDir = "/var/lib/cni/sriov"
+	// DefaultDeviceInfoDir used for publishing the device information of network attachments
+	DefaultDeviceInfoDir = "/var/run/k8s.cni.cncf.io/devinfo/cni"
 )
 
 // SetLogging sets global logging parameters.
@@ -33,51 +43,102 @@ func SetLogging(stdinData []byte, containerID, netns, ifName string) error {
 func LoadConf(bytes []byte) (*sriovtypes.NetConf, error) {
 	n := &sriovtypes.NetConf{}
 	if err := json.Unmarshal(bytes, n); err != nil {
-		return nil, fmt.Errorf("LoadConf(): failed to load netconf: %v", err)
+		return nil, types.NewError(types.ErrDecodingFailure, "LoadConf(): failed to load netconf", err.Error())
+	}
+	if err := checkConfKeys(bytes, n.Strict); err != nil {
+		return nil, err
+	}
+
+	// check the values before a VF is locked
+	if err := validateConfValues(n); err != nil {
+		return nil, err
+	}
+
+	allocator := utils.NewPCIAllocator(DefaultCNIDir)
+
+	// Without a deviceID or a VF index the VF is picked from the pool of VFs of the configured PFs
+	poolMode := n.DeviceID == "" && n.VFIndex == nil && (n.Master != "" || len(n.PFNames) > 0)
+	if poolMode {
+		pciAddr, err := selectFreeVF(n, allocator)
+		if err != nil {
+			return nil, fmt.Errorf("LoadConf(): failed to select a VF from the pool: %w", err)
+		}
+		n.DeviceID = pciAddr
+	}
+
+	// Without a deviceID the VF can be given by its PF name and VF index
+	if n.DeviceID == "" && n.VFIndex != nil {
+		if n.Master == "" {
+			return nil, invalidConfError("LoadConf(): master is required to select a VF by vfIndex")
+		}
+		if *n.VFIndex < 0 {
+			return nil, invalidConfError("LoadConf(): vfIndex %d invalid: value must not be negative", *n.VFIndex)
+		}
+		pciAddr, err := utils.GetPciAddress(n.Master, *n.VFIndex)
+		if err != nil {
+			return nil, fmt.Errorf("LoadConf(): failed to get the pci address of VF %d of PF %s: %q", *n.VFIndex, n.Master, err)
+		}
+		n.DeviceID = pciAddr
 	}
 
 	// DeviceID takes precedence; if we are given a VF pciaddr then work from there
-	if n.DeviceID != "" {
+	if n.DeviceID != "" && utils.IsAuxDevice(n.DeviceID) {
+		// Scalable Functions have no physfn/virtfn links, their PF is the parent of the auxiliary device
+		pfName, sfNum, err := utils.GetSfInfo(n.DeviceID)
+		if err != nil {
+			return nil, types.NewError(sriovtypes.ErrPfNotFound, "LoadConf(): failed to get SF information", err.Error())
+		}
+		// there is no VF to configure through the PF
+		n.VFID = -1
+		n.SFNum = &sfNum
+		n.Master = pfName
+	} else if n.DeviceID != "" {
 		// Get rest of the VF information
 		pfName, vfID, err := getVfInfo(n.DeviceID)
 		if err != nil {
-			return nil, fmt.Errorf("LoadConf(): failed to get VF information: %q", err)
+			return nil, types.NewError(sriovtypes.ErrPfNotFound, "LoadConf(): failed to get VF information", err.Error())
 		}
 		n.VFID = vfID
 		n.Master = pfName
 	} else {
-		return nil, fmt.Errorf("LoadConf(): VF pci addr is required")
+		return nil, invalidConfError("LoadConf(): VF pci addr or master and vfIndex are required")
 	}
 
-	allocator := utils.NewPCIAllocator(DefaultCNIDir)
-	err := allocator.Lock(n.DeviceID)
-	if err != nil {
-		return nil, err
-	}
-	logging.Debug("Acquired device lock",
-		"func", "LoadConf",
-		"DeviceID", n.DeviceID)
-
-	// Check if the device is already allocated.
-	// This is to prevent issues where kubelet request to delete a pod and in the same time a new pod using the same
-	// vf is started. we can have an issue where the cmdDel of the old pod is called AFTER the cmdAdd of the new one
-	// This will block the new pod creation until the cmdDel is done.
-	logging.Debug("Check if the device is already allocated",
-		"func", "LoadConf",
-		"DefaultCNIDir", DefaultCNIDir,
-		"n.DeviceID", n.DeviceID)
-	isAllocated, err := allocator.IsAllocated(n.DeviceID)
-	if err != nil {
-		return n, err
-	}
+	// A VF selected from the pool is already locked and known to be free
+	if !poolMode {
+		err := allocator.Lock(n.DeviceID)
+		if err != nil {
+			return nil, err
+		}
+		logging.Debug("Acquired device lock",
+			"func", "LoadConf",
+			"DeviceID", n.DeviceID)
+
+		// Check if the device is already allocated.
+		// This is to prevent issues where kubelet request to delete a pod and in the same time a new pod using the same
+		// vf is started. we can have an issue where the cmdDel of the old pod is called AFTER the cmdAdd of the new one
+		// This will block the new pod creation until the cmdDel is done.
+		logging.Debug("Check if the device is already allocated",
+			"func", "LoadConf",
+			"DefaultCNIDir", DefaultCNIDir,
+			"n.DeviceID", n.DeviceID)
+		isAllocated, err := allocator.IsAllocated(n.DeviceID)
+		if err != nil {
+			return n, err
+		}
 
-	if isAllocated {
-		return n, fmt.Errorf("pci address %s is already allocated", n.DeviceID)
+		if isAllocated {
+			return n, types.NewError(sriovtypes.ErrVfAllocated, fmt.Sprintf("pci address %s is already allocated", n.DeviceID), "")
+		}
 	}
 
 	// Assuming VF is netdev interface; Get interface name(s)
-	hostIFName, err := utils.GetVFLinkName(n.DeviceID)
-	if err != nil || hostIFName == "" {
+	getLinkName := utils.GetVFLinkName
+	if n.IsSF() {
+		getLinkName = utils.GetSFLinkName
+	}
+	hostIFName, err := getLinkName(n.DeviceID)
+	if (err != nil || hostIFName == "") && !n.IsSF() {
 		// VF interface not found; check if VF has dpdk driver
 		hasDpdkDriver, err := utils.HasDpdkDriver(n.DeviceID)
 		if err != nil {
@@ -90,24 +151,74 @@ func LoadConf(bytes []byte) (*sriovtypes.NetConf, error) {
 		n.OrigVfState.HostIFName = hostIFName
 	}
 
-	if hostIFName == "" && !n.DPDKMode {
+	if n.VdpaType != "" && n.DPDKMode {
+		return nil, invalidConfError("LoadConf(): vdpaType can not be used with VF %s bound to a dpdk driver", n.DeviceID)
+	}
+
+	// VFs bound to a vDPA parent driver, e.g. vp_vdpa, have no netdevice of their own
+	if hostIFName == "" && !n.DPDKMode && n.VdpaType == "" {
 		return nil, fmt.Errorf("LoadConf(): the VF %s does not have a interface name or a dpdk driver", n.DeviceID)
 	}
 
+	// RoCE capable VFs also expose an RDMA device that has to follow the netdev
+	rdmaDevices, err := utils.GetVFRdmaDevices(n.DeviceID)
+	if err != nil {
+		return nil, fmt.Errorf("LoadConf(): failed to get RDMA devices of VF %s: %q", n.DeviceID, err)
+	}
+	if len(rdmaDevices) > 0 {
+		n.RdmaDevice = rdmaDevices[0]
+	}
+
+	if n.IsSF() {
+		if err := validateSFConf(n); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validatePodNetdevConf(n); err != nil {
+		return nil, err
+	}
+
+	if n.RequestedMTU != nil {
+		if n.DPDKMode {
+			return nil, invalidConfError("LoadConf(): mtu can not be set on VF %s bound to a dpdk driver", n.DeviceID)
+		}
+		if n.VdpaType == sriovtypes.VdpaTypeVhost {
+			return nil, invalidConfError("LoadConf(): mtu can not be set on VF %s exposed as a vhost vDPA device", n.DeviceID)
+		}
+	}
+
+	if n.VlanTrunk != "" {
+		if err := utils.CheckVFVlanTrunkSupport(n.Master, n.VFID); err != nil {
+			return nil, invalidConfError("LoadConf(): vlanTrunk can not be set on VF %s: %v", n.DeviceID, err)
+		}
+	}
+
+	return n, nil
+}
+
+// validateConfValues checks the values of the VF settings that do not depend on the device, it sets the defaults of
+// vlan QoS and proto
+func validateConfValues(n *sriovtypes.NetConf) error {
+	// the VF selected by vfIndex could be another one than the VF given by deviceID
+	if n.DeviceID != "" && n.VFIndex != nil {
+		return invalidConfError("LoadConf(): vfIndex can not be used together with deviceID")
+	}
+
 	if n.Vlan == nil {
 		// validate non-nil value for vlan qos
 		if n.VlanQoS != nil {
-			return nil, fmt.Errorf("LoadConf(): vlan id must be configured to set vlan QoS to a non-nil value")
+			return invalidConfError("LoadConf(): vlan id must be configured to set vlan QoS to a non-nil value")
 		}
 
 		// validate non-nil value for vlan proto
 		if n.VlanProto != nil {
-			return nil, fmt.Errorf("LoadConf(): vlan id must be configured to set vlan proto to a non-nil value")
+			return invalidConfError("LoadConf(): vlan id must be configured to set vlan proto to a non-nil value")
 		}
 	} else {
 		// validate vlan id range
 		if *n.Vlan < 0 || *n.Vlan > 4094 {
-			return nil, fmt.Errorf("LoadConf(): vlan id %d invalid: value must be in the range 0-4094", *n.Vlan)
+			return invalidConfError("LoadConf(): vlan id %d invalid: value must be in the range 0-4094", *n.Vlan)
 		}
 
 		if n.VlanQoS == nil {
@@ -117,12 +228,12 @@ func LoadConf(bytes []byte) (*sriovtypes.NetConf, error) {
 
 		// validate that VLAN QoS is in the 0-7 range
 		if *n.VlanQoS < 0 || *n.VlanQoS > 7 {
-			return nil, fmt.Errorf("LoadConf(): vlan QoS PCP %d invalid: value must be in the range 0-7", *n.VlanQoS)
+			return invalidConfError("LoadConf(): vlan QoS PCP %d invalid: value must be in the range 0-7", *n.VlanQoS)
 		}
 
 		// validate non-zero value for vlan id if vlan qos is set to a non-zero value
 		if *n.VlanQoS != 0 && *n.Vlan == 0 {
-			return nil, fmt.Errorf("LoadConf(): non-zero vlan id must be configured to set vlan QoS to a non-zero value")
+			return invalidConfError("LoadConf(): non-zero vlan id must be configured to set vlan QoS to a non-zero value")
 		}
 
 		if n.VlanProto == nil {
@@ -132,21 +243,564 @@ func LoadConf(bytes []byte) (*sriovtypes.NetConf, error) {
 
 		*n.VlanProto = strings.ToLower(*n.VlanProto)
 		if *n.VlanProto != sriovtypes.Proto8021ad && *n.VlanProto != sriovtypes.Proto8021q {
-			return nil, fmt.Errorf("LoadConf(): vlan Proto %s invalid: value must be '802.1Q' or '802.1ad'", *n.VlanProto)
+			return invalidConfError("LoadConf(): vlan Proto %s invalid: value must be '802.1Q' or '802.1ad'", *n.VlanProto)
 		}
 
 		// validate non-zero value for vlan id if vlan proto is set to 802.1ad
 		if *n.VlanProto == sriovtypes.Proto8021ad && *n.Vlan == 0 {
-			return nil, fmt.Errorf("LoadConf(): non-zero vlan id must be configured to set vlan proto 802.1ad")
+			return invalidConfError("LoadConf(): non-zero vlan id must be configured to set vlan proto 802.1ad")
+		}
+	}
+
+	if n.MAC != "" {
+		mac, err := NormalizeMAC(n.MAC, n.LocalMACOnly)
+		if err != nil {
+			return invalidConfError("LoadConf(): %v", err)
+		}
+		n.MAC = mac
+	}
+	if n.RuntimeConfig.Mac != "" {
+		mac, err := NormalizeMAC(n.RuntimeConfig.Mac, n.LocalMACOnly)
+		if err != nil {
+			return invalidConfError("LoadConf(): runtimeConfig %v", err)
+		}
+		n.RuntimeConfig.Mac = mac
+	}
+	if n.GUID != "" {
+		guid, err := NormalizeGUID(n.GUID)
+		if err != nil {
+			return invalidConfError("LoadConf(): %v", err)
+		}
+		n.GUID = guid
+	}
+	if n.RuntimeConfig.GUID != "" {
+		guid, err := NormalizeGUID(n.RuntimeConfig.GUID)
+		if err != nil {
+			return invalidConfError("LoadConf(): runtimeConfig %v", err)
+		}
+		n.RuntimeConfig.GUID = guid
+	}
+
+	if n.MACGeneration != nil {
+		if err := validateMACGenerationConf(n.MACGeneration); err != nil {
+			return err
 		}
 	}
 
 	// validate that link state is one of supported values
 	if n.LinkState != "" && n.LinkState != "auto" && n.LinkState != "enable" && n.LinkState != "disable" {
-		return nil, fmt.Errorf("LoadConf(): invalid link_state value: %s", n.LinkState)
+		return invalidConfError("LoadConf(): invalid link_state value: %s", n.LinkState)
 	}
 
-	return n, nil
+	if n.VdpaType != "" && n.VdpaType != sriovtypes.VdpaTypeVhost && n.VdpaType != sriovtypes.VdpaTypeVirtio {
+		return invalidConfError("LoadConf(): invalid vdpaType value: %s", n.VdpaType)
+	}
+
+	rates := []struct {
+		key   string
+		value *int
+	}{
+		{"min_tx_rate", n.MinTxRate},
+		{"max_tx_rate", n.MaxTxRate},
+	}
+	for _, rate := range rates {
+		if rate.value != nil && *rate.value < 0 {
+			return invalidConfError("LoadConf(): %s %d invalid: value must not be negative", rate.key, *rate.value)
+		}
+	}
+	// a max tx rate of 0 disables the limit, any min tx rate is below it
+	if n.MinTxRate != nil && n.MaxTxRate != nil && *n.MaxTxRate > 0 && *n.MinTxRate > *n.MaxTxRate {
+		return invalidConfError("LoadConf(): min_tx_rate %d must not be greater than max_tx_rate %d", *n.MinTxRate, *n.MaxTxRate)
+	}
+
+	if n.TxRateMode != "" && n.TxRateMode != sriovtypes.TxRateModeHardware && n.TxRateMode != sriovtypes.TxRateModeSoftware {
+		return invalidConfError("LoadConf(): invalid txRateMode value: %s", n.TxRateMode)
+	}
+	// a tc rate limiter in the pod only enforces a max tx rate
+	if n.TxRateMode == sriovtypes.TxRateModeSoftware && n.MinTxRate != nil && *n.MinTxRate != 0 {
+		return invalidConfError("LoadConf(): min_tx_rate is not supported with software tx rate limiting")
+	}
+
+	// allmulticast and promiscuous mode are only honored by the PF driver for trusted VFs
+	switches := []struct {
+		key   string
+		value string
+	}{
+		{"spoofchk", n.SpoofChk},
+		{"trust", n.Trust},
+		{"allmulti", n.AllMulti},
+		{"promisc", n.Promisc},
+	}
+	for _, sw := range switches {
+		if sw.value != "" && sw.value != "on" && sw.value != "off" {
+			return invalidConfError("LoadConf(): invalid %s value: %s", sw.key, sw.value)
+		}
+	}
+	if n.AllMulti == "on" && n.Trust != "on" {
+		return invalidConfError("LoadConf(): allmulti requires trust to be on")
+	}
+	if n.Promisc == "on" && n.Trust != "on" {
+		return invalidConfError("LoadConf(): promisc requires trust to be on")
+	}
+
+	if n.RequestedMTU != nil && *n.RequestedMTU <= 0 {
+		return invalidConfError("LoadConf(): mtu %d invalid: value must be positive", *n.RequestedMTU)
+	}
+
+	if n.UsePrevResultIPs && n.IPAM.Type != "" {
+		return invalidConfError("LoadConf(): usePrevResultIPs can not be used together with ipam")
+	}
+
+	if n.Ethtool != nil {
+		if err := validateEthtoolConf(n); err != nil {
+			return err
+		}
+	}
+
+	if len(n.Vlans) > 0 {
+		if err := validateVlansConf(n); err != nil {
+			return err
+		}
+	}
+
+	if n.VlanTrunk != "" {
+		// vlan 0 would clear the port VLAN, which a trunk config does not expect to be touched
+		if n.Vlan != nil {
+			return invalidConfError("LoadConf(): vlanTrunk can not be used together with vlan %d", *n.Vlan)
+		}
+		if _, err := utils.ParseVlanTrunk(n.VlanTrunk); err != nil {
+			return invalidConfError("LoadConf(): %v", err)
+		}
+	}
+
+	return nil
+}
+
+// NormalizeMAC parses the MAC address to set on a VF and returns it in the lower case colon format netlink reports.
+// Multicast, broadcast and all-zero addresses are rejected, PF drivers either fail to set them or leave the VF with
+// another address. If localOnly is set, universally administered addresses are rejected as well.
+func NormalizeMAC(mac string, localOnly bool) (string, error) {
+	hwAddr, err := net.ParseMAC(mac)
+	if err != nil {
+		return "", fmt.Errorf("mac %q invalid: %v", mac, err)
+	}
+	if len(hwAddr) != 6 {
+		return "", fmt.Errorf("mac %q invalid: value must be a 48-bit Ethernet address", mac)
+	}
+	if !utils.IsValidMACAddress(hwAddr) {
+		return "", fmt.Errorf("mac %q invalid: all-zero and broadcast addresses can not be set on a VF", mac)
+	}
+	if hwAddr[0]&0x01 != 0 {
+		return "", fmt.Errorf("mac %q invalid: multicast addresses can not be set on a VF", mac)
+	}
+	if localOnly && hwAddr[0]&0x02 == 0 {
+		return "", fmt.Errorf("mac %q invalid: value must be a locally administered address as localMACOnly is set", mac)
+	}
+	return hwAddr.String(), nil
+}
+
+// NormalizeGUID parses the node and port GUID to set on an InfiniBand VF and returns it in the lower case colon format.
+func NormalizeGUID(guid string) (string, error) {
+	hwAddr, err := net.ParseMAC(guid)
+	if err != nil {
+		return "", fmt.Errorf("guid %q invalid: %v", guid, err)
+	}
+	if len(hwAddr) != 8 {
+		return "", fmt.Errorf("guid %q invalid: value must be 8 colon separated bytes", guid)
+	}
+	return hwAddr.String(), nil
+}
+
+// validateMACGenerationConf checks that the MAC addresses generated with conf are locally administered unicast ones
+func validateMACGenerationConf(conf *sriovtypes.MACGenerationConf) error {
+	switch conf.Source {
+	case sriovtypes.MACSourceContainerID, sriovtypes.MACSourcePod, sriovtypes.MACSourceNetwork:
+	default:
+		return invalidConfError("LoadConf(): invalid macGeneration source value: %s", conf.Source)
+	}
+
+	prefix, err := parseMACPrefix(conf.Prefix)
+	if err != nil {
+		return invalidConfError("LoadConf(): %v", err)
+	}
+	if prefix[0]&0x03 != 0x02 {
+		return invalidConfError("LoadConf(): macGeneration prefix %q invalid: the first octet must be a locally administered unicast one, e.g. 02", conf.Prefix)
+	}
+	return nil
+}
+
+// parseMACPrefix parses the leading octets of a generated MAC address, at least one octet of the address is left to
+// the hash
+func parseMACPrefix(prefix string) ([]byte, error) {
+	if prefix == "" {
+		return []byte{0x02}, nil
+	}
+
+	octets := strings.Split(prefix, ":")
+	if len(octets) > 5 {
+		return nil, fmt.Errorf("macGeneration prefix %q invalid: value must have at most 5 octets", prefix)
+	}
+	parsed := make([]byte, 0, len(octets))
+	for _, octet := range octets {
+		b, err := hex.DecodeString(octet)
+		if err != nil || len(b) != 1 {
+			return nil, fmt.Errorf("macGeneration prefix %q invalid: value must be colon separated octets, e.g. 0a:58", prefix)
+		}
+		parsed = append(parsed, b[0])
+	}
+	return parsed, nil
+}
+
+// GenerateMAC derives the MAC address of the pod interface ifName of the network netName from the identity of the
+// attachment selected by conf. The same identity always gives the same address.
+func GenerateMAC(conf *sriovtypes.MACGenerationConf, netName, containerID, podNamespace, podName, ifName string) (string, error) {
+	prefix, err := parseMACPrefix(conf.Prefix)
+	if err != nil {
+		return "", err
+	}
+
+	var identity []string
+	switch conf.Source {
+	case sriovtypes.MACSourceContainerID:
+		identity = []string{netName, containerID, ifName}
+	case sriovtypes.MACSourcePod:
+		if podNamespace == "" || podName == "" {
+			return "", fmt.Errorf("macGeneration source pod requires K8S_POD_NAMESPACE and K8S_POD_NAME in CNI_ARGS")
+		}
+		identity = []string{netName, podNamespace, podName, ifName}
+	case sriovtypes.MACSourceNetwork:
+		identity = []string{netName, ifName}
+	default:
+		return "", fmt.Errorf("invalid macGeneration source value: %s", conf.Source)
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(identity, "/")))
+	mac := append(net.HardwareAddr{}, prefix...)
+	mac = append(mac, sum[:6-len(prefix)]...)
+	return mac.String(), nil
+}
+
+// validatePodNetdevConf rejects the settings that are applied to the VF netdevice in the pod,
+// which VFs bound to a dpdk driver or exposed as a vhost vDPA device do not have
+func validatePodNetdevConf(n *sriovtypes.NetConf) error {
+	if n.HasPodNetdev() {
+		return nil
+	}
+
+	unsupported := []struct {
+		key string
+		set bool
+	}{
+		{"allmulti", n.AllMulti != ""},
+		{"promisc", n.Promisc != ""},
+		{"ethtool", n.Ethtool != nil},
+		{"vlans", len(n.Vlans) > 0},
+		{"software tx rate limiting", n.TxRateMode == sriovtypes.TxRateModeSoftware},
+	}
+	for _, u := range unsupported {
+		if u.set {
+			return invalidConfError("LoadConf(): %s can not be set on VF %s without a netdevice in the pod", u.key, n.DeviceID)
+		}
+	}
+	return nil
+}
+
+// IsBondConf returns true if the stdin netconf bonds several VFs into the pod interface
+func IsBondConf(bytes []byte) bool {
+	n := struct {
+		Bond json.RawMessage `json:"bond"`
+	}{}
+	return json.Unmarshal(bytes, &n) == nil && len(n.Bond) > 0 && string(n.Bond) != "null"
+}
+
+// LoadBondConf parses and validates the stdin netconf of a bond and returns it along with the NetConf of every link.
+// The NetConf of a link is loaded by LoadConf from the stdin netconf with the VF selection of the link in place of
+// the bond, so that all links share the VF settings. The IPAM configuration applies to the bond only.
+func LoadBondConf(bytes []byte) (*sriovtypes.NetConf, []*sriovtypes.NetConf, error) {
+	n := &sriovtypes.NetConf{}
+	if err := json.Unmarshal(bytes, n); err != nil {
+		return nil, nil, types.NewError(types.ErrDecodingFailure, "LoadBondConf(): failed to load netconf", err.Error())
+	}
+	if err := checkConfKeys(bytes, n.Strict); err != nil {
+		return nil, nil, err
+	}
+	if err := validateBondConf(n); err != nil {
+		return nil, nil, err
+	}
+
+	conf := map[string]any{}
+	if err := json.Unmarshal(bytes, &conf); err != nil {
+		return nil, nil, types.NewError(types.ErrDecodingFailure, "LoadBondConf(): failed to load netconf", err.Error())
+	}
+	delete(conf, "bond")
+	delete(conf, "ipam")
+
+	links := make([]*sriovtypes.NetConf, 0, len(n.Bond.Links))
+	for i := range n.Bond.Links {
+		linkBytes, err := bondLinkConf(conf, &n.Bond.Links[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		link, err := LoadConf(linkBytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("LoadBondConf(): failed to load netconf of bond link %d: %w", i, err)
+		}
+		if !link.HasPodNetdev() {
+			return nil, nil, invalidConfError("LoadBondConf(): VF %s of bond link %d has no netdevice to bond in the pod", link.DeviceID, i)
+		}
+		links = append(links, link)
+	}
+
+	return n, links, nil
+}
+
+// bondLinkConf returns the stdin netconf of a bond link, that is conf with the VF selection of link
+func bondLinkConf(conf map[string]any, link *sriovtypes.BondLink) ([]byte, error) {
+	linkBytes, err := json.Marshal(link)
+	if err != nil {
+		return nil, err
+	}
+	linkConf := map[string]any{}
+	if err := json.Unmarshal(linkBytes, &linkConf); err != nil {
+		return nil, err
+	}
+	for k, v := range conf {
+		linkConf[k] = v
+	}
+	return json.Marshal(linkConf)
+}
+
+// validateBondConf checks the bond configuration, the VF settings of its links are checked by LoadConf
+func validateBondConf(n *sriovtypes.NetConf) error {
+	if n.Bond.Mode != sriovtypes.BondModeActiveBackup && n.Bond.Mode != sriovtypes.BondMode8023AD {
+		return invalidConfError("LoadBondConf(): invalid bond mode value: %s", n.Bond.Mode)
+	}
+	if n.Bond.Miimon != nil && *n.Bond.Miimon < 0 {
+		return invalidConfError("LoadBondConf(): bond miimon %d invalid: value must not be negative", *n.Bond.Miimon)
+	}
+	if len(n.Bond.Links) < 2 {
+		return invalidConfError("LoadBondConf(): bond requires at least 2 links")
+	}
+	if n.UsePrevResultIPs && n.IPAM.Type != "" {
+		return invalidConfError("LoadBondConf(): usePrevResultIPs can not be used together with ipam")
+	}
+
+	// the VFs are selected by the links only, and VLAN sub-interfaces would end up on the links
+	unsupported := []struct {
+		key string
+		set bool
+	}{
+		{"deviceID", n.DeviceID != ""},
+		{"master", n.Master != ""},
+		{"vfIndex", n.VFIndex != nil},
+		{"pfNames", len(n.PFNames) > 0},
+		{"vfRange", n.VFRange != ""},
+		{"vlans", len(n.Vlans) > 0},
+	}
+	for _, u := range unsupported {
+		if u.set {
+			return invalidConfError("LoadBondConf(): %s can not be used together with bond", u.key)
+		}
+	}
+
+	deviceIDs := make(map[string]bool, len(n.Bond.Links))
+	for i := range n.Bond.Links {
+		link := &n.Bond.Links[i]
+		if link.DeviceID == "" && link.Master == "" && len(link.PFNames) == 0 {
+			return invalidConfError("LoadBondConf(): bond link %d requires a deviceID, master or pfNames", i)
+		}
+		if link.DeviceID != "" {
+			if deviceIDs[link.DeviceID] {
+				return invalidConfError("LoadBondConf(): bond link deviceID %s is used more than once", link.DeviceID)
+			}
+			deviceIDs[link.DeviceID] = true
+		}
+	}
+
+	return nil
+}
+
+// validateEthtoolConf checks the ethtool configuration, which is applied to the VF netdevice in the pod
+func validateEthtoolConf(n *sriovtypes.NetConf) error {
+	for name := range n.Ethtool.Features {
+		if name == "" {
+			return invalidConfError("LoadConf(): ethtool feature name must not be empty")
+		}
+	}
+
+	if n.Ethtool.Rings != nil {
+		if (n.Ethtool.Rings.Rx != nil && *n.Ethtool.Rings.Rx == 0) || (n.Ethtool.Rings.Tx != nil && *n.Ethtool.Rings.Tx == 0) {
+			return invalidConfError("LoadConf(): ethtool ring sizes must be positive")
+		}
+	}
+
+	return nil
+}
+
+// validateVlansConf checks the VLAN sub-interfaces, which are created on top of the VF netdevice in the pod
+func validateVlansConf(n *sriovtypes.NetConf) error {
+	// the sub-interfaces carry the tags, the VF must not tag or filter on its own
+	if n.Vlan != nil && *n.Vlan != 0 {
+		return invalidConfError("LoadConf(): vlans can not be used together with vlan %d", *n.Vlan)
+	}
+
+	ids := make(map[int]bool, len(n.Vlans))
+	names := make(map[string]bool, len(n.Vlans))
+	for i := range n.Vlans {
+		vlan := &n.Vlans[i]
+		if vlan.ID < 1 || vlan.ID > 4094 {
+			return invalidConfError("LoadConf(): vlans id %d invalid: value must be in the range 1-4094", vlan.ID)
+		}
+		if ids[vlan.ID] {
+			return invalidConfError("LoadConf(): vlans id %d is used more than once", vlan.ID)
+		}
+		ids[vlan.ID] = true
+
+		if vlan.Name != "" {
+			if len(vlan.Name) > 15 {
+				return invalidConfError("LoadConf(): vlans name %q is longer than 15 characters", vlan.Name)
+			}
+			if names[vlan.Name] {
+				return invalidConfError("LoadConf(): vlans name %q is used more than once", vlan.Name)
+			}
+			names[vlan.Name] = true
+		}
+
+		if len(vlan.IPAM) > 0 && vlan.IPAMType() == "" {
+			return invalidConfError("LoadConf(): vlans id %d has an ipam configuration without a type", vlan.ID)
+		}
+	}
+
+	return nil
+}
+
+// selectFreeVF returns the pci address of the first VF of the configured PF pool that is neither being configured
+// by another process nor allocated. The VF is returned locked.
+func selectFreeVF(n *sriovtypes.NetConf, allocator *utils.PCIAllocator) (string, error) {
+	if n.Master != "" && len(n.PFNames) > 0 {
+		return "", invalidConfError("master and pfNames are mutually exclusive")
+	}
+
+	pfNames := n.PFNames
+	if n.Master != "" {
+		pfNames = []string{n.Master}
+	}
+
+	firstVF, lastVF := 0, -1
+	if n.VFRange != "" {
+		if n.Master == "" {
+			return "", invalidConfError("master is required to select a VF from vfRange")
+		}
+		var err error
+		firstVF, lastVF, err = parseVFRange(n.VFRange)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	for _, pfName := range pfNames {
+		numVfs, err := utils.GetSriovNumVfs(pfName)
+		if err != nil {
+			return "", err
+		}
+
+		last := numVfs - 1
+		if lastVF >= 0 && lastVF < last {
+			last = lastVF
+		}
+
+		for vfIndex := firstVF; vfIndex <= last; vfIndex++ {
+			pciAddr, err := utils.GetPciAddress(pfName, vfIndex)
+			if err != nil {
+				logging.Debug("Skipping VF without pci address",
+					"func", "selectFreeVF",
+					"pfName", pfName,
+					"vfIndex", vfIndex,
+					"err", err)
+				continue
+			}
+
+			locked, err := allocator.TryLock(pciAddr)
+			if err != nil {
+				return "", err
+			}
+			if !locked {
+				continue
+			}
+
+			isAllocated, err := allocator.IsAllocated(pciAddr)
+			if err == nil && !isAllocated {
+				logging.Debug("Selected free VF from pool",
+					"func", "selectFreeVF",
+					"pfName", pfName,
+					"vfIndex", vfIndex,
+					"pciAddr", pciAddr)
+				return pciAddr, nil
+			}
+
+			if unlockErr := allocator.Unlock(pciAddr); unlockErr != nil {
+				return "", unlockErr
+			}
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return "", types.NewError(sriovtypes.ErrVfAllocated, fmt.Sprintf("no free VF found on PFs %v", pfNames), "")
+}
+
+// validateSFConf rejects the settings that are applied through the VF configuration of the PF,
+// which Scalable Functions do not have
+func validateSFConf(n *sriovtypes.NetConf) error {
+	unsupported := []struct {
+		key string
+		set bool
+	}{
+		{"vlan", n.Vlan != nil || n.VlanQoS != nil || n.VlanProto != nil},
+		{"min_tx_rate", n.MinTxRate != nil},
+		{"max_tx_rate", n.MaxTxRate != nil},
+		{"spoofchk", n.SpoofChk != ""},
+		{"trust", n.Trust != ""},
+		{"link_state", n.LinkState != ""},
+		{"vlanTrunk", n.VlanTrunk != ""},
+		{"guid", n.GUID != ""},
+		{"vdpaType", n.VdpaType != ""},
+	}
+	for _, u := range unsupported {
+		if u.set {
+			return invalidConfError("LoadConf(): %s is not supported for Scalable Function %s", u.key, n.DeviceID)
+		}
+	}
+	return nil
+}
+
+// invalidConfError returns a CNI error for a network configuration that failed validation
+func invalidConfError(format string, a ...interface{}) error {
+	return types.NewError(types.ErrInvalidNetworkConfig, fmt.Sprintf(format, a...), "")
+}
+
+// parseVFRange parses a VF index range in the form "first-last" or a single VF index
+func parseVFRange(vfRange string) (int, int, error) {
+	firstStr, lastStr, isRange := strings.Cut(vfRange, "-")
+	first, err := strconv.Atoi(strings.TrimSpace(firstStr))
+	if err != nil {
+		return 0, 0, invalidConfError("vfRange %q invalid: %v", vfRange, err)
+	}
+
+	last := first
+	if isRange {
+		last, err = strconv.Atoi(strings.TrimSpace(lastStr))
+		if err != nil {
+			return 0, 0, invalidConfError("vfRange %q invalid: %v", vfRange, err)
+		}
+	}
+
+	if first < 0 || last < first {
+		return 0, 0, invalidConfError("vfRange %q invalid: value must be in the form first-last with 0 <= first <= last", vfRange)
+	}
+
+	return first, last, nil
 }
 
 func getVfInfo(vfPci string) (string, int, error) {
@@ -185,6 +839,67 @@ func LoadConfFromCache(args *skel.CmdArgs) (*sriovtypes.NetConf, string, error)
 	return netConf, cRefPath, nil
 }
 
+// LoadConfsFromCache retrieves all NetConfs cached in DefaultCNIDir keyed by the path of their cache file.
+// A bond is cached with a NetConf of its own next to the NetConfs of its links.
+// Files that do not hold a cached NetConf are skipped.
+func LoadConfsFromCache() (map[string]*sriovtypes.NetConf, error) {
+	entries, err := os.ReadDir(DefaultCNIDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read cache directory %s: %v", DefaultCNIDir, err)
+	}
+
+	netConfs := make(map[string]*sriovtypes.NetConf)
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		cRefPath := filepath.Join(DefaultCNIDir, entry.Name())
+		netConfBytes, err := utils.ReadScratchNetConf(cRefPath)
+		if err != nil {
+			return nil, err
+		}
+
+		netConf := &sriovtypes.NetConf{}
+		if err := json.Unmarshal(netConfBytes, netConf); err != nil || (netConf.DeviceID == "" && netConf.Bond == nil) {
+			logging.Debug("Skipping file without a cached NetConf",
+				"func", "LoadConfsFromCache",
+				"cRefPath", cRefPath)
+			continue
+		}
+		netConfs[cRefPath] = netConf
+	}
+
+	return netConfs, nil
+}
+
+// LoadPrevResult parses the prevResult of the stdin netconf and converts it to the current result version.
+// It returns nil if the netconf carries no prevResult.
+func LoadPrevResult(bytes []byte) (*current.Result, error) {
+	n := &types.NetConf{}
+	if err := json.Unmarshal(bytes, n); err != nil {
+		return nil, fmt.Errorf("LoadPrevResult(): failed to load netconf: %v", err)
+	}
+
+	if err := version.ParsePrevResult(n); err != nil {
+		return nil, fmt.Errorf("LoadPrevResult(): failed to parse prevResult: %v", err)
+	}
+
+	if n.PrevResult == nil {
+		return nil, nil
+	}
+
+	prevResult, err := current.NewResultFromResult(n.PrevResult)
+	if err != nil {
+		return nil, fmt.Errorf("LoadPrevResult(): failed to convert prevResult: %v", err)
+	}
+
+	return prevResult, nil
+}
+
 // GetMacAddressForResult return the mac address we should report to the CNI call return object
 // if the device is on kernel mode we report that one back
 // if not we check the administrative mac address on the PF
@@ -193,7 +908,7 @@ func GetMacAddressForResult(netConf *sriovtypes.NetConf) string {
 	if netConf.MAC != "" {
 		return netConf.MAC
 	}
-	if !netConf.DPDKMode {
+	if netConf.HasPodNetdev() {
 		return netConf.OrigVfState.EffectiveMAC
 	}
 	if netConf.OrigVfState.AdminMAC != "00:00:00:00:00:00" {
@@ -1,9 +1,12 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 
+	cnitypes "github.com/containernetworking/cni/pkg/types"
 	"github.com/containernetworking/plugins/pkg/testutils"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -126,6 +129,304 @@ var _ = Describe("Config", func() {
 			Entry("default values for vlan, qos and proto", &zeroVlanID, &zeroQoS, &valid8021qProto, false),
 		)
 
+		DescribeTable("MTU",
+			func(conf string, failure bool) {
+				netConf, err := LoadConf([]byte(conf))
+				if failure {
+					Expect(err).To(HaveOccurred())
+				} else {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(*netConf.RequestedMTU).To(Equal(9000))
+					Expect(netConf.MTU).To(BeNil())
+				}
+			},
+			Entry("valid mtu", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "mtu": 9000}`, false),
+			Entry("zero mtu", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "mtu": 0}`, true),
+			Entry("negative mtu", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "mtu": -1}`, true),
+			Entry("mtu with vhost vdpa", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "mtu": 9000, "vdpaType": "vhost"}`, true),
+			Entry("mtu with virtio vdpa", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "mtu": 9000, "vdpaType": "virtio"}`, false),
+		)
+
+		DescribeTable("MAC address",
+			func(conf string, mac string) {
+				netConf, err := LoadConf([]byte(conf))
+				if mac == "" {
+					Expect(err).To(HaveOccurred())
+					cniErr := &cnitypes.Error{}
+					Expect(errors.As(err, &cniErr)).To(BeTrue())
+					Expect(cniErr.Code).To(Equal(cnitypes.ErrInvalidNetworkConfig))
+				} else {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(netConf.MAC).To(Equal(mac))
+				}
+			},
+			Entry("normalized", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "mac": "CA-FE-C0-FF-EE-00"}`, "ca:fe:c0:ff:ee:00"),
+			Entry("universally administered", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "mac": "e4:11:22:33:44:55"}`, "e4:11:22:33:44:55"),
+			Entry("locally administered with localMACOnly", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "mac": "ca:fe:c0:ff:ee:00", "localMACOnly": true}`, "ca:fe:c0:ff:ee:00"),
+			Entry("universally administered with localMACOnly", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "mac": "e4:11:22:33:44:55", "localMACOnly": true}`, ""),
+			Entry("invalid syntax", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "mac": "e4:11:22:33:44"}`, ""),
+			Entry("EUI-64", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "mac": "e4:11:22:33:44:55:66:77"}`, ""),
+			Entry("multicast", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "mac": "01:00:5e:00:00:01"}`, ""),
+			Entry("broadcast", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "mac": "ff:ff:ff:ff:ff:ff"}`, ""),
+			Entry("all-zero", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "mac": "00:00:00:00:00:00"}`, ""),
+			Entry("multicast in runtimeConfig", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "runtimeConfig": {"mac": "33:33:00:00:00:01"}}`, ""),
+		)
+
+		DescribeTable("GUID",
+			func(conf string, guid string) {
+				netConf, err := LoadConf([]byte(conf))
+				if guid == "" {
+					Expect(err).To(HaveOccurred())
+					cniErr := &cnitypes.Error{}
+					Expect(errors.As(err, &cniErr)).To(BeTrue())
+					Expect(cniErr.Code).To(Equal(cnitypes.ErrInvalidNetworkConfig))
+				} else {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(netConf.GUID).To(Equal(guid))
+				}
+			},
+			Entry("normalized", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "guid": "00:11:22:33:44:55:66:AA"}`, "00:11:22:33:44:55:66:aa"),
+			Entry("invalid syntax", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "guid": "zz"}`, ""),
+			Entry("48-bit address", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "guid": "00:11:22:33:44:55"}`, ""),
+			Entry("invalid syntax in runtimeConfig", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "runtimeConfig": {"guid": "zz"}}`, ""),
+		)
+
+		DescribeTable("MAC generation",
+			func(conf string, failure bool) {
+				_, err := LoadConf([]byte(conf))
+				if failure {
+					Expect(err).To(HaveOccurred())
+				} else {
+					Expect(err).ToNot(HaveOccurred())
+				}
+			},
+			Entry("default prefix", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "macGeneration": {"source": "pod"}}`, false),
+			Entry("prefix", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "macGeneration": {"prefix": "0a:58:0a", "source": "containerID"}}`, false),
+			Entry("invalid source", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "macGeneration": {"source": "node"}}`, true),
+			Entry("universally administered prefix", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "macGeneration": {"prefix": "00:1b:21", "source": "pod"}}`, true),
+			Entry("multicast prefix", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "macGeneration": {"prefix": "03", "source": "pod"}}`, true),
+			Entry("prefix without room for the hash", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "macGeneration": {"prefix": "02:11:22:33:44:55", "source": "pod"}}`, true),
+			Entry("invalid prefix", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "macGeneration": {"prefix": "2:g", "source": "pod"}}`, true),
+		)
+
+		DescribeTable("vDPA type",
+			func(vdpaType string, failure bool) {
+				netConf, err := LoadConf([]byte(`{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "vdpaType": "` + vdpaType + `"}`))
+				if failure {
+					Expect(err).To(HaveOccurred())
+				} else {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(netConf.VdpaType).To(Equal(vdpaType))
+				}
+			},
+			Entry("vhost", "vhost", false),
+			Entry("virtio", "virtio", false),
+			Entry("invalid", "vhost-user", true),
+		)
+
+		DescribeTable("allmulti and promisc",
+			func(conf string, failure bool) {
+				_, err := LoadConf([]byte(conf))
+				if failure {
+					Expect(err).To(HaveOccurred())
+				} else {
+					Expect(err).ToNot(HaveOccurred())
+				}
+			},
+			Entry("allmulti on with trust on", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "trust": "on", "allmulti": "on"}`, false),
+			Entry("promisc on with trust on", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "trust": "on", "promisc": "on"}`, false),
+			Entry("allmulti off without trust", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "allmulti": "off"}`, false),
+			Entry("allmulti on without trust", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "allmulti": "on"}`, true),
+			Entry("promisc on with trust off", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "trust": "off", "promisc": "on"}`, true),
+			Entry("invalid allmulti", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "trust": "on", "allmulti": "yes"}`, true),
+			Entry("promisc with vhost vdpa", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "trust": "on", "promisc": "on", "vdpaType": "vhost"}`, true),
+		)
+
+		DescribeTable("tx rate mode",
+			func(conf string, failure bool) {
+				_, err := LoadConf([]byte(conf))
+				if failure {
+					Expect(err).To(HaveOccurred())
+				} else {
+					Expect(err).ToNot(HaveOccurred())
+				}
+			},
+			Entry("software with max_tx_rate", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "txRateMode": "software", "max_tx_rate": 100}`, false),
+			Entry("hardware with min_tx_rate", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "txRateMode": "hardware", "min_tx_rate": 10}`, false),
+			Entry("software with min_tx_rate", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "txRateMode": "software", "min_tx_rate": 10}`, true),
+			Entry("software with vhost vdpa", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "txRateMode": "software", "vdpaType": "vhost"}`, true),
+			Entry("invalid", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "txRateMode": "tc"}`, true),
+			Entry("min_tx_rate above max_tx_rate", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "min_tx_rate": 200, "max_tx_rate": 100}`, true),
+			Entry("min_tx_rate without max_tx_rate limit", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "min_tx_rate": 200, "max_tx_rate": 0}`, false),
+			Entry("negative max_tx_rate", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "max_tx_rate": -1}`, true),
+		)
+
+		DescribeTable("VLAN sub-interfaces",
+			func(conf string, failure bool) {
+				netConf, err := LoadConf([]byte(conf))
+				if failure {
+					Expect(err).To(HaveOccurred())
+				} else {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(netConf.Vlans).To(HaveLen(2))
+					Expect(netConf.Vlans[0].IfName("net1")).To(Equal("net1.100"))
+					Expect(netConf.Vlans[1].IfName("net1")).To(Equal("storage"))
+					Expect(netConf.Vlans[1].IPAMType()).To(Equal("host-local"))
+				}
+			},
+			Entry("valid", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "vlans": [{"id": 100}, {"id": 200, "name": "storage", "ipam": {"type": "host-local", "subnet": "10.2.0.0/24"}}]}`, false),
+			Entry("invalid id", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "vlans": [{"id": 100}, {"id": 4095}]}`, true),
+			Entry("duplicate id", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "vlans": [{"id": 100}, {"id": 100, "name": "storage"}]}`, true),
+			Entry("duplicate name", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "vlans": [{"id": 100, "name": "storage"}, {"id": 200, "name": "storage"}]}`, true),
+			Entry("ipam without type", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "vlans": [{"id": 100, "ipam": {"subnet": "10.2.0.0/24"}}]}`, true),
+			Entry("together with vlan", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "vlan": 10, "vlans": [{"id": 100}]}`, true),
+			Entry("with vhost vdpa", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "vdpaType": "vhost", "vlans": [{"id": 100}]}`, true),
+		)
+
+		DescribeTable("VLAN trunk",
+			func(conf string, failure bool) {
+				netConf, err := LoadConf([]byte(conf))
+				if failure {
+					Expect(err).To(HaveOccurred())
+				} else {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(netConf.VlanTrunk).To(Equal("100-200,300"))
+				}
+			},
+			Entry("valid", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "vlanTrunk": "100-200,300"}`, false),
+			Entry("together with vlan 0", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "vlan": 0, "vlanTrunk": "100-200,300"}`, true),
+			Entry("together with vlan", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "vlan": 10, "vlanTrunk": "100-200,300"}`, true),
+			Entry("invalid range", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "vlanTrunk": "200-100"}`, true),
+			Entry("PF driver without trunk support", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.0", "vlanTrunk": "100-200,300"}`, true),
+		)
+
+		DescribeTable("ethtool",
+			func(conf string, failure bool) {
+				netConf, err := LoadConf([]byte(conf))
+				if failure {
+					Expect(err).To(HaveOccurred())
+				} else {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(netConf.Ethtool).ToNot(BeNil())
+				}
+			},
+			Entry("features, rings and channels", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "ethtool": {"features": {"rx-gro": false}, "rings": {"rx": 4096}, "channels": {"combined": 8}}}`, false),
+			Entry("zero ring size", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "ethtool": {"rings": {"tx": 0}}}`, true),
+			Entry("negative channel count", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "ethtool": {"channels": {"combined": -1}}}`, true),
+			Entry("empty feature name", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "ethtool": {"features": {"": true}}}`, true),
+			Entry("with vhost vdpa", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "vdpaType": "vhost", "ethtool": {"features": {"rx-gro": false}}}`, true),
+		)
+
+		DescribeTable("usePrevResultIPs",
+			func(conf string, failure bool) {
+				netConf, err := LoadConf([]byte(conf))
+				if failure {
+					Expect(err).To(HaveOccurred())
+				} else {
+					Expect(err).ToNot(HaveOccurred())
+					Expect(netConf.UsePrevResultIPs).To(BeTrue())
+				}
+			},
+			Entry("without ipam", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "usePrevResultIPs": true}`, false),
+			Entry("together with ipam", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "usePrevResultIPs": true, "ipam": {"type": "host-local"}}`, true),
+		)
+
+		It("Assuming VF with RDMA device", func() {
+			netConf, err := LoadConf([]byte(`{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1"}`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netConf.RdmaDevice).To(Equal("mlx5_1"))
+		})
+		It("Assuming Scalable Function as deviceID", func() {
+			netConf, err := LoadConf([]byte(`{"name": "mynet", "type": "sriov", "deviceID": "mlx5_core.sf.2", "mac": "e4:11:22:33:44:55"}`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netConf.IsSF()).To(BeTrue())
+			Expect(*netConf.SFNum).To(Equal(88))
+			Expect(netConf.Master).To(Equal("enp175s0f1"))
+			Expect(netConf.OrigVfState.HostIFName).To(Equal("enp175s0f1s88"))
+		})
+		DescribeTable("Assuming Scalable Function with VF settings",
+			func(conf string) {
+				_, err := LoadConf([]byte(conf))
+				Expect(err).To(HaveOccurred())
+				cniErr := &cnitypes.Error{}
+				Expect(errors.As(err, &cniErr)).To(BeTrue())
+				Expect(cniErr.Code).To(Equal(cnitypes.ErrInvalidNetworkConfig))
+			},
+			Entry("vlan", `{"name": "mynet", "type": "sriov", "deviceID": "mlx5_core.sf.2", "vlan": 100}`),
+			Entry("max_tx_rate", `{"name": "mynet", "type": "sriov", "deviceID": "mlx5_core.sf.2", "max_tx_rate": 100}`),
+			Entry("trust", `{"name": "mynet", "type": "sriov", "deviceID": "mlx5_core.sf.2", "trust": "on"}`),
+		)
+		It("Assuming correct config file - existing master and vfIndex", func() {
+			conf := []byte(`{
+        "name": "mynet",
+        "type": "sriov",
+        "master": "enp175s0f1",
+        "vfIndex": 1
+                        }`)
+			netConf, err := LoadConf(conf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netConf.DeviceID).To(Equal("0000:af:06.1"))
+			Expect(netConf.VFID).To(Equal(1))
+			Expect(netConf.Master).To(Equal("enp175s0f1"))
+		})
+		DescribeTable("Assuming incorrect config file - invalid master and vfIndex",
+			func(conf string) {
+				_, err := LoadConf([]byte(conf))
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("vfIndex without master", `{"name": "mynet", "type": "sriov", "vfIndex": 1}`),
+			Entry("negative vfIndex", `{"name": "mynet", "type": "sriov", "master": "enp175s0f1", "vfIndex": -1}`),
+			Entry("not existing vfIndex", `{"name": "mynet", "type": "sriov", "master": "enp175s0f1", "vfIndex": 5}`),
+			Entry("not existing master", `{"name": "mynet", "type": "sriov", "master": "enp175s0f2", "vfIndex": 0}`),
+			Entry("vfIndex with deviceID", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "master": "enp175s0f1", "vfIndex": 1}`),
+		)
+		Context("Selecting a VF from a PF pool", func() {
+			It("Should select the first free VF of the PFs", func() {
+				netConf, err := LoadConf([]byte(`{"name": "mynet", "type": "sriov", "pfNames": ["enp175s0f1"]}`))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netConf.DeviceID).To(Equal("0000:af:06.0"))
+				Expect(netConf.Master).To(Equal("enp175s0f1"))
+				Expect(netConf.VFID).To(Equal(0))
+			})
+
+			It("Should skip allocated and locked VFs", func() {
+				targetNetNS, err := testutils.NewNS()
+				Expect(err).NotTo(HaveOccurred())
+				defer func() {
+					targetNetNS.Close()
+					_ = testutils.UnmountNS(targetNetNS)
+				}()
+				allocator := utils.NewPCIAllocator(DefaultCNIDir)
+				Expect(allocator.SaveAllocatedPCI("0000:af:06.0", targetNetNS.Path())).To(Succeed())
+
+				netConf, err := LoadConf([]byte(`{"name": "mynet", "type": "sriov", "master": "enp175s0f1"}`))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netConf.DeviceID).To(Equal("0000:af:06.1"))
+
+				// 0000:af:06.1 is still locked by the previous call
+				_, err = LoadConf([]byte(`{"name": "mynet", "type": "sriov", "master": "enp175s0f1"}`))
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("no free VF found"))
+			})
+
+			It("Should select a VF from the VF range", func() {
+				netConf, err := LoadConf([]byte(`{"name": "mynet", "type": "sriov", "master": "enp175s0f1", "vfRange": "1-3"}`))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netConf.DeviceID).To(Equal("0000:af:06.1"))
+				Expect(netConf.VFID).To(Equal(1))
+			})
+
+			DescribeTable("Should fail on invalid pool config",
+				func(conf string) {
+					_, err := LoadConf([]byte(conf))
+					Expect(err).To(HaveOccurred())
+				},
+				Entry("master and pfNames", `{"name": "mynet", "type": "sriov", "master": "enp175s0f1", "pfNames": ["enp175s0f1"]}`),
+				Entry("vfRange without master", `{"name": "mynet", "type": "sriov", "pfNames": ["enp175s0f1"], "vfRange": "0-1"}`),
+				Entry("invalid vfRange", `{"name": "mynet", "type": "sriov", "master": "enp175s0f1", "vfRange": "3-1"}`),
+				Entry("PF without VFs", `{"name": "mynet", "type": "sriov", "pfNames": ["ens1"]}`),
+			)
+		})
 		It("Assuming device is allocated", func() {
 			conf := []byte(`{
         "name": "mynet",
@@ -166,9 +467,209 @@ var _ = Describe("Config", func() {
 			_, err = LoadConf(conf)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("pci address 0000:af:06.1 is already allocated"))
+			var cniErr *cnitypes.Error
+			Expect(errors.As(err, &cniErr)).To(BeTrue())
+			Expect(cniErr.Code).To(Equal(types.ErrVfAllocated))
+		})
+		DescribeTable("keys",
+			func(conf string, failure bool) {
+				_, err := LoadConf([]byte(conf))
+				if failure {
+					Expect(err).To(HaveOccurred())
+					var cniErr *cnitypes.Error
+					Expect(errors.As(err, &cniErr)).To(BeTrue())
+					Expect(cniErr.Code).To(Equal(uint(cnitypes.ErrInvalidNetworkConfig)))
+				} else {
+					Expect(err).ToNot(HaveOccurred())
+				}
+			},
+			Entry("unknown key", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "vf": 0}`, false),
+			Entry("unknown key in strict mode", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "strict": true, "vf": 0}`, true),
+			Entry("unknown nested key in strict mode", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "strict": true, "ethtool": {"rings": {"size": 1}}}`, true),
+			Entry("runtime keys in strict mode", `{"cniVersion": "1.0.0", "name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "strict": true, "runtimeConfig": {"mac": "e4:11:22:33:44:55", "ips": ["10.0.0.2/24"]}}`, false),
+			Entry("key that differs in case", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "spoofChk": "on"}`, false),
+			Entry("key that differs in case in strict mode", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "strict": true, "spoofChk": "on"}`, true),
+			Entry("nested key that differs in case", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "ethtool": {"rings": {"RX": 512}}}`, false),
+			Entry("nested key that differs in case in strict mode", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "strict": true, "ethtool": {"rings": {"RX": 512}}}`, true),
+			Entry("untagged setting that differs in case", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "MAC": "e4:11:22:33:44:55"}`, false),
+			Entry("internal field", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "Representor": "eth0"}`, true),
+			Entry("internal field in lower case", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "containerid": "abc"}`, true),
+			Entry("internal field sharing a setting name", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "MTU": 9000}`, true),
+			Entry("internal flag", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "MACGenerated": true}`, true),
+		)
+		It("Should apply the keys that differ in case only", func() {
+			conf, err := LoadConf([]byte(`{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "spoofChk": "on", "max_tx_Rate": 100}`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conf.SpoofChk).To(Equal("on"))
+			Expect(*conf.MaxTxRate).To(Equal(100))
+		})
+
+		DescribeTable("Assuming incorrect config file - error codes",
+			func(conf string, code uint) {
+				_, err := LoadConf([]byte(conf))
+				Expect(err).To(HaveOccurred())
+				var cniErr *cnitypes.Error
+				Expect(errors.As(err, &cniErr)).To(BeTrue())
+				Expect(cniErr.Code).To(Equal(code))
+			},
+			Entry("malformed config", `{"name": "mynet", "type": "sriov", "deviceID": 1}`, cnitypes.ErrDecodingFailure),
+			Entry("invalid vlan", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "vlan": 5000}`, cnitypes.ErrInvalidNetworkConfig),
+			Entry("invalid link_state", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "link_state": "up"}`, cnitypes.ErrInvalidNetworkConfig),
+			Entry("VF without PF", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:07.0"}`, types.ErrPfNotFound),
+			Entry("no free VF in pool", `{"name": "mynet", "type": "sriov", "pfNames": ["ens1"]}`, types.ErrVfAllocated),
+		)
+
+	})
+	Context("Checking LoadBondConf function", func() {
+		It("Should load the NetConf of every bond link", func() {
+			conf := []byte(`{"name": "mynet", "type": "sriov", "trust": "on", "mac": "e4:11:22:33:44:55",
+				"ipam": {"type": "host-local", "subnet": "10.55.206.0/26"},
+				"bond": {"mode": "active-backup", "links": [{"deviceID": "0000:af:06.0"}, {"pfNames": ["enp175s0f1"]}]}}`)
+			Expect(IsBondConf(conf)).To(BeTrue())
+
+			bondConf, links, err := LoadBondConf(conf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bondConf.IPAM.Type).To(Equal("host-local"))
+			Expect(bondConf.Bond.Mode).To(Equal(types.BondModeActiveBackup))
+			Expect(links).To(HaveLen(2))
+			// the VF of the first link is locked, the pool of the second link skips it
+			Expect(links[0].DeviceID).To(Equal("0000:af:06.0"))
+			Expect(links[1].DeviceID).To(Equal("0000:af:06.1"))
+			for _, link := range links {
+				Expect(link.Bond).To(BeNil())
+				Expect(link.IPAM.Type).To(BeEmpty())
+				Expect(link.Trust).To(Equal("on"))
+				Expect(link.MAC).To(Equal("e4:11:22:33:44:55"))
+			}
+		})
+		It("Should not take a single VF config for a bond", func() {
+			Expect(IsBondConf([]byte(`{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1"}`))).To(BeFalse())
+		})
+		DescribeTable("Should fail on invalid bond config",
+			func(conf string) {
+				_, _, err := LoadBondConf([]byte(conf))
+				Expect(err).To(HaveOccurred())
+				cniErr := &cnitypes.Error{}
+				Expect(errors.As(err, &cniErr)).To(BeTrue())
+				Expect(cniErr.Code).To(Equal(cnitypes.ErrInvalidNetworkConfig))
+			},
+			Entry("invalid mode", `{"name": "mynet", "type": "sriov", "bond": {"mode": "balance-rr", "links": [{"deviceID": "0000:af:06.0"}, {"deviceID": "0000:af:06.1"}]}}`),
+			Entry("negative miimon", `{"name": "mynet", "type": "sriov", "bond": {"mode": "802.3ad", "miimon": -1, "links": [{"deviceID": "0000:af:06.0"}, {"deviceID": "0000:af:06.1"}]}}`),
+			Entry("single link", `{"name": "mynet", "type": "sriov", "bond": {"mode": "802.3ad", "links": [{"deviceID": "0000:af:06.0"}]}}`),
+			Entry("deviceID outside of the links", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.0", "bond": {"mode": "802.3ad", "links": [{"deviceID": "0000:af:06.0"}, {"deviceID": "0000:af:06.1"}]}}`),
+			Entry("vlans", `{"name": "mynet", "type": "sriov", "vlans": [{"id": 100}], "bond": {"mode": "802.3ad", "links": [{"deviceID": "0000:af:06.0"}, {"deviceID": "0000:af:06.1"}]}}`),
+			Entry("link without VF selection", `{"name": "mynet", "type": "sriov", "bond": {"mode": "802.3ad", "links": [{"deviceID": "0000:af:06.0"}, {"vfRange": "0-1"}]}}`),
+			Entry("duplicate deviceID", `{"name": "mynet", "type": "sriov", "bond": {"mode": "802.3ad", "links": [{"deviceID": "0000:af:06.0"}, {"deviceID": "0000:af:06.0"}]}}`),
+			Entry("invalid VF setting", `{"name": "mynet", "type": "sriov", "vlan": 5000, "bond": {"mode": "802.3ad", "links": [{"deviceID": "0000:af:06.0"}, {"deviceID": "0000:af:06.1"}]}}`),
+			Entry("bond key that differs in case in strict mode", `{"name": "mynet", "type": "sriov", "strict": true, "bond": {"Mode": "802.3ad", "links": [{"deviceID": "0000:af:06.0"}, {"deviceID": "0000:af:06.1"}]}}`),
+			Entry("unknown key in strict mode", `{"name": "mynet", "type": "sriov", "strict": true, "vf": 0, "bond": {"mode": "802.3ad", "links": [{"deviceID": "0000:af:06.0"}, {"deviceID": "0000:af:06.1"}]}}`),
+		)
+	})
+	Context("Checking UnknownKeys function", func() {
+		It("Should report the keys that are ignored", func() {
+			unknown, err := UnknownKeys([]byte(`{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "vf": 0,
+				"ethtool": {"rings": {"rx": 512, "size": 1}}, "vlans": [{"id": 100, "vlan": 100}]}`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(unknown).To(Equal([]string{
+				`unknown key "ethtool.rings.size"`,
+				`unknown key "vf"`,
+				`unknown key "vlans[0].vlan"`,
+			}))
+		})
+		It("Should report the keys that differ in case only and leave the internal keys to checkConfKeys", func() {
+			unknown, err := UnknownKeys([]byte(`{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "spoofChk": "on",
+				"Representor": "eth0"}`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(unknown).To(Equal([]string{`key "spoofChk" differs in case only from "spoofchk"`}))
+		})
+		It("Should accept the keys added by the runtime", func() {
+			unknown, err := UnknownKeys([]byte(`{"cniVersion": "1.0.0", "name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1",
+				"runtimeConfig": {"mac": "e4:11:22:33:44:55", "ips": ["10.0.0.2/24"]}, "prevResult": {"cniVersion": "1.0.0"},
+				"bond": {"mode": "802.3ad", "links": [{"deviceID": "0000:af:06.0"}, {"pfNames": ["enp175s0f1"]}]}}`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(unknown).To(BeEmpty())
+		})
+	})
+
+	Context("Checking ValidateConf function", func() {
+		DescribeTable("Should validate the netconf without a device",
+			func(conf string, failure bool) {
+				err := ValidateConf([]byte(conf))
+				if failure {
+					Expect(err).To(HaveOccurred())
+				} else {
+					Expect(err).ToNot(HaveOccurred())
+				}
+			},
+			Entry("VF that does not exist", `{"name": "mynet", "type": "sriov", "deviceID": "0000:00:00.9", "vlan": 100, "spoofchk": "on"}`, false),
+			Entry("VF pool", `{"name": "mynet", "type": "sriov", "master": "enp175s0f1", "vfRange": "0-3"}`, false),
+			Entry("bond", `{"name": "mynet", "type": "sriov", "bond": {"mode": "802.3ad", "links": [{"deviceID": "0000:af:06.0"}, {"pfNames": ["enp175s0f1"]}]}}`, false),
+			Entry("no VF selection", `{"name": "mynet", "type": "sriov", "vlan": 100}`, true),
+			Entry("vfIndex without master", `{"name": "mynet", "type": "sriov", "vfIndex": 1}`, true),
+			Entry("invalid vfRange", `{"name": "mynet", "type": "sriov", "master": "enp175s0f1", "vfRange": "3-1"}`, true),
+			Entry("invalid mac", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "mac": "e4:11:22:33:44"}`, true),
+			Entry("invalid guid", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "guid": "zz"}`, true),
+			Entry("invalid spoofchk", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "spoofchk": "true"}`, true),
+			Entry("invalid trust", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "trust": "yes"}`, true),
+			Entry("invalid link_state", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "link_state": "up"}`, true),
+			Entry("vlan QoS without vlan", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "vlanQoS": 1}`, true),
+			Entry("min_tx_rate above max_tx_rate", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "min_tx_rate": 200, "max_tx_rate": 100}`, true),
+			Entry("invalid setting of a bond", `{"name": "mynet", "type": "sriov", "trust": "yes", "bond": {"mode": "802.3ad", "links": [{"deviceID": "0000:af:06.0"}, {"deviceID": "0000:af:06.1"}]}}`, true),
+			Entry("invalid bond link", `{"name": "mynet", "type": "sriov", "bond": {"mode": "802.3ad", "links": [{"deviceID": "0000:af:06.0"}, {"vfIndex": 1}]}}`, true),
+			Entry("key that differs in case", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "max_tx_Rate": 100}`, false),
+			Entry("key that differs in case in strict mode", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "strict": true, "max_tx_Rate": 100}`, true),
+			Entry("bond link key that differs in case", `{"name": "mynet", "type": "sriov", "bond": {"mode": "802.3ad", "links": [{"deviceID": "0000:af:06.0"}, {"DeviceID": "0000:af:06.1"}]}}`, false),
+			Entry("bond link key that differs in case in strict mode", `{"name": "mynet", "type": "sriov", "strict": true, "bond": {"mode": "802.3ad", "links": [{"deviceID": "0000:af:06.0"}, {"DeviceID": "0000:af:06.1"}]}}`, true),
+			Entry("unknown key in strict mode", `{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "strict": true, "vf": 0}`, true),
+		)
+
+		It("Should report the internal keys", func() {
+			err := ValidateConf([]byte(`{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "spoofChk": "on", "Representor": "eth0"}`))
+			Expect(err).To(MatchError(ContainSubstring(`key "Representor" is set by the plugin and can not be configured`)))
+			Expect(err).NotTo(MatchError(ContainSubstring("spoofChk")))
+		})
+
+		It("Should report the internal keys and the keys that differ in case in strict mode", func() {
+			err := ValidateConf([]byte(`{"name": "mynet", "type": "sriov", "deviceID": "0000:af:06.1", "strict": true, "spoofChk": "on",
+				"Representor": "eth0"}`))
+			Expect(err).To(MatchError(ContainSubstring(`key "Representor" is set by the plugin and can not be configured; ` +
+				`key "spoofChk" differs in case only from "spoofchk"`)))
+		})
+	})
+
+	Context("Checking GenerateMAC function", func() {
+		It("Should generate the same locally administered unicast MAC address for the same pod", func() {
+			conf := &types.MACGenerationConf{Prefix: "0a:58", Source: types.MACSourcePod}
+			mac, err := GenerateMAC(conf, "mynet", "cid1", "default", "pod1", "net1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mac).To(HavePrefix("0a:58:"))
+			_, err = NormalizeMAC(mac, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			restarted, err := GenerateMAC(conf, "mynet", "cid2", "default", "pod1", "net1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restarted).To(Equal(mac))
+
+			other, err := GenerateMAC(conf, "mynet", "cid1", "default", "pod2", "net1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(other).NotTo(Equal(mac))
 		})
+		It("Should generate a MAC address per container", func() {
+			conf := &types.MACGenerationConf{Source: types.MACSourceContainerID}
+			mac, err := GenerateMAC(conf, "mynet", "cid1", "", "", "net1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mac).To(HavePrefix("02:"))
 
+			restarted, err := GenerateMAC(conf, "mynet", "cid2", "", "", "net1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restarted).NotTo(Equal(mac))
+		})
+		It("Should fail for the pod source without the pod in CNI_ARGS", func() {
+			_, err := GenerateMAC(&types.MACGenerationConf{Source: types.MACSourcePod}, "mynet", "cid1", "", "", "net1")
+			Expect(err).To(HaveOccurred())
+		})
 	})
+
 	Context("Checking getVfInfo function", func() {
 		It("Assuming existing PF", func() {
 			_, _, err := getVfInfo("0000:af:06.0")
@@ -224,4 +725,70 @@ var _ = Describe("Config", func() {
 			Expect(GetMacAddressForResult(netconf)).To(Equal(""))
 		})
 	})
+	Context("Checking LoadPrevResult function", func() {
+		It("Should return nil when there is no prevResult", func() {
+			conf := []byte(`{
+        "cniVersion": "1.0.0",
+        "name": "mynet",
+        "type": "sriov",
+        "deviceID": "0000:af:06.1"
+                        }`)
+			prevResult, err := LoadPrevResult(conf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(prevResult).To(BeNil())
+		})
+		It("Should parse the prevResult", func() {
+			conf := []byte(`{
+        "cniVersion": "1.0.0",
+        "name": "mynet",
+        "type": "sriov",
+        "deviceID": "0000:af:06.1",
+        "prevResult": {
+            "cniVersion": "1.0.0",
+            "interfaces": [
+                {"name": "net1", "mac": "6e:16:06:0e:b7:e9", "mtu": 1500, "sandbox": "/var/run/netns/test"}
+            ],
+            "ips": [
+                {"address": "10.55.206.46/26", "interface": 0}
+            ]
+        }
+                        }`)
+			prevResult, err := LoadPrevResult(conf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(prevResult.Interfaces).To(HaveLen(1))
+			Expect(prevResult.Interfaces[0].Name).To(Equal("net1"))
+			Expect(prevResult.IPs).To(HaveLen(1))
+			Expect(*prevResult.IPs[0].Interface).To(Equal(0))
+		})
+	})
+	Context("Checking LoadConfsFromCache function", func() {
+		It("Should return every cached NetConf and skip unrelated files", func() {
+			netConf := &types.NetConf{SriovNetConf: types.SriovNetConf{DeviceID: "0000:af:06.0", Master: "enp175s0f1"}}
+			netConf.Name = "mynet"
+			Expect(utils.SaveNetConf("cid", DefaultCNIDir, "net1", netConf)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(DefaultCNIDir, "sriov.log"), []byte("log line"), 0o600)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(DefaultCNIDir, "pci"), 0o700)).To(Succeed())
+
+			netConfs, err := LoadConfsFromCache()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netConfs).To(HaveLen(1))
+			Expect(netConfs).To(HaveKey(filepath.Join(DefaultCNIDir, "cid-net1")))
+			Expect(netConfs[filepath.Join(DefaultCNIDir, "cid-net1")].Name).To(Equal("mynet"))
+		})
+		It("Should return the cached NetConf of a bond", func() {
+			bondConf := &types.NetConf{SriovNetConf: types.SriovNetConf{Bond: &types.BondConf{Mode: types.BondMode8023AD}}}
+			Expect(utils.SaveNetConf("cid", DefaultCNIDir, "net1", bondConf)).To(Succeed())
+
+			netConfs, err := LoadConfsFromCache()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netConfs).To(HaveKey(filepath.Join(DefaultCNIDir, "cid-net1")))
+			Expect(netConfs[filepath.Join(DefaultCNIDir, "cid-net1")].Bond.Mode).To(Equal(types.BondMode8023AD))
+		})
+		It("Should return nothing when the cache directory does not exist", func() {
+			DefaultCNIDir = filepath.Join(DefaultCNIDir, "missing")
+			netConfs, err := LoadConfsFromCache()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netConfs).To(BeEmpty())
+		})
+	})
 })
@@ -0,0 +1,269 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/containernetworking/cni/pkg/types"
+
+	sriovtypes "github.com/k8snetworkplumbingwg/sriov-cni/pkg/types"
+)
+
+// confKeys are the keys of a JSON object of the netconf mapped to the keys of their nested objects.
+// A nil value means the nested keys are not checked.
+type confKeys map[string]confKeys
+
+// netConfKeys are the keys of the stdin netconf, including the ones the runtime adds
+var netConfKeys = confKeys{
+	// CNI
+	"cniVersion":                nil,
+	"name":                      nil,
+	"type":                      nil,
+	"ipam":                      nil,
+	"dns":                       nil,
+	"capabilities":              nil,
+	"runtimeConfig":             nil,
+	"args":                      nil,
+	"prevResult":                nil,
+	"cni.dev/valid-attachments": nil,
+	// VF selection
+	"deviceID": nil,
+	"master":   nil,
+	"vfIndex":  nil,
+	"pfNames":  nil,
+	"vfRange":  nil,
+	"vdpaType": nil,
+	// VF settings
+	"mac":           nil,
+	"localMACOnly":  nil,
+	"macGeneration": {"prefix": nil, "source": nil},
+	"guid":          nil,
+	"mtu":           nil,
+	"vlan":          nil,
+	"vlanQoS":       nil,
+	"vlanProto":     nil,
+	"vlanTrunk":     nil,
+	"min_tx_rate":   nil,
+	"max_tx_rate":   nil,
+	"txRateMode":    nil,
+	"spoofchk":      nil,
+	"trust":         nil,
+	"allmulti":      nil,
+	"promisc":       nil,
+	"link_state":    nil,
+	"ethtool": {
+		"features": nil,
+		"rings":    {"rx": nil, "tx": nil},
+		"channels": {"rx": nil, "tx": nil, "other": nil, "combined": nil},
+	},
+	"vlans": {"id": nil, "name": nil, "ipam": nil},
+	"bond": {
+		"mode":   nil,
+		"miimon": nil,
+		"links":  {"deviceID": nil, "master": nil, "vfIndex": nil, "pfNames": nil, "vfRange": nil},
+	},
+	// plugin
+	"logLevel":          nil,
+	"logFile":           nil,
+	"renameRepresentor": nil,
+	"usePrevResultIPs":  nil,
+	"strict":            nil,
+}
+
+// internalKeys are the JSON names of the NetConf fields the plugin sets itself, e.g. from the device or the CNI
+// args, and caches for DEL. json.Unmarshal would decode them from stdin as well.
+var internalKeys = internalConfKeys(jsonFieldNames(reflect.TypeOf(sriovtypes.NetConf{})))
+
+// internalConfKeys returns the field names that are not known keys. Untagged fields such as Master are configured
+// through the known key they match case-insensitively, unless another field is tagged with that key, as MTU is.
+func internalConfKeys(names []string) []string {
+	var keys []string
+	for _, name := range names {
+		if _, ok := netConfKeys[name]; ok {
+			continue
+		}
+		if knownKey := equalFoldAny(name, netConfKeys.keys()); knownKey != "" && !slices.Contains(names, knownKey) {
+			continue
+		}
+		keys = append(keys, name)
+	}
+	return keys
+}
+
+// jsonFieldNames returns the JSON names of the fields of the struct t, and of its embedded structs
+func jsonFieldNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			names = append(names, jsonFieldNames(field.Type)...)
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// UnknownKeys returns a message for every key of the stdin netconf that does not match a known key exactly: the keys
+// the plugin ignores, and the keys that differ in case only from a known key, which json.Unmarshal applies anyway.
+// Keys of the state the plugin sets itself are rejected by checkConfKeys instead.
+func UnknownKeys(bytes []byte) ([]string, error) {
+	var conf any
+	if err := json.Unmarshal(bytes, &conf); err != nil {
+		return nil, types.NewError(types.ErrDecodingFailure, "UnknownKeys(): failed to load netconf", err.Error())
+	}
+	_, unknown := checkKeys("", conf, netConfKeys)
+	return unknown, nil
+}
+
+// checkConfKeys returns an error for the keys of the stdin netconf that json.Unmarshal matches case-insensitively to a
+// field the plugin sets itself, so that a key such as "Representor" can not change the cached state. In strict mode
+// the keys that do not match a known key exactly fail as well, including the ones that differ in case only, such as
+// "spoofChk", which are applied otherwise.
+func checkConfKeys(bytes []byte, strict bool) error {
+	var conf any
+	if err := json.Unmarshal(bytes, &conf); err != nil {
+		return types.NewError(types.ErrDecodingFailure, "failed to load netconf", err.Error())
+	}
+	invalid, unknown := checkKeys("", conf, netConfKeys)
+	if strict {
+		invalid = append(invalid, unknown...)
+	}
+	if len(invalid) > 0 {
+		return invalidConfError("%s", strings.Join(invalid, "; "))
+	}
+	return nil
+}
+
+// checkKeys checks the keys of the JSON value at path against known, case-sensitively. It returns a message for every
+// key that names an internal field, and for every other key that is not known, either because json.Unmarshal ignores
+// it or because it differs in case only from a known key. The elements of an array are checked against known as well.
+func checkKeys(path string, value any, known confKeys) (invalid, unknown []string) {
+	switch v := value.(type) {
+	case []any:
+		for i, elem := range v {
+			elemInvalid, elemUnknown := checkKeys(fmt.Sprintf("%s[%d]", path, i), elem, known)
+			invalid = append(invalid, elemInvalid...)
+			unknown = append(unknown, elemUnknown...)
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			keyPath := key
+			if path != "" {
+				keyPath = path + "." + key
+			}
+			nested, ok := known[key]
+			if ok {
+				if nested != nil {
+					nestedInvalid, nestedUnknown := checkKeys(keyPath, v[key], nested)
+					invalid = append(invalid, nestedInvalid...)
+					unknown = append(unknown, nestedUnknown...)
+				}
+				continue
+			}
+			// json.Unmarshal prefers the field named exactly by the key, e.g. "MTU" over "mtu"
+			internal := path == "" && slices.Contains(internalKeys, key)
+			if knownKey := equalFoldAny(key, known.keys()); knownKey != "" && !internal {
+				unknown = append(unknown, fmt.Sprintf("key %q differs in case only from %q", keyPath, knownKey))
+			} else if path == "" && equalFoldAny(key, internalKeys) != "" {
+				invalid = append(invalid, fmt.Sprintf("key %q is set by the plugin and can not be configured", keyPath))
+			} else {
+				unknown = append(unknown, fmt.Sprintf("unknown key %q", keyPath))
+			}
+		}
+	}
+	return invalid, unknown
+}
+
+// keys returns the keys of known
+func (known confKeys) keys() []string {
+	keys := make([]string, 0, len(known))
+	for key := range known {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// equalFoldAny returns the first of keys that equals key under Unicode case-folding, or "" if there is none
+func equalFoldAny(key string, keys []string) string {
+	for _, k := range keys {
+		if strings.EqualFold(key, k) {
+			return k
+		}
+	}
+	return ""
+}
+
+// ValidateConf checks the stdin netconf the way LoadConf and LoadBondConf do, without looking up or locking a device,
+// so that it can be run on any host
+func ValidateConf(bytes []byte) error {
+	n := &sriovtypes.NetConf{}
+	if err := json.Unmarshal(bytes, n); err != nil {
+		return types.NewError(types.ErrDecodingFailure, "ValidateConf(): failed to load netconf", err.Error())
+	}
+	if err := checkConfKeys(bytes, n.Strict); err != nil {
+		return err
+	}
+
+	if n.Bond != nil {
+		if err := validateBondConf(n); err != nil {
+			return err
+		}
+		for i := range n.Bond.Links {
+			link := &n.Bond.Links[i]
+			if err := validateVFSelection(link.DeviceID, link.Master, link.VFIndex, link.PFNames, link.VFRange); err != nil {
+				return fmt.Errorf("ValidateConf(): bond link %d: %w", i, err)
+			}
+		}
+	} else if n.DeviceID == "" && n.Master == "" && len(n.PFNames) == 0 {
+		return invalidConfError("ValidateConf(): VF pci addr, master or pfNames are required")
+	} else if err := validateVFSelection(n.DeviceID, n.Master, n.VFIndex, n.PFNames, n.VFRange); err != nil {
+		return err
+	}
+
+	return validateConfValues(n)
+}
+
+// validateVFSelection checks the settings that select a VF when no deviceID is given
+func validateVFSelection(deviceID, master string, vfIndex *int, pfNames []string, vfRange string) error {
+	if deviceID != "" {
+		return nil
+	}
+	if master != "" && len(pfNames) > 0 {
+		return invalidConfError("master and pfNames are mutually exclusive")
+	}
+	if vfIndex != nil {
+		if master == "" {
+			return invalidConfError("master is required to select a VF by vfIndex")
+		}
+		if *vfIndex < 0 {
+			return invalidConfError("vfIndex %d invalid: value must not be negative", *vfIndex)
+		}
+	}
+	if vfRange != "" {
+		if master == "" {
+			return invalidConfError("master is required to select a VF from vfRange")
+		}
+		if _, _, err := parseVFRange(vfRange); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -11,6 +11,9 @@ const (
 	labelContainerID = "containerID"
 	labelNetNS       = "netns"
 	labelIFName      = "ifname"
+	labelPodNS       = "podNamespace"
+	labelPodName     = "podName"
+	labelPodUID      = "podUID"
 	cniName          = "sriov-cni"
 )
 
@@ -19,6 +22,9 @@ var (
 	containerID     = ""
 	netNS           = ""
 	ifName          = ""
+	podNamespace    = ""
+	podName         = ""
+	podUID          = ""
 )
 
 // Init initializes logging with the requested parameters in this order: log level, log file, container ID,
@@ -29,6 +35,14 @@ func Init(logLevel, logFile, containerIdentification, networkNamespace, interfac
 	containerID = containerIdentification
 	netNS = networkNamespace
 	ifName = interfaceName
+	podNamespace, podName, podUID = "", "", ""
+}
+
+// SetPod sets the namespace, name and UID of the pod of the attachment, which Init resets.
+func SetPod(namespace, name, uid string) {
+	podNamespace = namespace
+	podName = name
+	podUID = uid
 }
 
 // setLogLevel sets the log level to either verbose, debug, info, warn, error or panic. If an invalid string is
@@ -77,8 +91,17 @@ func Panic(msg string, args ...interface{}) {
 	cnilog.PanicStructured(msg, prependArgs(args)...)
 }
 
-// prependArgs prepends cniName, containerID, netNS and ifName to the args of every log message.
+// prependArgs prepends cniName, containerID, netNS, ifName and the pod to the args of every log message.
 func prependArgs(args []interface{}) []interface{} {
+	if podUID != "" {
+		args = append([]interface{}{labelPodUID, podUID}, args...)
+	}
+	if podName != "" {
+		args = append([]interface{}{labelPodName, podName}, args...)
+	}
+	if podNamespace != "" {
+		args = append([]interface{}{labelPodNS, podNamespace}, args...)
+	}
 	if ifName != "" {
 		args = append([]interface{}{labelIFName, ifName}, args...)
 	}
@@ -72,6 +72,41 @@ var _ = g.Describe("Logging", func() {
 				o.Expect(out).Should(o.ContainSubstring(fmt.Sprintf(`%s="%s"`, labelIFName, testIFName)))
 			})
 		})
+
+		g.When("the pod is specified", func() {
+			const (
+				testPodNS   = "test-namespace"
+				testPodName = "test-pod"
+				testPodUID  = "test-uid"
+			)
+
+			g.BeforeEach(func() {
+				Init("", "", "test-containerid", "", "")
+				SetPod(testPodNS, testPodName, testPodUID)
+			})
+
+			g.It("should log the pod namespace, name and UID", func() {
+				Panic("test message", "a", "b")
+				_, _ = stderrFile.Seek(0, 0)
+				out, err := io.ReadAll(stderrFile)
+				o.Expect(err).NotTo(o.HaveOccurred())
+				//nolint:gocritic
+				o.Expect(out).Should(o.ContainSubstring(fmt.Sprintf(`%s="%s"`, labelPodNS, testPodNS)))
+				//nolint:gocritic
+				o.Expect(out).Should(o.ContainSubstring(fmt.Sprintf(`%s="%s"`, labelPodName, testPodName)))
+				//nolint:gocritic
+				o.Expect(out).Should(o.ContainSubstring(fmt.Sprintf(`%s="%s"`, labelPodUID, testPodUID)))
+			})
+
+			g.It("should not log the pod once logging is initialized again", func() {
+				Init("", "", "test-containerid", "", "")
+				Panic("test message", "a", "b")
+				_, _ = stderrFile.Seek(0, 0)
+				out, err := io.ReadAll(stderrFile)
+				o.Expect(err).NotTo(o.HaveOccurred())
+				o.Expect(out).ShouldNot(o.ContainSubstring(labelPodNS))
+			})
+		})
 	})
 
 	g.Context("log levels", func() {
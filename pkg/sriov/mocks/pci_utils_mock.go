@@ -9,6 +9,24 @@ type PciUtils struct {
 	mock.Mock
 }
 
+// BindVdpaDriver provides a mock function with given fields: name, driver
+func (_m *PciUtils) BindVdpaDriver(name, driver string) error {
+	ret := _m.Called(name, driver)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BindVdpaDriver")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string) error); ok {
+		r0 = rf(name, driver)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // EnableArpAndNdiscNotify provides a mock function with given fields: ifName
 func (_m *PciUtils) EnableArpAndNdiscNotify(ifName string) error {
 	ret := _m.Called(ifName)
@@ -73,6 +91,34 @@ func (_m *PciUtils) GetPciAddress(ifName string, vf int) (string, error) {
 	return r0, r1
 }
 
+// GetSFLinkName provides a mock function with given fields: auxDev
+func (_m *PciUtils) GetSFLinkName(auxDev string) (string, error) {
+	ret := _m.Called(auxDev)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSFLinkName")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+		return rf(auxDev)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(auxDev)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(auxDev)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetSriovNumVfs provides a mock function with given fields: ifName
 func (_m *PciUtils) GetSriovNumVfs(ifName string) (int, error) {
 	ret := _m.Called(ifName)
@@ -101,6 +147,41 @@ func (_m *PciUtils) GetSriovNumVfs(ifName string) (int, error) {
 	return r0, r1
 }
 
+// GetVFGUIDs provides a mock function with given fields: pfName, vfID
+func (_m *PciUtils) GetVFGUIDs(pfName string, vfID int) (string, string, error) {
+	ret := _m.Called(pfName, vfID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetVFGUIDs")
+	}
+
+	var r0 string
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(string, int) (string, string, error)); ok {
+		return rf(pfName, vfID)
+	}
+	if rf, ok := ret.Get(0).(func(string, int) string); ok {
+		r0 = rf(pfName, vfID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, int) string); ok {
+		r1 = rf(pfName, vfID)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(string, int) error); ok {
+		r2 = rf(pfName, vfID)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetVFLinkNamesFromVFID provides a mock function with given fields: pfName, vfID
 func (_m *PciUtils) GetVFLinkNamesFromVFID(pfName string, vfID int) ([]string, error) {
 	ret := _m.Called(pfName, vfID)
@@ -131,6 +212,164 @@ func (_m *PciUtils) GetVFLinkNamesFromVFID(pfName string, vfID int) ([]string, e
 	return r0, r1
 }
 
+// GetVFRepresentor provides a mock function with given fields: pfName, vfID
+func (_m *PciUtils) GetVFRepresentor(pfName string, vfID int) (string, error) {
+	ret := _m.Called(pfName, vfID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetVFRepresentor")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, int) (string, error)); ok {
+		return rf(pfName, vfID)
+	}
+	if rf, ok := ret.Get(0).(func(string, int) string); ok {
+		r0 = rf(pfName, vfID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, int) error); ok {
+		r1 = rf(pfName, vfID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetVdpaDeviceName provides a mock function with given fields: pciAddr
+func (_m *PciUtils) GetVdpaDeviceName(pciAddr string) (string, error) {
+	ret := _m.Called(pciAddr)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetVdpaDeviceName")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+		return rf(pciAddr)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(pciAddr)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(pciAddr)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetVdpaDriver provides a mock function with given fields: name
+func (_m *PciUtils) GetVdpaDriver(name string) (string, error) {
+	ret := _m.Called(name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetVdpaDriver")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+		return rf(name)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(name)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetVhostVdpaPath provides a mock function with given fields: name
+func (_m *PciUtils) GetVhostVdpaPath(name string) (string, error) {
+	ret := _m.Called(name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetVhostVdpaPath")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+		return rf(name)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(name)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetVirtioVdpaNetdev provides a mock function with given fields: name
+func (_m *PciUtils) GetVirtioVdpaNetdev(name string) (string, error) {
+	ret := _m.Called(name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetVirtioVdpaNetdev")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
+		return rf(name)
+	}
+	if rf, ok := ret.Get(0).(func(string) string); ok {
+		r0 = rf(name)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetVFVlanTrunk provides a mock function with given fields: pfName, vfID, trunk, add
+func (_m *PciUtils) SetVFVlanTrunk(pfName string, vfID int, trunk string, add bool) error {
+	ret := _m.Called(pfName, vfID, trunk, add)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetVFVlanTrunk")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, int, string, bool) error); ok {
+		r0 = rf(pfName, vfID, trunk, add)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewPciUtils creates a new instance of PciUtils. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewPciUtils(t interface {
@@ -1,10 +1,16 @@
 package sriov
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"time"
 
+	"github.com/containernetworking/cni/pkg/types"
+	current "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/containernetworking/plugins/pkg/ns"
 	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
 
 	"github.com/k8snetworkplumbingwg/sriov-cni/pkg/logging"
 	sriovtypes "github.com/k8snetworkplumbingwg/sriov-cni/pkg/types"
@@ -17,6 +23,15 @@ type pciUtils interface {
 	GetPciAddress(ifName string, vf int) (string, error)
 	EnableArpAndNdiscNotify(ifName string) error
 	EnableOptimisticDad(ifName string) error
+	GetVFGUIDs(pfName string, vfID int) (string, string, error)
+	SetVFVlanTrunk(pfName string, vfID int, trunk string, add bool) error
+	GetVdpaDeviceName(pciAddr string) (string, error)
+	GetVdpaDriver(name string) (string, error)
+	BindVdpaDriver(name, driver string) error
+	GetVhostVdpaPath(name string) (string, error)
+	GetVirtioVdpaNetdev(name string) (string, error)
+	GetVFRepresentor(pfName string, vfID int) (string, error)
+	GetSFLinkName(auxDev string) (string, error)
 }
 
 type pciUtilsImpl struct{}
@@ -41,6 +56,42 @@ func (p *pciUtilsImpl) EnableOptimisticDad(ifName string) error {
 	return utils.EnableOptimisticDad(ifName)
 }
 
+func (p *pciUtilsImpl) GetVFGUIDs(pfName string, vfID int) (string, string, error) {
+	return utils.GetVFGUIDs(pfName, vfID)
+}
+
+func (p *pciUtilsImpl) SetVFVlanTrunk(pfName string, vfID int, trunk string, add bool) error {
+	return utils.SetVFVlanTrunk(pfName, vfID, trunk, add)
+}
+
+func (p *pciUtilsImpl) GetVdpaDeviceName(pciAddr string) (string, error) {
+	return utils.GetVdpaDeviceName(pciAddr)
+}
+
+func (p *pciUtilsImpl) GetVdpaDriver(name string) (string, error) {
+	return utils.GetVdpaDriver(name)
+}
+
+func (p *pciUtilsImpl) BindVdpaDriver(name, driver string) error {
+	return utils.BindVdpaDriver(name, driver)
+}
+
+func (p *pciUtilsImpl) GetVhostVdpaPath(name string) (string, error) {
+	return utils.GetVhostVdpaPath(name)
+}
+
+func (p *pciUtilsImpl) GetVirtioVdpaNetdev(name string) (string, error) {
+	return utils.GetVirtioVdpaNetdev(name)
+}
+
+func (p *pciUtilsImpl) GetVFRepresentor(pfName string, vfID int) (string, error) {
+	return utils.GetVFRepresentor(pfName, vfID)
+}
+
+func (p *pciUtilsImpl) GetSFLinkName(auxDev string) (string, error) {
+	return utils.GetSFLinkName(auxDev)
+}
+
 // Manager provides interface invoke sriov nic related operations
 type Manager interface {
 	SetupVF(conf *sriovtypes.NetConf, podifName string, netns ns.NetNS) error
@@ -48,18 +99,29 @@ type Manager interface {
 	ResetVFConfig(conf *sriovtypes.NetConf) error
 	ApplyVFConfig(conf *sriovtypes.NetConf) error
 	FillOriginalVfInfo(conf *sriovtypes.NetConf) error
+	CheckVFConfig(conf *sriovtypes.NetConf) ([]string, error)
+	CheckVF(podIf *current.Interface, netns ns.NetNS) ([]string, error)
+	RestoreHostIFName(conf *sriovtypes.NetConf) error
+	SetupVdpaDevice(conf *sriovtypes.NetConf) error
+	ReleaseVdpaDevice(conf *sriovtypes.NetConf) error
+	RestoreRepresentor(conf *sriovtypes.NetConf) error
+	SetupBond(conf *sriovtypes.NetConf, bondIfName string, linkIfNames []string, netns ns.NetNS) (string, error)
+	ReleaseBond(bondIfName string, netns ns.NetNS) error
+	CheckBond(conf *sriovtypes.NetConf, bondIfName string, linkIfNames []string, netns ns.NetNS) ([]string, error)
 }
 
 type sriovManager struct {
-	nLink utils.NetlinkManager
-	utils pciUtils
+	nLink   utils.NetlinkManager
+	ethtool utils.EthtoolManager
+	utils   pciUtils
 }
 
 // NewSriovManager returns an instance of SriovManager
 func NewSriovManager() Manager {
 	return &sriovManager{
-		nLink: utils.GetNetlinkManager(),
-		utils: &pciUtilsImpl{},
+		nLink:   utils.GetNetlinkManager(),
+		ethtool: utils.GetEthtoolManager(),
+		utils:   &pciUtilsImpl{},
 	}
 }
 
@@ -150,6 +212,13 @@ func (s *sriovManager) SetupVF(conf *sriovtypes.NetConf, podifName string, netns
 		return fmt.Errorf("setupVF failed: %v", err)
 	}
 
+	// Move the RDMA device along with the netdev, this is only possible if the RDMA subsystem is in exclusive mode
+	if conf.RdmaDevice != "" {
+		if err = s.moveRdmaDevice(conf.RdmaDevice, netns); err != nil {
+			return fmt.Errorf("failed to move RDMA device %s to netns: %v", conf.RdmaDevice, err)
+		}
+	}
+
 	err = netns.Do(func(_ ns.NetNS) error {
 		netNSLinkObj, err := s.nLink.LinkByName(podifName)
 		if err != nil {
@@ -176,27 +245,98 @@ func (s *sriovManager) SetupVF(conf *sriovtypes.NetConf, podifName string, netns
 			}
 		}
 
-		logging.Debug("7. Enable Optimistic DAD for IPv6 addresses", "func", "SetupVF",
+		// 7. Set MTU
+		if conf.RequestedMTU != nil {
+			logging.Debug("7. Set MTU",
+				"func", "SetupVF",
+				"podifName", podifName,
+				"conf.RequestedMTU", *conf.RequestedMTU)
+			if err = s.nLink.LinkSetMTU(netNSLinkObj, *conf.RequestedMTU); err != nil {
+				return fmt.Errorf("failed to set MTU %d on %s: %v", *conf.RequestedMTU, podifName, err)
+			}
+		}
+
+		logging.Debug("8. Enable Optimistic DAD for IPv6 addresses", "func", "SetupVF",
 			"linkObj", netNSLinkObj)
 		_ = s.utils.EnableOptimisticDad(podifName)
 
-		// 8. Bring IF up in Pod netns
-		logging.Debug("8. Bring IF up in Pod netns",
+		// 9. Apply ethtool configuration
+		if conf.Ethtool != nil {
+			logging.Debug("9. Apply ethtool configuration",
+				"func", "SetupVF",
+				"podifName", podifName,
+				"conf.Ethtool", conf.Ethtool)
+			conf.OrigVfState.Ethtool = &sriovtypes.EthtoolConf{}
+			if err = s.setEthtoolConf(podifName, conf.Ethtool, conf.OrigVfState.Ethtool); err != nil {
+				return fmt.Errorf("failed to apply ethtool configuration to %s: %v", podifName, err)
+			}
+		}
+
+		// 10. Bring IF up in Pod netns
+		logging.Debug("10. Bring IF up in Pod netns",
 			"func", "SetupVF",
 			"linkObj", netNSLinkObj)
 		if err = s.nLink.LinkSetUp(netNSLinkObj); err != nil {
 			return fmt.Errorf("error bringing interface up in container ns: %q", err)
 		}
 
+		// 11. Set allmulticast and promiscuous mode
+		if err = s.setLinkModes(netNSLinkObj, conf.AllMulti, conf.Promisc); err != nil {
+			return fmt.Errorf("failed to set link modes of %s: %v", podifName, err)
+		}
+
+		// 12. Enforce the max tx rate with a tc qdisc if the PF can not
+		if conf.HasSoftwareTxRate() {
+			logging.Debug("12. Install tc rate limiter",
+				"func", "SetupVF",
+				"podifName", podifName,
+				"conf.MaxTxRate", *conf.MaxTxRate)
+			if err = s.nLink.QdiscReplace(newTxRateQdisc(netNSLinkObj, *conf.MaxTxRate)); err != nil {
+				return fmt.Errorf("failed to install tc rate limiter of %d Mbps on %s: %v", *conf.MaxTxRate, podifName, err)
+			}
+		}
+
+		// 13. Create VLAN sub-interfaces
+		for i := range conf.Vlans {
+			vlanIfName := conf.Vlans[i].IfName(podifName)
+			logging.Debug("13. Create VLAN sub-interface",
+				"func", "SetupVF",
+				"podifName", podifName,
+				"vlanIfName", vlanIfName,
+				"id", conf.Vlans[i].ID)
+			vlanLink := &netlink.Vlan{
+				LinkAttrs: netlink.LinkAttrs{
+					Name:        vlanIfName,
+					ParentIndex: netNSLinkObj.Attrs().Index,
+				},
+				VlanId:       conf.Vlans[i].ID,
+				VlanProtocol: netlink.VLAN_PROTOCOL_8021Q,
+			}
+			if err = s.nLink.LinkAdd(vlanLink); err != nil {
+				return fmt.Errorf("failed to create VLAN sub-interface %s: %v", vlanIfName, err)
+			}
+			if err = s.nLink.LinkSetUp(vlanLink); err != nil {
+				return fmt.Errorf("failed to bring VLAN sub-interface %s up: %v", vlanIfName, err)
+			}
+		}
+
 		return nil
 	})
 	if err != nil {
 		return fmt.Errorf("error setting up interface in container namespace: %q", err)
 	}
 
+	// 14. Set up the VF representor if the PF eswitch is in switchdev mode
+	if err = s.setupRepresentor(conf, podifName); err != nil {
+		return fmt.Errorf("failed to set up representor of VF %d of PF %s: %v", conf.VFID, conf.Master, err)
+	}
+
 	// Copy the MTU value to a new variable
 	// and use it as a pointer
 	vfMTU := linkObj.Attrs().MTU
+	if conf.RequestedMTU != nil {
+		vfMTU = *conf.RequestedMTU
+	}
 	conf.MTU = &vfMTU
 	return nil
 }
@@ -249,6 +389,247 @@ func (s *sriovManager) renameAndMoveLink(source, target ns.NetNS, possibleIfName
 	})
 }
 
+// setLinkModes turns allmulticast and promiscuous mode of a link on or off, an empty value leaves the mode untouched
+func (s *sriovManager) setLinkModes(link netlink.Link, allMulti, promisc string) error {
+	logging.Debug("Set link modes",
+		"func", "setLinkModes",
+		"link", link.Attrs().Name,
+		"allMulti", allMulti,
+		"promisc", promisc)
+
+	switch allMulti {
+	case "on":
+		if err := s.nLink.LinkSetAllmulticastOn(link); err != nil {
+			return fmt.Errorf("failed to turn allmulticast on: %v", err)
+		}
+	case "off":
+		if err := s.nLink.LinkSetAllmulticastOff(link); err != nil {
+			return fmt.Errorf("failed to turn allmulticast off: %v", err)
+		}
+	}
+
+	switch promisc {
+	case "on":
+		if err := s.nLink.SetPromiscOn(link); err != nil {
+			return fmt.Errorf("failed to turn promiscuous mode on: %v", err)
+		}
+	case "off":
+		if err := s.nLink.SetPromiscOff(link); err != nil {
+			return fmt.Errorf("failed to turn promiscuous mode off: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Burst and queueing latency of the tc rate limiter
+const (
+	txRateMinBurst  = 64 * 1024
+	txRateLatencyMs = 50
+)
+
+// newTxRateQdisc returns a root token bucket filter limiting the egress of link to maxTxRate Mbps
+func newTxRateQdisc(link netlink.Link, maxTxRate int) *netlink.Tbf {
+	rate := uint64(maxTxRate) * 1000 * 1000 / 8
+	// allow a burst of 10ms at the configured rate, but at least a few jumbo frames
+	burst := uint32(rate / 100)
+	if burst < txRateMinBurst {
+		burst = txRateMinBurst
+	}
+	limit := uint32(rate*txRateLatencyMs/1000) + burst
+
+	return &netlink.Tbf{
+		QdiscAttrs: netlink.QdiscAttrs{
+			LinkIndex: link.Attrs().Index,
+			Handle:    netlink.MakeHandle(1, 0),
+			Parent:    netlink.HANDLE_ROOT,
+		},
+		Rate:   rate,
+		Limit:  limit,
+		Buffer: netlink.Xmittime(rate, burst),
+	}
+}
+
+// setEthtoolConf applies ethtool features, ring sizes and channel counts to a netdevice.
+// If saved is not nil, every value is recorded in it before it is changed, so that it can be restored later.
+func (s *sriovManager) setEthtoolConf(ifName string, want, saved *sriovtypes.EthtoolConf) error {
+	if len(want.Features) > 0 {
+		if saved != nil {
+			current, err := s.ethtool.Features(ifName)
+			if err != nil {
+				return fmt.Errorf("failed to get features: %v", err)
+			}
+			saved.Features = make(map[string]bool, len(want.Features))
+			for name := range want.Features {
+				value, ok := current[name]
+				if !ok {
+					return fmt.Errorf("unsupported feature %q", name)
+				}
+				saved.Features[name] = value
+			}
+		}
+		if err := s.ethtool.Change(ifName, want.Features); err != nil {
+			return fmt.Errorf("failed to set features: %v", err)
+		}
+	}
+
+	if want.Rings != nil {
+		ring, err := s.ethtool.GetRing(ifName)
+		if err != nil {
+			return fmt.Errorf("failed to get ring sizes: %v", err)
+		}
+		if saved != nil {
+			saved.Rings = &sriovtypes.EthtoolRings{}
+			if want.Rings.Rx != nil {
+				saved.Rings.Rx = &ring.RxPending
+			}
+			if want.Rings.Tx != nil {
+				saved.Rings.Tx = &ring.TxPending
+			}
+		}
+		// newRing is a copy, the saved pointers keep the original sizes
+		newRing := ring
+		if want.Rings.Rx != nil {
+			newRing.RxPending = *want.Rings.Rx
+		}
+		if want.Rings.Tx != nil {
+			newRing.TxPending = *want.Rings.Tx
+		}
+		if _, err = s.ethtool.SetRing(ifName, newRing); err != nil {
+			return fmt.Errorf("failed to set ring sizes: %v", err)
+		}
+	}
+
+	if want.Channels != nil {
+		channels, err := s.ethtool.GetChannels(ifName)
+		if err != nil {
+			return fmt.Errorf("failed to get channel counts: %v", err)
+		}
+		if saved != nil {
+			saved.Channels = &sriovtypes.EthtoolChannels{}
+			if want.Channels.Rx != nil {
+				saved.Channels.Rx = &channels.RxCount
+			}
+			if want.Channels.Tx != nil {
+				saved.Channels.Tx = &channels.TxCount
+			}
+			if want.Channels.Other != nil {
+				saved.Channels.Other = &channels.OtherCount
+			}
+			if want.Channels.Combined != nil {
+				saved.Channels.Combined = &channels.CombinedCount
+			}
+		}
+		newChannels := channels
+		if want.Channels.Rx != nil {
+			newChannels.RxCount = *want.Channels.Rx
+		}
+		if want.Channels.Tx != nil {
+			newChannels.TxCount = *want.Channels.Tx
+		}
+		if want.Channels.Other != nil {
+			newChannels.OtherCount = *want.Channels.Other
+		}
+		if want.Channels.Combined != nil {
+			newChannels.CombinedCount = *want.Channels.Combined
+		}
+		if _, err = s.ethtool.SetChannels(ifName, newChannels); err != nil {
+			return fmt.Errorf("failed to set channel counts: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// setupRepresentor brings the VF representor up, renaming it first if requested, and records it in conf
+func (s *sriovManager) setupRepresentor(conf *sriovtypes.NetConf, podifName string) error {
+	// only VF representors are looked up
+	if conf.IsSF() {
+		return nil
+	}
+
+	repName, err := s.utils.GetVFRepresentor(conf.Master, conf.VFID)
+	if err != nil {
+		return err
+	}
+	if repName == "" {
+		return nil
+	}
+
+	repLink, err := s.nLink.LinkByName(repName)
+	if err != nil {
+		return fmt.Errorf("failed to get representor netdevice with name %s: %q", repName, err)
+	}
+	conf.OrigVfState.RepresentorName = repName
+	conf.OrigVfState.RepresentorUp = repLink.Attrs().Flags&net.FlagUp != 0
+	// recorded before the representor is changed, so that a rollback restores it
+	conf.Representor = repName
+
+	if conf.RenameRepresentor {
+		newName := utils.RepresentorName(conf.ContainerID, podifName)
+		logging.Debug("Rename VF representor",
+			"func", "setupRepresentor",
+			"repName", repName,
+			"newName", newName)
+		// a netdevice can only be renamed while it is down
+		if err = s.nLink.LinkSetDown(repLink); err != nil {
+			return fmt.Errorf("failed to set representor %s down: %q", repName, err)
+		}
+		if err = s.nLink.LinkSetName(repLink, newName); err != nil {
+			// the representor keeps its name, only its admin state has to be restored
+			if conf.OrigVfState.RepresentorUp {
+				_ = s.nLink.LinkSetUp(repLink)
+			}
+			return fmt.Errorf("failed to rename representor %s to %s: %q", repName, newName, err)
+		}
+		repName = newName
+		conf.Representor = repName
+	}
+
+	logging.Debug("Bring VF representor up",
+		"func", "setupRepresentor",
+		"repName", repName)
+	if err = s.nLink.LinkSetUp(repLink); err != nil {
+		return fmt.Errorf("failed to set representor %s up: %q", repName, err)
+	}
+	return nil
+}
+
+// RestoreRepresentor gives the VF representor back the name and admin state it had before SetupVF
+func (s *sriovManager) RestoreRepresentor(conf *sriovtypes.NetConf) error {
+	if conf.Representor == "" {
+		return nil
+	}
+
+	repLink, err := s.nLink.LinkByName(conf.Representor)
+	if err != nil {
+		return fmt.Errorf("failed to get representor netdevice with name %s: %q", conf.Representor, err)
+	}
+
+	if conf.Representor != conf.OrigVfState.RepresentorName || !conf.OrigVfState.RepresentorUp {
+		if err = s.nLink.LinkSetDown(repLink); err != nil {
+			return fmt.Errorf("failed to set representor %s down: %q", conf.Representor, err)
+		}
+	}
+
+	if conf.Representor != conf.OrigVfState.RepresentorName {
+		logging.Debug("Restore VF representor name",
+			"func", "RestoreRepresentor",
+			"conf.Representor", conf.Representor,
+			"conf.OrigVfState.RepresentorName", conf.OrigVfState.RepresentorName)
+		if err = s.nLink.LinkSetName(repLink, conf.OrigVfState.RepresentorName); err != nil {
+			return fmt.Errorf("failed to rename representor %s to %s: %q", conf.Representor, conf.OrigVfState.RepresentorName, err)
+		}
+		if conf.OrigVfState.RepresentorUp {
+			if err = s.nLink.LinkSetUp(repLink); err != nil {
+				return fmt.Errorf("failed to set representor %s up: %q", conf.OrigVfState.RepresentorName, err)
+			}
+		}
+	}
+
+	return nil
+}
+
 // ReleaseVF reset a VF from Pod netns and return it to init netns
 func (s *sriovManager) ReleaseVF(conf *sriovtypes.NetConf, podifName string, netns ns.NetNS) error {
 	initns, err := ns.GetCurrentNS()
@@ -261,7 +642,7 @@ func (s *sriovManager) ReleaseVF(conf *sriovtypes.NetConf, podifName string, net
 		}
 	}()
 
-	return netns.Do(func(_ ns.NetNS) error {
+	err = netns.Do(func(_ ns.NetNS) error {
 		// get VF device
 		logging.Debug("Get VF device",
 			"func", "ReleaseVF",
@@ -271,6 +652,57 @@ func (s *sriovManager) ReleaseVF(conf *sriovtypes.NetConf, podifName string, net
 			return fmt.Errorf("failed to get netlink device with name %s: %q", podifName, err)
 		}
 
+		// clear allmulticast and promiscuous mode set by SetupVF
+		clearAllMulti, clearPromisc := "", ""
+		if conf.AllMulti == "on" {
+			clearAllMulti = "off"
+		}
+		if conf.Promisc == "on" {
+			clearPromisc = "off"
+		}
+		if err = s.setLinkModes(linkObj, clearAllMulti, clearPromisc); err != nil {
+			return fmt.Errorf("failed to clear link modes of %s: %v", podifName, err)
+		}
+
+		// delete VLAN sub-interfaces, missing ones were not created because SetupVF failed early
+		for i := range conf.Vlans {
+			vlanIfName := conf.Vlans[i].IfName(podifName)
+			vlanLink, err := s.nLink.LinkByName(vlanIfName)
+			if err != nil {
+				if _, ok := err.(netlink.LinkNotFoundError); ok {
+					continue
+				}
+				return fmt.Errorf("failed to get VLAN sub-interface %s: %v", vlanIfName, err)
+			}
+			logging.Debug("Delete VLAN sub-interface",
+				"func", "ReleaseVF",
+				"vlanIfName", vlanIfName)
+			if err = s.nLink.LinkDel(vlanLink); err != nil {
+				return fmt.Errorf("failed to delete VLAN sub-interface %s: %v", vlanIfName, err)
+			}
+		}
+
+		// remove the tc rate limiter, the VF gets back the default qdisc of its driver
+		if conf.HasSoftwareTxRate() {
+			logging.Debug("Remove tc rate limiter",
+				"func", "ReleaseVF",
+				"podifName", podifName)
+			if err = s.nLink.QdiscDel(newTxRateQdisc(linkObj, *conf.MaxTxRate)); err != nil && !errors.Is(err, unix.ENOENT) {
+				return fmt.Errorf("failed to remove tc rate limiter of %s: %v", podifName, err)
+			}
+		}
+
+		// restore ethtool settings changed by SetupVF
+		if conf.OrigVfState.Ethtool != nil {
+			logging.Debug("Restore ethtool settings",
+				"func", "ReleaseVF",
+				"podifName", podifName,
+				"conf.OrigVfState.Ethtool", conf.OrigVfState.Ethtool)
+			if err = s.setEthtoolConf(podifName, conf.OrigVfState.Ethtool, nil); err != nil {
+				return fmt.Errorf("failed to restore ethtool settings of %s: %v", podifName, err)
+			}
+		}
+
 		// shutdown VF device
 		logging.Debug("Shutdown VF device",
 			"func", "ReleaseVF",
@@ -324,8 +756,268 @@ func (s *sriovManager) ReleaseVF(conf *sriovtypes.NetConf, podifName string, net
 			return fmt.Errorf("failed to move interface %s to init netns: %v", conf.OrigVfState.HostIFName, err)
 		}
 
+		// move RDMA device to init netns
+		if conf.RdmaDevice != "" {
+			logging.Debug("Move RDMA device to init netns",
+				"func", "ReleaseVF",
+				"conf.RdmaDevice", conf.RdmaDevice)
+			if err = s.moveRdmaDevice(conf.RdmaDevice, initns); err != nil {
+				return fmt.Errorf("failed to move RDMA device %s to init netns: %v", conf.RdmaDevice, err)
+			}
+		}
+
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	return s.RestoreRepresentor(conf)
+}
+
+// moveRdmaDevice moves an RDMA device from the current netns to the target netns.
+// Nothing is done if the RDMA subsystem is in shared mode, where RDMA devices are visible in all network namespaces.
+func (s *sriovManager) moveRdmaDevice(rdmaDevice string, target ns.NetNS) error {
+	mode, err := s.nLink.RdmaSystemGetNetnsMode()
+	if err != nil {
+		return fmt.Errorf("failed to get RDMA subsystem netns mode: %v", err)
+	}
+	if mode != "exclusive" {
+		logging.Debug("RDMA subsystem is not in exclusive mode, not moving RDMA device",
+			"func", "moveRdmaDevice",
+			"rdmaDevice", rdmaDevice,
+			"mode", mode)
+		return nil
+	}
+
+	rdmaLink, err := s.nLink.RdmaLinkByName(rdmaDevice)
+	if err != nil {
+		// the device is not in the current netns, e.g. a previous attempt to move it failed
+		logging.Warning("RDMA device not found in netns, not moving it",
+			"func", "moveRdmaDevice",
+			"rdmaDevice", rdmaDevice,
+			"error", err)
+		return nil
+	}
+
+	logging.Debug("Move RDMA device",
+		"func", "moveRdmaDevice",
+		"rdmaDevice", rdmaDevice,
+		"target.Fd()", int(target.Fd()))
+	//nolint:gosec
+	return s.nLink.RdmaLinkSetNsFd(rdmaLink, uint32(target.Fd()))
+}
+
+// SetupBond creates the bond bondIfName in the pod netns and enslaves the VF netdevices linkIfNames set up by
+// SetupVF. It returns the MAC address of the bond, which is the one of its first link.
+func (s *sriovManager) SetupBond(conf *sriovtypes.NetConf, bondIfName string, linkIfNames []string, netns ns.NetNS) (string, error) {
+	miimon := 100
+	if conf.Bond.Miimon != nil {
+		miimon = *conf.Bond.Miimon
+	}
+
+	var mac string
+	err := netns.Do(func(_ ns.NetNS) error {
+		logging.Debug("Create bond",
+			"func", "SetupBond",
+			"bondIfName", bondIfName,
+			"mode", conf.Bond.Mode,
+			"miimon", miimon)
+		bond := netlink.NewLinkBond(netlink.LinkAttrs{Name: bondIfName})
+		bond.Mode = netlink.StringToBondMode(conf.Bond.Mode)
+		bond.Miimon = miimon
+		if err := s.nLink.LinkAdd(bond); err != nil {
+			return fmt.Errorf("failed to create bond %s: %v", bondIfName, err)
+		}
+		bondLink, err := s.nLink.LinkByName(bondIfName)
+		if err != nil {
+			return fmt.Errorf("failed to get bond %s: %v", bondIfName, err)
+		}
+
+		// links have to be down to be enslaved, the bond brings them back up
+		for _, linkIfName := range linkIfNames {
+			logging.Debug("Enslave bond link",
+				"func", "SetupBond",
+				"bondIfName", bondIfName,
+				"linkIfName", linkIfName)
+			link, err := s.nLink.LinkByName(linkIfName)
+			if err != nil {
+				return fmt.Errorf("failed to get bond link %s: %v", linkIfName, err)
+			}
+			if err = s.nLink.LinkSetDown(link); err != nil {
+				return fmt.Errorf("failed to set bond link %s down: %v", linkIfName, err)
+			}
+			if err = s.nLink.LinkSetMaster(link, bondLink); err != nil {
+				return fmt.Errorf("failed to enslave %s to bond %s: %v", linkIfName, bondIfName, err)
+			}
+		}
+
+		if err = s.nLink.LinkSetUp(bondLink); err != nil {
+			return fmt.Errorf("failed to set bond %s up: %v", bondIfName, err)
+		}
+
+		// the bond takes its MAC address from the first link once it is enslaved
+		bondLink, err = s.nLink.LinkByName(bondIfName)
+		if err != nil {
+			return fmt.Errorf("failed to get bond %s: %v", bondIfName, err)
+		}
+		mac = bondLink.Attrs().HardwareAddr.String()
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("error setting up bond in container namespace: %q", err)
+	}
+
+	return mac, nil
+}
+
+// ReleaseBond deletes the bond bondIfName from the pod netns, which releases its links
+func (s *sriovManager) ReleaseBond(bondIfName string, netns ns.NetNS) error {
+	return netns.Do(func(_ ns.NetNS) error {
+		bondLink, err := s.nLink.LinkByName(bondIfName)
+		if err != nil {
+			// the bond was not created because SetupBond failed early
+			if _, ok := err.(netlink.LinkNotFoundError); ok {
+				return nil
+			}
+			return fmt.Errorf("failed to get bond %s: %v", bondIfName, err)
+		}
+		logging.Debug("Delete bond",
+			"func", "ReleaseBond",
+			"bondIfName", bondIfName)
+		if err = s.nLink.LinkDel(bondLink); err != nil {
+			return fmt.Errorf("failed to delete bond %s: %v", bondIfName, err)
+		}
+		return nil
+	})
+}
+
+// CheckBond verifies that the bond bondIfName set up by SetupBond exists in netns, is up, has the mode of conf and
+// has the VF netdevices linkIfNames enslaved. It returns a description of every attribute that drifted.
+func (s *sriovManager) CheckBond(conf *sriovtypes.NetConf, bondIfName string, linkIfNames []string, netns ns.NetNS) ([]string, error) {
+	var drifted []string
+	err := netns.Do(func(_ ns.NetNS) error {
+		bondLink, err := s.nLink.LinkByName(bondIfName)
+		if err != nil {
+			drifted = append(drifted, fmt.Sprintf("name: bond %s not found: %v", bondIfName, err))
+			return nil
+		}
+
+		if bond, ok := bondLink.(*netlink.Bond); !ok {
+			drifted = append(drifted, fmt.Sprintf("type: expected bond, found %s", bondLink.Type()))
+		} else if bond.Mode.String() != conf.Bond.Mode {
+			drifted = append(drifted, fmt.Sprintf("mode: expected %s, found %s", conf.Bond.Mode, bond.Mode))
+		}
+
+		if bondLink.Attrs().Flags&net.FlagUp == 0 {
+			drifted = append(drifted, "state: expected up, found down")
+		}
+
+		for _, linkIfName := range linkIfNames {
+			link, err := s.nLink.LinkByName(linkIfName)
+			if err != nil {
+				drifted = append(drifted, fmt.Sprintf("links: link %s not found: %v", linkIfName, err))
+				continue
+			}
+			if link.Attrs().MasterIndex != bondLink.Attrs().Index {
+				drifted = append(drifted, fmt.Sprintf("links: link %s is not enslaved", linkIfName))
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check bond %s in container namespace: %v", bondIfName, err)
+	}
+
+	return drifted, nil
+}
+
+// SetupVdpaDevice finds the vDPA device of the VF, or creates one, and binds it to the driver of conf.VdpaType.
+// For a virtio vDPA device the virtio netdevice replaces the VF netdevice as the one moved to the Pod netns.
+func (s *sriovManager) SetupVdpaDevice(conf *sriovtypes.NetConf) error {
+	name, err := s.utils.GetVdpaDeviceName(conf.DeviceID)
+	if err != nil {
+		return fmt.Errorf("failed to get vDPA device of VF %s: %v", conf.DeviceID, err)
+	}
+
+	if name == "" {
+		name = "vdpa:" + conf.DeviceID
+		logging.Debug("Create vDPA device",
+			"func", "SetupVdpaDevice",
+			"name", name,
+			"conf.DeviceID", conf.DeviceID)
+		if err = s.nLink.VDPANewDev(name, "pci", conf.DeviceID, netlink.VDPANewDevParams{}); err != nil {
+			return netlinkError("failed to create vDPA device %s on VF %s: %v", name, conf.DeviceID, err)
+		}
+		conf.VdpaCreated = true
+	}
+	conf.VdpaDevice = name
+
+	conf.OrigVfState.VdpaDriver, err = s.utils.GetVdpaDriver(name)
+	if err != nil {
+		return err
+	}
+
+	driver := utils.VhostVdpaDriver
+	if conf.VdpaType == sriovtypes.VdpaTypeVirtio {
+		driver = utils.VirtioVdpaDriver
+	}
+	logging.Debug("Bind vDPA device",
+		"func", "SetupVdpaDevice",
+		"name", name,
+		"driver", driver,
+		"conf.OrigVfState.VdpaDriver", conf.OrigVfState.VdpaDriver)
+	if err = s.utils.BindVdpaDriver(name, driver); err != nil {
+		return err
+	}
+
+	// the vhost-vdpa character device or virtio netdevice show up once the driver probed the vDPA device
+	return utils.Retry(5, 100*time.Millisecond, func() error {
+		if conf.VdpaType == sriovtypes.VdpaTypeVirtio {
+			netdev, err := s.utils.GetVirtioVdpaNetdev(name)
+			if err != nil {
+				return err
+			}
+			conf.OrigVfState.HostIFName = netdev
+			return nil
+		}
+
+		path, err := s.utils.GetVhostVdpaPath(name)
+		if err != nil {
+			return err
+		}
+		conf.VdpaPath = path
+		return nil
+	})
+}
+
+// ReleaseVdpaDevice deletes the vDPA device of the VF if it was created by SetupVdpaDevice,
+// otherwise the device is bound back to its original driver
+func (s *sriovManager) ReleaseVdpaDevice(conf *sriovtypes.NetConf) error {
+	if conf.VdpaDevice == "" {
+		return nil
+	}
+
+	if conf.VdpaCreated {
+		logging.Debug("Delete vDPA device",
+			"func", "ReleaseVdpaDevice",
+			"conf.VdpaDevice", conf.VdpaDevice)
+		if err := s.nLink.VDPADelDev(conf.VdpaDevice); err != nil {
+			return netlinkError("failed to delete vDPA device %s: %v", conf.VdpaDevice, err)
+		}
+		return nil
+	}
+
+	if conf.OrigVfState.VdpaDriver == "" {
+		return nil
+	}
+
+	logging.Debug("Restore vDPA device driver",
+		"func", "ReleaseVdpaDevice",
+		"conf.VdpaDevice", conf.VdpaDevice,
+		"conf.OrigVfState.VdpaDriver", conf.OrigVfState.VdpaDriver)
+	return s.utils.BindVdpaDriver(conf.VdpaDevice, conf.OrigVfState.VdpaDriver)
 }
 
 func getVfInfo(link netlink.Link, id int) *netlink.VfInfo {
@@ -338,23 +1030,67 @@ func getVfInfo(link netlink.Link, id int) *netlink.VfInfo {
 	return nil
 }
 
+// vfWithMAC returns the ID of a VF of the PF link other than skipID that has the administrative MAC address mac
+func vfWithMAC(link netlink.Link, mac string, skipID int) (int, bool) {
+	for _, vf := range link.Attrs().Vfs {
+		if vf.ID != skipID && vf.Mac.String() == mac {
+			return vf.ID, true
+		}
+	}
+	return 0, false
+}
+
+// vfLinkState maps a link_state config value to its netlink representation
+func vfLinkState(linkState string) (uint32, bool) {
+	switch linkState {
+	//nolint:goconst
+	case "auto":
+		return netlink.VF_LINK_STATE_AUTO, true
+	//nolint:goconst
+	case "enable":
+		return netlink.VF_LINK_STATE_ENABLE, true
+	//nolint:goconst
+	case "disable":
+		return netlink.VF_LINK_STATE_DISABLE, true
+	}
+	return 0, false
+}
+
 // ApplyVFConfig configure a VF with parameters given in NetConf
 func (s *sriovManager) ApplyVFConfig(conf *sriovtypes.NetConf) error {
 	pfLink, err := s.nLink.LinkByName(conf.Master)
 	if err != nil {
-		return fmt.Errorf("failed to lookup master %q: %v", conf.Master, err)
+		return pfNotFoundError(conf.Master, err)
+	}
+
+	// a VF can not have a bigger MTU than its PF
+	if conf.RequestedMTU != nil && *conf.RequestedMTU > pfLink.Attrs().MTU {
+		return invalidConfError("requested MTU %d for vf %d exceeds the MTU %d of PF %s", *conf.RequestedMTU, conf.VFID, pfLink.Attrs().MTU, conf.Master)
 	}
+
+	// a Scalable Function has no VF configuration on the PF
+	if conf.IsSF() {
+		return nil
+	}
+
 	// 1. Set vlan
 	if conf.Vlan != nil {
 		if err = s.nLink.LinkSetVfVlanQosProto(pfLink, conf.VFID, *conf.Vlan, *conf.VlanQoS, sriovtypes.VlanProtoInt[*conf.VlanProto]); err != nil {
-			return fmt.Errorf("failed to set vf %d vlan configuration - id %d, qos %d and proto %s: %v", conf.VFID, *conf.Vlan, *conf.VlanQoS, *conf.VlanProto, err)
+			return netlinkError("failed to set vf %d vlan configuration - id %d, qos %d and proto %s: %v", conf.VFID, *conf.Vlan, *conf.VlanQoS, *conf.VlanProto, err)
 		}
 	}
 	// 2. Set mac address
 	if conf.MAC != "" {
+		// a generated MAC address may clash with the one of another pod, the PF would not know which VF to switch to
+		if conf.MACGenerated {
+			if vfID, found := vfWithMAC(pfLink, conf.MAC, conf.VFID); found {
+				return types.NewError(sriovtypes.ErrMACCollision,
+					fmt.Sprintf("generated MAC address %s is already set on vf %d of PF %s", conf.MAC, vfID, conf.Master), "")
+			}
+		}
 		// when we restore the original hardware mac address we may get a device or resource busy. so we introduce retry
 		if err := utils.SetVFHardwareMAC(s.nLink, conf.Master, conf.VFID, conf.MAC); err != nil {
-			return fmt.Errorf("failed to set MAC address to %s: %v", conf.MAC, err)
+			return netlinkError("failed to set MAC address to %s: %v", conf.MAC, err)
 		}
 	}
 
@@ -372,9 +1108,20 @@ func (s *sriovManager) ApplyVFConfig(conf *sriovtypes.NetConf) error {
 	}
 
 	if rateConfigured {
-		if err = s.nLink.LinkSetVfRate(pfLink, conf.VFID, minTxRate, maxTxRate); err != nil {
-			return fmt.Errorf("failed to set vf %d min_tx_rate to %d Mbps: max_tx_rate to %d Mbps: %v",
-				conf.VFID, minTxRate, maxTxRate, err)
+		conf.TxRateLimiter = sriovtypes.TxRateModeHardware
+		if conf.TxRateMode == sriovtypes.TxRateModeSoftware {
+			conf.TxRateLimiter = sriovtypes.TxRateModeSoftware
+		} else if err = s.nLink.LinkSetVfRate(pfLink, conf.VFID, minTxRate, maxTxRate); err != nil {
+			// a tc qdisc in the pod can only enforce a max tx rate, and only on a netdevice
+			if !errors.Is(err, unix.EOPNOTSUPP) || minTxRate != 0 || !conf.HasPodNetdev() {
+				return netlinkError("failed to set vf %d min_tx_rate to %d Mbps: max_tx_rate to %d Mbps: %v",
+					conf.VFID, minTxRate, maxTxRate, err)
+			}
+			logging.Info("PF does not support VF rate limiting, falling back to a tc rate limiter",
+				"func", "ApplyVFConfig",
+				"conf.Master", conf.Master,
+				"conf.VFID", conf.VFID)
+			conf.TxRateLimiter = sriovtypes.TxRateModeSoftware
 		}
 	}
 
@@ -386,7 +1133,7 @@ func (s *sriovManager) ApplyVFConfig(conf *sriovtypes.NetConf) error {
 			spoofChk = true
 		}
 		if err = s.nLink.LinkSetVfSpoofchk(pfLink, conf.VFID, spoofChk); err != nil {
-			return fmt.Errorf("failed to set vf %d spoofchk flag to %s: %v", conf.VFID, conf.SpoofChk, err)
+			return netlinkError("failed to set vf %d spoofchk flag to %s: %v", conf.VFID, conf.SpoofChk, err)
 		}
 	}
 
@@ -397,35 +1144,49 @@ func (s *sriovManager) ApplyVFConfig(conf *sriovtypes.NetConf) error {
 			trust = true
 		}
 		if err = s.nLink.LinkSetVfTrust(pfLink, conf.VFID, trust); err != nil {
-			return fmt.Errorf("failed to set vf %d trust flag to %s: %v", conf.VFID, conf.Trust, err)
+			return netlinkError("failed to set vf %d trust flag to %s: %v", conf.VFID, conf.Trust, err)
 		}
 	}
 
 	// 6. Set link state
 	if conf.LinkState != "" {
-		var state uint32
-		switch conf.LinkState {
-		//nolint:goconst
-		case "auto":
-			state = netlink.VF_LINK_STATE_AUTO
-		//nolint:goconst
-		case "enable":
-			state = netlink.VF_LINK_STATE_ENABLE
-		//nolint:goconst
-		case "disable":
-			state = netlink.VF_LINK_STATE_DISABLE
-		default:
+		state, ok := vfLinkState(conf.LinkState)
+		if !ok {
 			// the value should have been validated earlier, return error if we somehow got here
-			return fmt.Errorf("unknown link state %s when setting it for vf %d: %v", conf.LinkState, conf.VFID, err)
+			return invalidConfError("unknown link state %s when setting it for vf %d: %v", conf.LinkState, conf.VFID, err)
 		}
 		if err = s.nLink.LinkSetVfState(pfLink, conf.VFID, state); err != nil {
-			return fmt.Errorf("failed to set vf %d link state to %d: %v", conf.VFID, state, err)
+			return netlinkError("failed to set vf %d link state to %d: %v", conf.VFID, state, err)
+		}
+	}
+
+	// 7. Set node and port GUID of InfiniBand VFs
+	if conf.GUID != "" {
+		guid, err := parseGUID(conf.GUID)
+		if err != nil {
+			return err
+		}
+		if err = s.nLink.LinkSetVfNodeGUID(pfLink, conf.VFID, guid); err != nil {
+			return netlinkError("failed to set vf %d node GUID to %s: %v", conf.VFID, conf.GUID, err)
+		}
+		if err = s.nLink.LinkSetVfPortGUID(pfLink, conf.VFID, guid); err != nil {
+			return netlinkError("failed to set vf %d port GUID to %s: %v", conf.VFID, conf.GUID, err)
+		}
+	}
+
+	// 8. Set VLAN trunk
+	if conf.VlanTrunk != "" {
+		if err = s.utils.SetVFVlanTrunk(conf.Master, conf.VFID, conf.VlanTrunk, true); err != nil {
+			return fmt.Errorf("failed to set vf %d VLAN trunk to %s: %v", conf.VFID, conf.VlanTrunk, err)
 		}
 	}
 
 	// Copy the MTU value to a new variable
 	// and use it as a pointer
 	pfMtu := pfLink.Attrs().MTU
+	if conf.RequestedMTU != nil {
+		pfMtu = *conf.RequestedMTU
+	}
 	conf.MTU = &pfMtu
 
 	return nil
@@ -435,14 +1196,24 @@ func (s *sriovManager) ApplyVFConfig(conf *sriovtypes.NetConf) error {
 func (s *sriovManager) FillOriginalVfInfo(conf *sriovtypes.NetConf) error {
 	pfLink, err := s.nLink.LinkByName(conf.Master)
 	if err != nil {
-		return fmt.Errorf("failed to lookup master %q: %v", conf.Master, err)
+		return pfNotFoundError(conf.Master, err)
 	}
-	// Save current the VF state before modifying it
-	vfState := getVfInfo(pfLink, conf.VFID)
-	if vfState == nil {
-		return fmt.Errorf("failed to find vf %d", conf.VFID)
+	// Save current the VF state before modifying it, a Scalable Function has no VF state on the PF
+	if !conf.IsSF() {
+		vfState := getVfInfo(pfLink, conf.VFID)
+		if vfState == nil {
+			return fmt.Errorf("failed to find vf %d", conf.VFID)
+		}
+		conf.OrigVfState.FillFromVfInfo(vfState)
+	}
+
+	// GUIDs are not reported by netlink, read them from sysfs if we are going to change them
+	if conf.GUID != "" && !conf.IsSF() {
+		conf.OrigVfState.NodeGUID, conf.OrigVfState.PortGUID, err = s.utils.GetVFGUIDs(conf.Master, conf.VFID)
+		if err != nil {
+			return fmt.Errorf("failed to get original GUIDs of vf %d: %v", conf.VFID, err)
+		}
 	}
-	conf.OrigVfState.FillFromVfInfo(vfState)
 
 	// add also MTU to the vf info in the vf is we have an interface name
 	if conf.OrigVfState.HostIFName != "" {
@@ -458,9 +1229,14 @@ func (s *sriovManager) FillOriginalVfInfo(conf *sriovtypes.NetConf) error {
 
 // ResetVFConfig reset a VF to its original state
 func (s *sriovManager) ResetVFConfig(conf *sriovtypes.NetConf) error {
+	// nothing was configured on the PF for a Scalable Function
+	if conf.IsSF() {
+		return nil
+	}
+
 	pfLink, err := s.nLink.LinkByName(conf.Master)
 	if err != nil {
-		return fmt.Errorf("failed to lookup master %q: %v", conf.Master, err)
+		return pfNotFoundError(conf.Master, err)
 	}
 
 	// Set 802.1q as default in case cache config does not have a value for vlan proto.
@@ -470,14 +1246,14 @@ func (s *sriovManager) ResetVFConfig(conf *sriovtypes.NetConf) error {
 
 	if conf.Vlan != nil {
 		if err = s.nLink.LinkSetVfVlanQosProto(pfLink, conf.VFID, conf.OrigVfState.Vlan, conf.OrigVfState.VlanQoS, conf.OrigVfState.VlanProto); err != nil {
-			return fmt.Errorf("failed to set vf %d vlan configuration - id %d, qos %d and proto %d: %v", conf.VFID, conf.OrigVfState.Vlan, conf.OrigVfState.VlanQoS, conf.OrigVfState.VlanProto, err)
+			return netlinkError("failed to set vf %d vlan configuration - id %d, qos %d and proto %d: %v", conf.VFID, conf.OrigVfState.Vlan, conf.OrigVfState.VlanQoS, conf.OrigVfState.VlanProto, err)
 		}
 	}
 
 	// Restore spoofchk
 	if conf.SpoofChk != "" {
 		if err = s.nLink.LinkSetVfSpoofchk(pfLink, conf.VFID, conf.OrigVfState.SpoofChk); err != nil {
-			return fmt.Errorf("failed to restore spoofchk for vf %d: %v", conf.VFID, err)
+			return netlinkError("failed to restore spoofchk for vf %d: %v", conf.VFID, err)
 		}
 	}
 
@@ -485,21 +1261,21 @@ func (s *sriovManager) ResetVFConfig(conf *sriovtypes.NetConf) error {
 	if conf.OrigVfState.AdminMAC != "" {
 		// when we restore the original hardware mac address we may get a device or resource busy. so we introduce retry
 		if err := utils.SetVFHardwareMAC(s.nLink, conf.Master, conf.VFID, conf.OrigVfState.AdminMAC); err != nil {
-			return fmt.Errorf("failed to restore original administrative MAC address %s: %v", conf.OrigVfState.AdminMAC, err)
+			return netlinkError("failed to restore original administrative MAC address %s: %v", conf.OrigVfState.AdminMAC, err)
 		}
 	}
 
 	// Restore VF trust
 	if conf.Trust != "" {
 		if err = s.nLink.LinkSetVfTrust(pfLink, conf.VFID, conf.OrigVfState.Trust); err != nil {
-			return fmt.Errorf("failed to set trust for vf %d: %v", conf.VFID, err)
+			return netlinkError("failed to set trust for vf %d: %v", conf.VFID, err)
 		}
 	}
 
-	// Restore rate limiting
-	if conf.MinTxRate != nil || conf.MaxTxRate != nil {
+	// Restore rate limiting, a tc rate limiter goes away with the pod netdevice configuration
+	if (conf.MinTxRate != nil || conf.MaxTxRate != nil) && conf.TxRateLimiter != sriovtypes.TxRateModeSoftware {
 		if err = s.nLink.LinkSetVfRate(pfLink, conf.VFID, conf.OrigVfState.MinTxRate, conf.OrigVfState.MaxTxRate); err != nil {
-			return fmt.Errorf("failed to disable rate limiting for vf %d %v", conf.VFID, err)
+			return netlinkError("failed to disable rate limiting for vf %d %v", conf.VFID, err)
 		}
 	}
 
@@ -508,9 +1284,211 @@ func (s *sriovManager) ResetVFConfig(conf *sriovtypes.NetConf) error {
 		// Reset only when link_state was explicitly specified, to  accommodate for drivers / NICs
 		// that don't support the netlink command (e.g. igb driver)
 		if err = s.nLink.LinkSetVfState(pfLink, conf.VFID, conf.OrigVfState.LinkState); err != nil {
-			return fmt.Errorf("failed to set link state to auto for vf %d: %v", conf.VFID, err)
+			return netlinkError("failed to set link state to auto for vf %d: %v", conf.VFID, err)
+		}
+	}
+
+	// Restore node and port GUID
+	if conf.GUID != "" {
+		if err = restoreVfGUID(pfLink, conf.VFID, conf.OrigVfState.NodeGUID, s.nLink.LinkSetVfNodeGUID); err != nil {
+			return netlinkError("failed to restore node GUID for vf %d: %v", conf.VFID, err)
+		}
+		if err = restoreVfGUID(pfLink, conf.VFID, conf.OrigVfState.PortGUID, s.nLink.LinkSetVfPortGUID); err != nil {
+			return netlinkError("failed to restore port GUID for vf %d: %v", conf.VFID, err)
+		}
+	}
+
+	// Remove VLAN trunk
+	if conf.VlanTrunk != "" {
+		if err = s.utils.SetVFVlanTrunk(conf.Master, conf.VFID, conf.VlanTrunk, false); err != nil {
+			return fmt.Errorf("failed to remove VLAN trunk %s of vf %d: %v", conf.VlanTrunk, conf.VFID, err)
 		}
 	}
 
 	return nil
 }
+
+// restoreVfGUID sets a cached original GUID back on a VF, if one was cached
+func restoreVfGUID(pfLink netlink.Link, vfID int, origGUID string,
+	setGUID func(netlink.Link, int, net.HardwareAddr) error) error {
+	if origGUID == "" {
+		return nil
+	}
+	guid, err := parseGUID(origGUID)
+	if err != nil {
+		return err
+	}
+	return setGUID(pfLink, vfID, guid)
+}
+
+// parseGUID parses an InfiniBand GUID in the 8 byte colon separated form, e.g. "00:11:22:33:44:55:66:77"
+func parseGUID(guid string) (net.HardwareAddr, error) {
+	hwAddr, err := net.ParseMAC(guid)
+	if err != nil || len(hwAddr) != 8 {
+		return nil, invalidConfError("invalid GUID %q: must be 8 colon separated bytes", guid)
+	}
+	return hwAddr, nil
+}
+
+// CheckVFConfig compares the VF settings applied by ApplyVFConfig with the current state of the VF on the PF.
+// It returns a description of every setting that drifted from the NetConf.
+func (s *sriovManager) CheckVFConfig(conf *sriovtypes.NetConf) ([]string, error) {
+	// nothing was configured on the PF for a Scalable Function
+	if conf.IsSF() {
+		return nil, nil
+	}
+
+	pfLink, err := s.nLink.LinkByName(conf.Master)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup master %q: %v", conf.Master, err)
+	}
+
+	vfInfo := getVfInfo(pfLink, conf.VFID)
+	if vfInfo == nil {
+		return nil, fmt.Errorf("failed to find vf %d", conf.VFID)
+	}
+
+	var drifted []string
+	checkField := func(field string, expected, actual interface{}) {
+		if expected != actual {
+			drifted = append(drifted, fmt.Sprintf("%s: expected %v, found %v", field, expected, actual))
+		}
+	}
+
+	if conf.Vlan != nil {
+		checkField("vlan", *conf.Vlan, vfInfo.Vlan)
+		if conf.VlanQoS != nil {
+			checkField("vlanQoS", *conf.VlanQoS, vfInfo.Qos)
+		}
+		// Kernels that do not report the VLAN protocol leave it unset, which means 802.1q
+		vlanProto := vfInfo.VlanProto
+		if vlanProto == 0 {
+			vlanProto = sriovtypes.VlanProtoInt[sriovtypes.Proto8021q]
+		}
+		if conf.VlanProto != nil {
+			checkField("vlanProto", sriovtypes.VlanProtoInt[*conf.VlanProto], vlanProto)
+		}
+	}
+
+	if conf.MAC != "" {
+		checkField("mac", conf.MAC, vfInfo.Mac.String())
+	}
+
+	// a tc rate limiter is not visible in the VF configuration of the PF
+	if conf.TxRateLimiter != sriovtypes.TxRateModeSoftware {
+		if conf.MinTxRate != nil {
+			checkField("min_tx_rate", *conf.MinTxRate, int(vfInfo.MinTxRate))
+		}
+
+		if conf.MaxTxRate != nil {
+			checkField("max_tx_rate", *conf.MaxTxRate, int(vfInfo.MaxTxRate))
+		}
+	}
+
+	if conf.SpoofChk != "" {
+		checkField("spoofchk", conf.SpoofChk == "on", vfInfo.Spoofchk)
+	}
+
+	if conf.Trust != "" {
+		checkField("trust", conf.Trust == "on", vfInfo.Trust != 0)
+	}
+
+	if conf.LinkState != "" {
+		state, _ := vfLinkState(conf.LinkState)
+		checkField("link_state", state, vfInfo.LinkState)
+	}
+
+	return drifted, nil
+}
+
+// CheckVF verifies that the pod interface described by podIf exists in netns with the expected MAC address and MTU.
+// It returns a description of every attribute that drifted from podIf.
+func (s *sriovManager) CheckVF(podIf *current.Interface, netns ns.NetNS) ([]string, error) {
+	var drifted []string
+	err := netns.Do(func(_ ns.NetNS) error {
+		linkObj, err := s.nLink.LinkByName(podIf.Name)
+		if err != nil {
+			drifted = append(drifted, fmt.Sprintf("name: interface %s not found: %v", podIf.Name, err))
+			return nil
+		}
+
+		if podIf.Mac != "" && linkObj.Attrs().HardwareAddr.String() != podIf.Mac {
+			drifted = append(drifted, fmt.Sprintf("mac: expected %s, found %s", podIf.Mac, linkObj.Attrs().HardwareAddr.String()))
+		}
+
+		if podIf.Mtu != 0 && linkObj.Attrs().MTU != podIf.Mtu {
+			drifted = append(drifted, fmt.Sprintf("mtu: expected %d, found %d", podIf.Mtu, linkObj.Attrs().MTU))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check interface %s in container namespace: %v", podIf.Name, err)
+	}
+
+	return drifted, nil
+}
+
+// RestoreHostIFName renames a VF netdev that came back to the init netns under its pod interface name,
+// e.g. because the pod netns was destroyed without a CNI DEL, to its original host interface name.
+func (s *sriovManager) RestoreHostIFName(conf *sriovtypes.NetConf) error {
+	if conf.OrigVfState.HostIFName == "" {
+		return nil
+	}
+
+	var linkNames []string
+	var err error
+	if conf.IsSF() {
+		var linkName string
+		if linkName, err = s.utils.GetSFLinkName(conf.DeviceID); err == nil {
+			linkNames = []string{linkName}
+		}
+	} else {
+		linkNames, err = s.utils.GetVFLinkNamesFromVFID(conf.Master, conf.VFID)
+	}
+	if err != nil || len(linkNames) == 0 {
+		logging.Debug("VF netdevice not found in init netns",
+			"func", "RestoreHostIFName",
+			"conf.Master", conf.Master,
+			"conf.VFID", conf.VFID)
+		return nil
+	}
+
+	if linkNames[0] == conf.OrigVfState.HostIFName {
+		return nil
+	}
+
+	linkObj, err := s.nLink.LinkByName(linkNames[0])
+	if err != nil {
+		return fmt.Errorf("failed to get netlink device with name %s: %q", linkNames[0], err)
+	}
+
+	logging.Debug("Restore VF device host name",
+		"func", "RestoreHostIFName",
+		"linkObj", linkObj,
+		"conf.OrigVfState.HostIFName", conf.OrigVfState.HostIFName)
+	if err = s.nLink.LinkSetDown(linkObj); err != nil {
+		return fmt.Errorf("failed to set link %s down: %q", linkNames[0], err)
+	}
+
+	if err = s.nLink.LinkSetName(linkObj, conf.OrigVfState.HostIFName); err != nil {
+		return fmt.Errorf("failed to rename link %s to host name %s: %q", linkNames[0], conf.OrigVfState.HostIFName, err)
+	}
+
+	return nil
+}
+
+// pfNotFoundError returns a CNI error for a PF netdevice that can not be found
+func pfNotFoundError(pfName string, err error) error {
+	return types.NewError(sriovtypes.ErrPfNotFound, fmt.Sprintf("failed to lookup master %q", pfName), err.Error())
+}
+
+// netlinkError returns a CNI error for a failed netlink operation on the VF
+func netlinkError(format string, a ...interface{}) error {
+	return types.NewError(sriovtypes.ErrNetlinkFailure, fmt.Sprintf(format, a...), "")
+}
+
+// invalidConfError returns a CNI error for a VF configuration that can not be applied as requested
+func invalidConfError(format string, a ...interface{}) error {
+	return types.NewError(types.ErrInvalidNetworkConfig, fmt.Sprintf(format, a...), "")
+}
@@ -8,10 +8,14 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	cnitypes "github.com/containernetworking/cni/pkg/types"
+	current "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/containernetworking/plugins/pkg/ns"
 	"github.com/containernetworking/plugins/pkg/testutils"
+	"github.com/safchain/ethtool"
 	"github.com/stretchr/testify/mock"
 	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
 
 	"github.com/k8snetworkplumbingwg/sriov-cni/pkg/sriov/mocks"
 	sriovtypes "github.com/k8snetworkplumbingwg/sriov-cni/pkg/types"
@@ -73,6 +77,7 @@ var _ = Describe("Sriov", func() {
 			mocked.On("LinkSetUp", fakeLink).Return(nil)
 			mockedPciUtils.On("EnableArpAndNdiscNotify", mock.AnythingOfType("string")).Return(nil)
 			mockedPciUtils.On("EnableOptimisticDad", mock.AnythingOfType("string")).Return(nil)
+			mockedPciUtils.On("GetVFRepresentor", netconf.Master, netconf.VFID).Return("", nil)
 			sm := sriovManager{nLink: mocked, utils: mockedPciUtils}
 			err = sm.SetupVF(netconf, podifName, targetNetNS)
 			Expect(err).NotTo(HaveOccurred())
@@ -116,6 +121,7 @@ var _ = Describe("Sriov", func() {
 			mockedPciUtils.On("EnableArpAndNdiscNotify", mock.AnythingOfType("string")).Return(nil)
 			mocked.On("LinkSetUp", net1Link).Return(nil)
 			mockedPciUtils.On("EnableOptimisticDad", mock.AnythingOfType("string")).Return(nil)
+			mockedPciUtils.On("GetVFRepresentor", netconf.Master, netconf.VFID).Return("", nil)
 			sm := sriovManager{nLink: mocked, utils: mockedPciUtils}
 			err = sm.SetupVF(netconf, podifName, targetNetNS)
 			Expect(err).NotTo(HaveOccurred())
@@ -202,6 +208,7 @@ var _ = Describe("Sriov", func() {
 			mocked.On("LinkSetUp", net1Link).Return(nil)
 			mockedPciUtils.On("EnableArpAndNdiscNotify", mock.AnythingOfType("string")).Return(nil)
 			mockedPciUtils.On("EnableOptimisticDad", mock.AnythingOfType("string")).Return(nil)
+			mockedPciUtils.On("GetVFRepresentor", netconf.Master, netconf.VFID).Return("", nil)
 			sm := sriovManager{nLink: mocked, utils: mockedPciUtils}
 			err = sm.SetupVF(netconf, podifName, targetNetNS)
 			Expect(err).NotTo(HaveOccurred())
@@ -247,6 +254,7 @@ var _ = Describe("Sriov", func() {
 			mocked.On("LinkSetUp", net1Link).Return(nil)
 			mockedPciUtils.On("EnableArpAndNdiscNotify", mock.AnythingOfType("string")).Return(nil)
 			mockedPciUtils.On("EnableOptimisticDad", mock.AnythingOfType("string")).Return(nil)
+			mockedPciUtils.On("GetVFRepresentor", netconf.Master, netconf.VFID).Return("", nil)
 			sm := sriovManager{nLink: mocked, utils: mockedPciUtils}
 			err = sm.SetupVF(netconf, podifName, targetNetNS)
 			Expect(err).NotTo(HaveOccurred())
@@ -254,6 +262,281 @@ var _ = Describe("Sriov", func() {
 			mocked.AssertExpectations(t)
 		})
 
+		It("Sets and returns the requested MTU", func() {
+			targetNetNS, err := testutils.NewNS()
+			defer func() {
+				if targetNetNS != nil {
+					targetNetNS.Close()
+				}
+			}()
+			Expect(err).NotTo(HaveOccurred())
+			mocked := &mocks_utils.NetlinkManager{}
+			mockedPciUtils := &mocks.PciUtils{}
+			fakeMac, err := net.ParseMAC("6e:16:06:0e:b7:e9")
+			Expect(err).NotTo(HaveOccurred())
+
+			requestedMTU := 9000
+			netconf.RequestedMTU = &requestedMTU
+
+			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{
+				Index:        1000,
+				Name:         "dummylink",
+				HardwareAddr: fakeMac,
+				MTU:          1500,
+			}}
+
+			net1Link := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{
+				Index:        1000,
+				Name:         "net1",
+				HardwareAddr: fakeMac,
+				MTU:          1500,
+			}}
+
+			mocked.On("LinkByName", "enp175s6").Return(fakeLink, nil)
+			mocked.On("LinkByName", "net1").Return(net1Link, nil)
+			mocked.On("LinkSetName", fakeLink, mock.Anything).Return(nil)
+			mocked.On("LinkSetNsFd", fakeLink, mock.AnythingOfType("int")).Return(nil)
+			mocked.On("LinkSetMTU", net1Link, 9000).Return(nil)
+			mocked.On("LinkSetUp", net1Link).Return(nil)
+			mockedPciUtils.On("EnableArpAndNdiscNotify", mock.AnythingOfType("string")).Return(nil)
+			mockedPciUtils.On("EnableOptimisticDad", mock.AnythingOfType("string")).Return(nil)
+			mockedPciUtils.On("GetVFRepresentor", netconf.Master, netconf.VFID).Return("", nil)
+			sm := sriovManager{nLink: mocked, utils: mockedPciUtils}
+			err = sm.SetupVF(netconf, podifName, targetNetNS)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*netconf.MTU).To(Equal(9000))
+			mocked.AssertExpectations(t)
+		})
+
+		DescribeTable("Moving the RDMA device",
+			func(mode string, moved bool) {
+				targetNetNS, err := testutils.NewNS()
+				defer func() {
+					if targetNetNS != nil {
+						targetNetNS.Close()
+					}
+				}()
+				Expect(err).NotTo(HaveOccurred())
+				mocked := &mocks_utils.NetlinkManager{}
+				mockedPciUtils := &mocks.PciUtils{}
+				fakeMac, err := net.ParseMAC("6e:16:06:0e:b7:e9")
+				Expect(err).NotTo(HaveOccurred())
+
+				netconf.RdmaDevice = "mlx5_0"
+				fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "dummylink", HardwareAddr: fakeMac}}
+				net1Link := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "net1", HardwareAddr: fakeMac}}
+				rdmaLink := &netlink.RdmaLink{Attrs: netlink.RdmaLinkAttrs{Name: "mlx5_0"}}
+
+				mocked.On("LinkByName", "enp175s6").Return(fakeLink, nil)
+				mocked.On("LinkByName", "net1").Return(net1Link, nil)
+				mocked.On("LinkSetName", fakeLink, mock.Anything).Return(nil)
+				mocked.On("LinkSetNsFd", fakeLink, mock.AnythingOfType("int")).Return(nil)
+				mocked.On("LinkSetUp", net1Link).Return(nil)
+				mocked.On("RdmaSystemGetNetnsMode").Return(mode, nil)
+				if moved {
+					mocked.On("RdmaLinkByName", "mlx5_0").Return(rdmaLink, nil)
+					mocked.On("RdmaLinkSetNsFd", rdmaLink, uint32(targetNetNS.Fd())).Return(nil)
+				}
+				mockedPciUtils.On("EnableArpAndNdiscNotify", mock.AnythingOfType("string")).Return(nil)
+				mockedPciUtils.On("EnableOptimisticDad", mock.AnythingOfType("string")).Return(nil)
+				mockedPciUtils.On("GetVFRepresentor", netconf.Master, netconf.VFID).Return("", nil)
+				sm := sriovManager{nLink: mocked, utils: mockedPciUtils}
+				err = sm.SetupVF(netconf, podifName, targetNetNS)
+				Expect(err).NotTo(HaveOccurred())
+				mocked.AssertExpectations(t)
+			},
+			Entry("in exclusive mode", "exclusive", true),
+			Entry("not in shared mode", "shared", false),
+		)
+
+		It("Renames the VF representor and brings it up", func() {
+			targetNetNS, err := testutils.NewNS()
+			defer func() {
+				if targetNetNS != nil {
+					targetNetNS.Close()
+				}
+			}()
+			Expect(err).NotTo(HaveOccurred())
+			mocked := &mocks_utils.NetlinkManager{}
+			mockedPciUtils := &mocks.PciUtils{}
+			fakeMac, err := net.ParseMAC("6e:16:06:0e:b7:e9")
+			Expect(err).NotTo(HaveOccurred())
+
+			netconf.ContainerID = "4e5a8b2c1d3f6e7a9b0c"
+			netconf.RenameRepresentor = true
+			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "dummylink", HardwareAddr: fakeMac}}
+			repLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1001, Name: "enp175s0f1_0"}}
+
+			mocked.On("LinkByName", "enp175s0f1_0").Return(repLink, nil)
+			mocked.On("LinkByName", mock.AnythingOfType("string")).Return(fakeLink, nil)
+			mocked.On("LinkSetName", fakeLink, mock.Anything).Return(nil)
+			mocked.On("LinkSetNsFd", fakeLink, mock.AnythingOfType("int")).Return(nil)
+			mocked.On("LinkSetUp", fakeLink).Return(nil)
+			mocked.On("LinkSetDown", repLink).Return(nil)
+			mocked.On("LinkSetName", repLink, "4e5a8b2c1d_net1").Return(nil)
+			mocked.On("LinkSetUp", repLink).Return(nil)
+			mockedPciUtils.On("EnableArpAndNdiscNotify", mock.AnythingOfType("string")).Return(nil)
+			mockedPciUtils.On("EnableOptimisticDad", mock.AnythingOfType("string")).Return(nil)
+			mockedPciUtils.On("GetVFRepresentor", netconf.Master, netconf.VFID).Return("enp175s0f1_0", nil)
+			sm := sriovManager{nLink: mocked, utils: mockedPciUtils}
+			err = sm.SetupVF(netconf, podifName, targetNetNS)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netconf.Representor).To(Equal("4e5a8b2c1d_net1"))
+			Expect(netconf.OrigVfState.RepresentorName).To(Equal("enp175s0f1_0"))
+			Expect(netconf.OrigVfState.RepresentorUp).To(BeFalse())
+			mocked.AssertExpectations(t)
+		})
+
+		It("Sets allmulticast and promiscuous mode in the pod netns", func() {
+			targetNetNS, err := testutils.NewNS()
+			defer func() {
+				if targetNetNS != nil {
+					targetNetNS.Close()
+				}
+			}()
+			Expect(err).NotTo(HaveOccurred())
+			mocked := &mocks_utils.NetlinkManager{}
+			mockedPciUtils := &mocks.PciUtils{}
+			fakeMac, err := net.ParseMAC("6e:16:06:0e:b7:e9")
+			Expect(err).NotTo(HaveOccurred())
+
+			netconf.AllMulti = "on"
+			netconf.Promisc = "on"
+			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "dummylink", HardwareAddr: fakeMac}}
+
+			mocked.On("LinkByName", mock.AnythingOfType("string")).Return(fakeLink, nil)
+			mocked.On("LinkSetName", fakeLink, mock.Anything).Return(nil)
+			mocked.On("LinkSetNsFd", fakeLink, mock.AnythingOfType("int")).Return(nil)
+			mocked.On("LinkSetUp", fakeLink).Return(nil)
+			mocked.On("LinkSetAllmulticastOn", fakeLink).Return(nil)
+			mocked.On("SetPromiscOn", fakeLink).Return(nil)
+			mockedPciUtils.On("EnableArpAndNdiscNotify", mock.AnythingOfType("string")).Return(nil)
+			mockedPciUtils.On("EnableOptimisticDad", mock.AnythingOfType("string")).Return(nil)
+			mockedPciUtils.On("GetVFRepresentor", netconf.Master, netconf.VFID).Return("", nil)
+			sm := sriovManager{nLink: mocked, utils: mockedPciUtils}
+			err = sm.SetupVF(netconf, podifName, targetNetNS)
+			Expect(err).NotTo(HaveOccurred())
+			mocked.AssertExpectations(t)
+		})
+
+		It("Applies the ethtool configuration and saves the original settings", func() {
+			targetNetNS, err := testutils.NewNS()
+			defer func() {
+				if targetNetNS != nil {
+					targetNetNS.Close()
+				}
+			}()
+			Expect(err).NotTo(HaveOccurred())
+			mocked := &mocks_utils.NetlinkManager{}
+			mockedEthtool := &mocks_utils.EthtoolManager{}
+			mockedPciUtils := &mocks.PciUtils{}
+			fakeMac, err := net.ParseMAC("6e:16:06:0e:b7:e9")
+			Expect(err).NotTo(HaveOccurred())
+
+			rxRing, combined := uint32(4096), uint32(8)
+			netconf.Ethtool = &sriovtypes.EthtoolConf{
+				Features: map[string]bool{"rx-gro": false},
+				Rings:    &sriovtypes.EthtoolRings{Rx: &rxRing},
+				Channels: &sriovtypes.EthtoolChannels{Combined: &combined},
+			}
+			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "dummylink", HardwareAddr: fakeMac}}
+
+			mocked.On("LinkByName", mock.AnythingOfType("string")).Return(fakeLink, nil)
+			mocked.On("LinkSetName", fakeLink, mock.Anything).Return(nil)
+			mocked.On("LinkSetNsFd", fakeLink, mock.AnythingOfType("int")).Return(nil)
+			mocked.On("LinkSetUp", fakeLink).Return(nil)
+			mockedEthtool.On("Features", podifName).Return(map[string]bool{"rx-gro": true, "tx-checksumming": true}, nil)
+			mockedEthtool.On("Change", podifName, map[string]bool{"rx-gro": false}).Return(nil)
+			mockedEthtool.On("GetRing", podifName).Return(ethtool.Ring{RxPending: 1024, TxPending: 1024}, nil)
+			mockedEthtool.On("SetRing", podifName, ethtool.Ring{RxPending: 4096, TxPending: 1024}).Return(ethtool.Ring{}, nil)
+			mockedEthtool.On("GetChannels", podifName).Return(ethtool.Channels{CombinedCount: 4}, nil)
+			mockedEthtool.On("SetChannels", podifName, ethtool.Channels{CombinedCount: 8}).Return(ethtool.Channels{}, nil)
+			mockedPciUtils.On("EnableArpAndNdiscNotify", mock.AnythingOfType("string")).Return(nil)
+			mockedPciUtils.On("EnableOptimisticDad", mock.AnythingOfType("string")).Return(nil)
+			mockedPciUtils.On("GetVFRepresentor", netconf.Master, netconf.VFID).Return("", nil)
+			sm := sriovManager{nLink: mocked, ethtool: mockedEthtool, utils: mockedPciUtils}
+			err = sm.SetupVF(netconf, podifName, targetNetNS)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netconf.OrigVfState.Ethtool.Features).To(Equal(map[string]bool{"rx-gro": true}))
+			Expect(*netconf.OrigVfState.Ethtool.Rings.Rx).To(BeEquivalentTo(1024))
+			Expect(netconf.OrigVfState.Ethtool.Rings.Tx).To(BeNil())
+			Expect(*netconf.OrigVfState.Ethtool.Channels.Combined).To(BeEquivalentTo(4))
+			mocked.AssertExpectations(t)
+			mockedEthtool.AssertExpectations(t)
+		})
+
+		It("Installs a tc rate limiter for software tx rate limiting", func() {
+			targetNetNS, err := testutils.NewNS()
+			defer func() {
+				if targetNetNS != nil {
+					targetNetNS.Close()
+				}
+			}()
+			Expect(err).NotTo(HaveOccurred())
+			mocked := &mocks_utils.NetlinkManager{}
+			mockedPciUtils := &mocks.PciUtils{}
+			fakeMac, err := net.ParseMAC("6e:16:06:0e:b7:e9")
+			Expect(err).NotTo(HaveOccurred())
+
+			maxTxRate := 1000
+			netconf.MaxTxRate = &maxTxRate
+			netconf.TxRateLimiter = sriovtypes.TxRateModeSoftware
+			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "dummylink", HardwareAddr: fakeMac}}
+
+			mocked.On("LinkByName", mock.AnythingOfType("string")).Return(fakeLink, nil)
+			mocked.On("LinkSetName", fakeLink, mock.Anything).Return(nil)
+			mocked.On("LinkSetNsFd", fakeLink, mock.AnythingOfType("int")).Return(nil)
+			mocked.On("LinkSetUp", fakeLink).Return(nil)
+			mocked.On("QdiscReplace", mock.MatchedBy(func(qdisc *netlink.Tbf) bool {
+				return qdisc.LinkIndex == 1000 && qdisc.Parent == netlink.HANDLE_ROOT && qdisc.Rate == 125000000
+			})).Return(nil)
+			mockedPciUtils.On("EnableArpAndNdiscNotify", mock.AnythingOfType("string")).Return(nil)
+			mockedPciUtils.On("EnableOptimisticDad", mock.AnythingOfType("string")).Return(nil)
+			mockedPciUtils.On("GetVFRepresentor", netconf.Master, netconf.VFID).Return("", nil)
+			sm := sriovManager{nLink: mocked, utils: mockedPciUtils}
+			err = sm.SetupVF(netconf, podifName, targetNetNS)
+			Expect(err).NotTo(HaveOccurred())
+			mocked.AssertExpectations(t)
+		})
+
+		It("Creates the VLAN sub-interfaces", func() {
+			targetNetNS, err := testutils.NewNS()
+			defer func() {
+				if targetNetNS != nil {
+					targetNetNS.Close()
+				}
+			}()
+			Expect(err).NotTo(HaveOccurred())
+			mocked := &mocks_utils.NetlinkManager{}
+			mockedPciUtils := &mocks.PciUtils{}
+			fakeMac, err := net.ParseMAC("6e:16:06:0e:b7:e9")
+			Expect(err).NotTo(HaveOccurred())
+
+			netconf.Vlans = []sriovtypes.VlanConf{{ID: 100}, {ID: 200, Name: "storage"}}
+			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "dummylink", HardwareAddr: fakeMac}}
+			isVlan := func(name string, id int) any {
+				return mock.MatchedBy(func(link *netlink.Vlan) bool {
+					return link.Name == name && link.VlanId == id && link.ParentIndex == 1000
+				})
+			}
+
+			mocked.On("LinkByName", mock.AnythingOfType("string")).Return(fakeLink, nil)
+			mocked.On("LinkSetName", fakeLink, mock.Anything).Return(nil)
+			mocked.On("LinkSetNsFd", fakeLink, mock.AnythingOfType("int")).Return(nil)
+			mocked.On("LinkSetUp", fakeLink).Return(nil)
+			mocked.On("LinkAdd", isVlan("net1.100", 100)).Return(nil)
+			mocked.On("LinkSetUp", isVlan("net1.100", 100)).Return(nil)
+			mocked.On("LinkAdd", isVlan("storage", 200)).Return(nil)
+			mocked.On("LinkSetUp", isVlan("storage", 200)).Return(nil)
+			mockedPciUtils.On("EnableArpAndNdiscNotify", mock.AnythingOfType("string")).Return(nil)
+			mockedPciUtils.On("EnableOptimisticDad", mock.AnythingOfType("string")).Return(nil)
+			mockedPciUtils.On("GetVFRepresentor", netconf.Master, netconf.VFID).Return("", nil)
+			sm := sriovManager{nLink: mocked, utils: mockedPciUtils}
+			err = sm.SetupVF(netconf, podifName, targetNetNS)
+			Expect(err).NotTo(HaveOccurred())
+			mocked.AssertExpectations(t)
+		})
+
 		It("Bring IF up in Pod netns fails", func() {
 			targetNetNS, err := testutils.NewNS()
 			defer func() {
@@ -389,6 +672,20 @@ var _ = Describe("Sriov", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 
+		It("should not configure the VF of a Scalable Function", func() {
+			vlan := 100
+			sfNum := 88
+			netconf.SFNum = &sfNum
+			netconf.VFID = -1
+			netconf.Vlan = &vlan
+			netconf.MAC = "e4:11:22:33:44:55"
+			mocked.On("LinkByName", mock.AnythingOfType("string")).Return(fakeLink, nil)
+			sm := sriovManager{nLink: mocked}
+			err := sm.ApplyVFConfig(netconf)
+			Expect(err).NotTo(HaveOccurred())
+			mocked.AssertExpectations(t)
+		})
+
 		It("should call functions to configure the VF when config has optional parameters", func() {
 			vlan := 100
 			netconf.Vlan = &vlan
@@ -421,79 +718,332 @@ var _ = Describe("Sriov", func() {
 			err = sm.ApplyVFConfig(netconf)
 			Expect(err).NotTo(HaveOccurred())
 		})
-	})
-	Context("Checking ReleaseVF function", func() {
-		var (
-			podifName string
-			netconf   *sriovtypes.NetConf
-		)
 
-		BeforeEach(func() {
-			podifName = "net1"
-			netconf = &sriovtypes.NetConf{SriovNetConf: sriovtypes.SriovNetConf{
-				Master:   "enp175s0f1",
-				DeviceID: "0000:af:06.0",
-				VFID:     0,
-				OrigVfState: sriovtypes.VfState{
-					HostIFName:   "enp175s6",
-					EffectiveMAC: "6e:16:06:0e:b7:e9",
-					MTU:          1500,
-				}},
-			}
-		})
-		It("Assuming existing interface", func() {
-			targetNetNS, err := testutils.NewNS()
-			defer func() {
-				if targetNetNS != nil {
-					targetNetNS.Close()
-				}
-			}()
-			Expect(err).NotTo(HaveOccurred())
-			mocked := &mocks_utils.NetlinkManager{}
-			fakeMac, err := net.ParseMAC("6e:16:06:0e:b7:e9")
+		It("should set the VLAN trunk when config has a vlanTrunk", func() {
+			netconf.VlanTrunk = "100-200,300"
+			mockedPciUtils := &mocks.PciUtils{}
+
+			mocked.On("LinkByName", mock.AnythingOfType("string")).Return(fakeLink, nil)
+			mockedPciUtils.On("SetVFVlanTrunk", netconf.Master, netconf.VFID, "100-200,300", true).Return(nil)
+
+			sm := sriovManager{nLink: mocked, utils: mockedPciUtils}
+			err := sm.ApplyVFConfig(netconf)
 			Expect(err).NotTo(HaveOccurred())
+			mockedPciUtils.AssertExpectations(t)
+		})
 
-			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "dummylink", HardwareAddr: fakeMac}}
+		It("should fall back to a tc rate limiter when the PF does not support VF rate limiting", func() {
+			maxTxRate := 4000
+			netconf.MaxTxRate = &maxTxRate
 
-			hostLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{
-				Index:        1000,
-				Name:         "enp175s6",
-				HardwareAddr: fakeMac,
-			}}
+			mocked.On("LinkByName", mock.AnythingOfType("string")).Return(fakeLink, nil)
+			mocked.On("LinkSetVfRate", fakeLink, netconf.VFID, 0, maxTxRate).Return(unix.EOPNOTSUPP)
 
-			mocked.On("LinkByName", podifName).Return(fakeLink, nil)
-			mocked.On("LinkByName", netconf.OrigVfState.HostIFName).Return(hostLink, nil)
-			mocked.On("LinkSetDown", fakeLink).Return(nil)
-			mocked.On("LinkSetName", fakeLink, netconf.OrigVfState.HostIFName).Return(nil)
-			mocked.On("LinkSetHardwareAddr", hostLink, fakeMac).Return(nil)
-			mocked.On("LinkSetNsFd", fakeLink, mock.AnythingOfType("int")).Return(nil)
-			mocked.On("LinkSetMTU", fakeLink, 1500).Return(nil)
 			sm := sriovManager{nLink: mocked}
-			err = sm.ReleaseVF(netconf, podifName, targetNetNS)
+			err := sm.ApplyVFConfig(netconf)
 			Expect(err).NotTo(HaveOccurred())
+			Expect(netconf.TxRateLimiter).To(Equal(sriovtypes.TxRateModeSoftware))
 			mocked.AssertExpectations(t)
 		})
-	})
-	Context("Checking ReleaseVF function - restore config", func() {
-		var (
-			podifName string
-			netconf   *sriovtypes.NetConf
-		)
 
-		BeforeEach(func() {
-			podifName = "net1"
-			netconf = &sriovtypes.NetConf{SriovNetConf: sriovtypes.SriovNetConf{
-				Master:   "enp175s0f1",
-				DeviceID: "0000:af:06.0",
-				VFID:     0,
-				OrigVfState: sriovtypes.VfState{
-					HostIFName:   "enp175s6",
-					EffectiveMAC: "c6:c8:7f:1f:21:90",
-				}},
+		It("should not fall back to a tc rate limiter for a min tx rate", func() {
+			maxTxRate := 4000
+			minTxRate := 1000
+			netconf.MaxTxRate = &maxTxRate
+			netconf.MinTxRate = &minTxRate
+
+			mocked.On("LinkByName", mock.AnythingOfType("string")).Return(fakeLink, nil)
+			mocked.On("LinkSetVfRate", fakeLink, netconf.VFID, minTxRate, maxTxRate).Return(unix.EOPNOTSUPP)
+
+			sm := sriovManager{nLink: mocked}
+			err := sm.ApplyVFConfig(netconf)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(*cnitypes.Error).Code).To(Equal(sriovtypes.ErrNetlinkFailure))
+		})
+
+		It("should not use the PF rate limiter in software tx rate mode", func() {
+			maxTxRate := 4000
+			netconf.MaxTxRate = &maxTxRate
+			netconf.TxRateMode = sriovtypes.TxRateModeSoftware
+
+			mocked.On("LinkByName", mock.AnythingOfType("string")).Return(fakeLink, nil)
+
+			sm := sriovManager{nLink: mocked}
+			err := sm.ApplyVFConfig(netconf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netconf.TxRateLimiter).To(Equal(sriovtypes.TxRateModeSoftware))
+			mocked.AssertExpectations(t)
+		})
+
+		It("should return a netlink error code when configuring the VF fails", func() {
+			netconf.SpoofChk = "on"
+
+			mocked.On("LinkByName", mock.AnythingOfType("string")).Return(fakeLink, nil)
+			mocked.On("LinkSetVfSpoofchk", fakeLink, netconf.VFID, true).Return(errors.New("operation not supported"))
+
+			sm := sriovManager{nLink: mocked}
+			err := sm.ApplyVFConfig(netconf)
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(BeAssignableToTypeOf(&cnitypes.Error{}))
+			Expect(err.(*cnitypes.Error).Code).To(Equal(sriovtypes.ErrNetlinkFailure))
+		})
+
+		It("should reject a generated MAC address that is set on another VF of the PF", func() {
+			hwaddr, err := net.ParseMAC("02:5e:7a:9c:1b:3d")
+			Expect(err).NotTo(HaveOccurred())
+			netconf.MAC = hwaddr.String()
+			netconf.MACGenerated = true
+			fakeLink.Vfs = []netlink.VfInfo{{ID: 0, Mac: hwaddr}, {ID: 3, Mac: hwaddr}}
+
+			mocked.On("LinkByName", mock.AnythingOfType("string")).Return(fakeLink, nil)
+
+			sm := sriovManager{nLink: mocked}
+			err = sm.ApplyVFConfig(netconf)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(*cnitypes.Error).Code).To(Equal(sriovtypes.ErrMACCollision))
+			Expect(err.Error()).To(ContainSubstring("vf 3"))
+			mocked.AssertNotCalled(t, "LinkSetVfHardwareAddr", mock.Anything, mock.Anything, mock.Anything)
+		})
+
+		It("should not check a requested MAC address against the other VFs of the PF", func() {
+			hwaddr, err := net.ParseMAC("02:5e:7a:9c:1b:3d")
+			Expect(err).NotTo(HaveOccurred())
+			netconf.MAC = hwaddr.String()
+			fakeLink.Vfs = []netlink.VfInfo{{ID: 0, Mac: hwaddr}, {ID: 3, Mac: hwaddr}}
+
+			mocked.On("LinkByName", mock.AnythingOfType("string")).Return(fakeLink, nil)
+			mocked.On("LinkSetVfHardwareAddr", fakeLink, netconf.VFID, hwaddr).Return(nil)
+
+			sm := sriovManager{nLink: mocked}
+			err = sm.ApplyVFConfig(netconf)
+			Expect(err).NotTo(HaveOccurred())
+			mocked.AssertExpectations(t)
+		})
+
+		It("should return a PF not found error code when the PF does not exist", func() {
+			mocked.On("LinkByName", mock.AnythingOfType("string")).Return(nil, errors.New("link not found"))
+
+			sm := sriovManager{nLink: mocked}
+			err := sm.ApplyVFConfig(netconf)
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(BeAssignableToTypeOf(&cnitypes.Error{}))
+			Expect(err.(*cnitypes.Error).Code).To(Equal(sriovtypes.ErrPfNotFound))
+		})
+
+		It("should set node and port GUID when config has a GUID", func() {
+			netconf.GUID = "00:11:22:33:44:55:66:77"
+			guid, err := net.ParseMAC(netconf.GUID)
+			Expect(err).NotTo(HaveOccurred())
+
+			mocked.On("LinkByName", mock.AnythingOfType("string")).Return(fakeLink, nil)
+			mocked.On("LinkSetVfNodeGUID", fakeLink, netconf.VFID, guid).Return(nil)
+			mocked.On("LinkSetVfPortGUID", fakeLink, netconf.VFID, guid).Return(nil)
+
+			sm := sriovManager{nLink: mocked}
+			err = sm.ApplyVFConfig(netconf)
+			Expect(err).NotTo(HaveOccurred())
+			mocked.AssertExpectations(t)
+		})
+
+		It("should fail when config has an invalid GUID", func() {
+			netconf.GUID = "00:11:22:33:44:55"
+
+			mocked.On("LinkByName", mock.AnythingOfType("string")).Return(fakeLink, nil)
+
+			sm := sriovManager{nLink: mocked}
+			err := sm.ApplyVFConfig(netconf)
+			Expect(err).To(HaveOccurred())
+			mocked.AssertExpectations(t)
+		})
+	})
+	Context("Checking ReleaseVF function", func() {
+		var (
+			podifName string
+			netconf   *sriovtypes.NetConf
+		)
+
+		BeforeEach(func() {
+			podifName = "net1"
+			netconf = &sriovtypes.NetConf{SriovNetConf: sriovtypes.SriovNetConf{
+				Master:   "enp175s0f1",
+				DeviceID: "0000:af:06.0",
+				VFID:     0,
+				OrigVfState: sriovtypes.VfState{
+					HostIFName:   "enp175s6",
+					EffectiveMAC: "6e:16:06:0e:b7:e9",
+					MTU:          1500,
+				}},
 			}
 		})
-		It("Should not restore Effective MAC address when EffectiveMAC is not in cached state", func() {
-			netconf.OrigVfState.EffectiveMAC = ""
+		It("Assuming existing interface", func() {
+			targetNetNS, err := testutils.NewNS()
+			defer func() {
+				if targetNetNS != nil {
+					targetNetNS.Close()
+				}
+			}()
+			Expect(err).NotTo(HaveOccurred())
+			mocked := &mocks_utils.NetlinkManager{}
+			fakeMac, err := net.ParseMAC("6e:16:06:0e:b7:e9")
+			Expect(err).NotTo(HaveOccurred())
+
+			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "dummylink", HardwareAddr: fakeMac}}
+
+			hostLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{
+				Index:        1000,
+				Name:         "enp175s6",
+				HardwareAddr: fakeMac,
+			}}
+
+			mocked.On("LinkByName", podifName).Return(fakeLink, nil)
+			mocked.On("LinkByName", netconf.OrigVfState.HostIFName).Return(hostLink, nil)
+			mocked.On("LinkSetDown", fakeLink).Return(nil)
+			mocked.On("LinkSetName", fakeLink, netconf.OrigVfState.HostIFName).Return(nil)
+			mocked.On("LinkSetHardwareAddr", hostLink, fakeMac).Return(nil)
+			mocked.On("LinkSetNsFd", fakeLink, mock.AnythingOfType("int")).Return(nil)
+			mocked.On("LinkSetMTU", fakeLink, 1500).Return(nil)
+			sm := sriovManager{nLink: mocked}
+			err = sm.ReleaseVF(netconf, podifName, targetNetNS)
+			Expect(err).NotTo(HaveOccurred())
+			mocked.AssertExpectations(t)
+		})
+
+		It("Clears allmulticast and promiscuous mode before moving the VF back", func() {
+			targetNetNS, err := testutils.NewNS()
+			defer func() {
+				if targetNetNS != nil {
+					targetNetNS.Close()
+				}
+			}()
+			Expect(err).NotTo(HaveOccurred())
+			mocked := &mocks_utils.NetlinkManager{}
+			fakeMac, err := net.ParseMAC("6e:16:06:0e:b7:e9")
+			Expect(err).NotTo(HaveOccurred())
+
+			netconf.AllMulti = "on"
+			netconf.Promisc = "off"
+			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "dummylink", HardwareAddr: fakeMac}}
+			hostLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "enp175s6", HardwareAddr: fakeMac}}
+
+			mocked.On("LinkByName", podifName).Return(fakeLink, nil)
+			mocked.On("LinkByName", netconf.OrigVfState.HostIFName).Return(hostLink, nil)
+			mocked.On("LinkSetAllmulticastOff", fakeLink).Return(nil)
+			mocked.On("LinkSetDown", fakeLink).Return(nil)
+			mocked.On("LinkSetName", fakeLink, netconf.OrigVfState.HostIFName).Return(nil)
+			mocked.On("LinkSetHardwareAddr", hostLink, fakeMac).Return(nil)
+			mocked.On("LinkSetNsFd", fakeLink, mock.AnythingOfType("int")).Return(nil)
+			mocked.On("LinkSetMTU", fakeLink, 1500).Return(nil)
+			sm := sriovManager{nLink: mocked}
+			err = sm.ReleaseVF(netconf, podifName, targetNetNS)
+			Expect(err).NotTo(HaveOccurred())
+			mocked.AssertExpectations(t)
+			mocked.AssertNotCalled(t, "SetPromiscOff", fakeLink)
+		})
+
+		It("Restores the original ethtool settings", func() {
+			targetNetNS, err := testutils.NewNS()
+			defer func() {
+				if targetNetNS != nil {
+					targetNetNS.Close()
+				}
+			}()
+			Expect(err).NotTo(HaveOccurred())
+			mocked := &mocks_utils.NetlinkManager{}
+			mockedEthtool := &mocks_utils.EthtoolManager{}
+			fakeMac, err := net.ParseMAC("6e:16:06:0e:b7:e9")
+			Expect(err).NotTo(HaveOccurred())
+
+			rxRing := uint32(1024)
+			netconf.OrigVfState.Ethtool = &sriovtypes.EthtoolConf{
+				Features: map[string]bool{"rx-gro": true},
+				Rings:    &sriovtypes.EthtoolRings{Rx: &rxRing},
+			}
+			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "dummylink", HardwareAddr: fakeMac}}
+			hostLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "enp175s6", HardwareAddr: fakeMac}}
+
+			mocked.On("LinkByName", podifName).Return(fakeLink, nil)
+			mocked.On("LinkByName", netconf.OrigVfState.HostIFName).Return(hostLink, nil)
+			mocked.On("LinkSetDown", fakeLink).Return(nil)
+			mocked.On("LinkSetName", fakeLink, netconf.OrigVfState.HostIFName).Return(nil)
+			mocked.On("LinkSetHardwareAddr", hostLink, fakeMac).Return(nil)
+			mocked.On("LinkSetNsFd", fakeLink, mock.AnythingOfType("int")).Return(nil)
+			mocked.On("LinkSetMTU", fakeLink, 1500).Return(nil)
+			mockedEthtool.On("Change", podifName, map[string]bool{"rx-gro": true}).Return(nil)
+			mockedEthtool.On("GetRing", podifName).Return(ethtool.Ring{RxPending: 4096, TxPending: 1024}, nil)
+			mockedEthtool.On("SetRing", podifName, ethtool.Ring{RxPending: 1024, TxPending: 1024}).Return(ethtool.Ring{}, nil)
+			sm := sriovManager{nLink: mocked, ethtool: mockedEthtool}
+			err = sm.ReleaseVF(netconf, podifName, targetNetNS)
+			Expect(err).NotTo(HaveOccurred())
+			mocked.AssertExpectations(t)
+			mockedEthtool.AssertExpectations(t)
+		})
+
+		It("Removes the tc rate limiter", func() {
+			targetNetNS, err := testutils.NewNS()
+			defer func() {
+				if targetNetNS != nil {
+					targetNetNS.Close()
+				}
+			}()
+			Expect(err).NotTo(HaveOccurred())
+			mocked := &mocks_utils.NetlinkManager{}
+			fakeMac, err := net.ParseMAC("6e:16:06:0e:b7:e9")
+			Expect(err).NotTo(HaveOccurred())
+
+			maxTxRate := 1000
+			netconf.MaxTxRate = &maxTxRate
+			netconf.TxRateLimiter = sriovtypes.TxRateModeSoftware
+			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "dummylink", HardwareAddr: fakeMac}}
+			hostLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "enp175s6", HardwareAddr: fakeMac}}
+
+			mocked.On("LinkByName", podifName).Return(fakeLink, nil)
+			mocked.On("LinkByName", netconf.OrigVfState.HostIFName).Return(hostLink, nil)
+			mocked.On("QdiscDel", mock.AnythingOfType("*netlink.Tbf")).Return(nil)
+			mocked.On("LinkSetDown", fakeLink).Return(nil)
+			mocked.On("LinkSetName", fakeLink, netconf.OrigVfState.HostIFName).Return(nil)
+			mocked.On("LinkSetHardwareAddr", hostLink, fakeMac).Return(nil)
+			mocked.On("LinkSetNsFd", fakeLink, mock.AnythingOfType("int")).Return(nil)
+			mocked.On("LinkSetMTU", fakeLink, 1500).Return(nil)
+			sm := sriovManager{nLink: mocked}
+			err = sm.ReleaseVF(netconf, podifName, targetNetNS)
+			Expect(err).NotTo(HaveOccurred())
+			mocked.AssertExpectations(t)
+		})
+
+		It("Deletes the VLAN sub-interfaces", func() {
+			targetNetNS, err := testutils.NewNS()
+			defer func() {
+				if targetNetNS != nil {
+					targetNetNS.Close()
+				}
+			}()
+			Expect(err).NotTo(HaveOccurred())
+			mocked := &mocks_utils.NetlinkManager{}
+			fakeMac, err := net.ParseMAC("6e:16:06:0e:b7:e9")
+			Expect(err).NotTo(HaveOccurred())
+
+			netconf.Vlans = []sriovtypes.VlanConf{{ID: 100}, {ID: 200}}
+			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "dummylink", HardwareAddr: fakeMac}}
+			hostLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "enp175s6", HardwareAddr: fakeMac}}
+			vlanLink := &netlink.Vlan{LinkAttrs: netlink.LinkAttrs{Index: 1001, Name: "net1.100"}, VlanId: 100}
+
+			mocked.On("LinkByName", podifName).Return(fakeLink, nil)
+			mocked.On("LinkByName", "net1.100").Return(vlanLink, nil)
+			mocked.On("LinkByName", "net1.200").Return(nil, netlink.LinkNotFoundError{})
+			mocked.On("LinkByName", netconf.OrigVfState.HostIFName).Return(hostLink, nil)
+			mocked.On("LinkDel", vlanLink).Return(nil)
+			mocked.On("LinkSetDown", fakeLink).Return(nil)
+			mocked.On("LinkSetName", fakeLink, netconf.OrigVfState.HostIFName).Return(nil)
+			mocked.On("LinkSetHardwareAddr", hostLink, fakeMac).Return(nil)
+			mocked.On("LinkSetNsFd", fakeLink, mock.AnythingOfType("int")).Return(nil)
+			mocked.On("LinkSetMTU", fakeLink, 1500).Return(nil)
+			sm := sriovManager{nLink: mocked}
+			err = sm.ReleaseVF(netconf, podifName, targetNetNS)
+			Expect(err).NotTo(HaveOccurred())
+			mocked.AssertExpectations(t)
+		})
+
+		It("Moves the RDMA device back to init netns", func() {
 			targetNetNS, err := testutils.NewNS()
 			defer func() {
 				if targetNetNS != nil {
@@ -501,21 +1051,460 @@ var _ = Describe("Sriov", func() {
 				}
 			}()
 			Expect(err).NotTo(HaveOccurred())
-			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "dummylink"}}
 			mocked := &mocks_utils.NetlinkManager{}
+			fakeMac, err := net.ParseMAC("6e:16:06:0e:b7:e9")
+			Expect(err).NotTo(HaveOccurred())
+
+			netconf.RdmaDevice = "mlx5_0"
+			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "dummylink", HardwareAddr: fakeMac}}
+			hostLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "enp175s6", HardwareAddr: fakeMac}}
+			rdmaLink := &netlink.RdmaLink{Attrs: netlink.RdmaLinkAttrs{Name: "mlx5_0"}}
 
 			mocked.On("LinkByName", podifName).Return(fakeLink, nil)
+			mocked.On("LinkByName", netconf.OrigVfState.HostIFName).Return(hostLink, nil)
 			mocked.On("LinkSetDown", fakeLink).Return(nil)
 			mocked.On("LinkSetName", fakeLink, netconf.OrigVfState.HostIFName).Return(nil)
+			mocked.On("LinkSetHardwareAddr", hostLink, fakeMac).Return(nil)
 			mocked.On("LinkSetNsFd", fakeLink, mock.AnythingOfType("int")).Return(nil)
+			mocked.On("LinkSetMTU", fakeLink, 1500).Return(nil)
+			mocked.On("RdmaSystemGetNetnsMode").Return("exclusive", nil)
+			mocked.On("RdmaLinkByName", "mlx5_0").Return(rdmaLink, nil)
+			mocked.On("RdmaLinkSetNsFd", rdmaLink, mock.AnythingOfType("uint32")).Return(nil)
+			sm := sriovManager{nLink: mocked}
+			err = sm.ReleaseVF(netconf, podifName, targetNetNS)
+			Expect(err).NotTo(HaveOccurred())
+			mocked.AssertExpectations(t)
+		})
+	})
+	Context("Checking ReleaseVF function - restore config", func() {
+		var (
+			podifName string
+			netconf   *sriovtypes.NetConf
+		)
+
+		BeforeEach(func() {
+			podifName = "net1"
+			netconf = &sriovtypes.NetConf{SriovNetConf: sriovtypes.SriovNetConf{
+				Master:   "enp175s0f1",
+				DeviceID: "0000:af:06.0",
+				VFID:     0,
+				OrigVfState: sriovtypes.VfState{
+					HostIFName:   "enp175s6",
+					EffectiveMAC: "c6:c8:7f:1f:21:90",
+				}},
+			}
+		})
+		It("Should not restore Effective MAC address when EffectiveMAC is not in cached state", func() {
+			netconf.OrigVfState.EffectiveMAC = ""
+			targetNetNS, err := testutils.NewNS()
+			defer func() {
+				if targetNetNS != nil {
+					targetNetNS.Close()
+				}
+			}()
+			Expect(err).NotTo(HaveOccurred())
+			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "dummylink"}}
+			mocked := &mocks_utils.NetlinkManager{}
+
+			mocked.On("LinkByName", podifName).Return(fakeLink, nil)
+			mocked.On("LinkSetDown", fakeLink).Return(nil)
+			mocked.On("LinkSetName", fakeLink, netconf.OrigVfState.HostIFName).Return(nil)
+			mocked.On("LinkSetNsFd", fakeLink, mock.AnythingOfType("int")).Return(nil)
+			sm := sriovManager{nLink: mocked}
+			err = sm.ReleaseVF(netconf, podifName, targetNetNS)
+			Expect(err).NotTo(HaveOccurred())
+			mocked.AssertExpectations(t)
+		})
+
+		It("Restores Effective MAC address when present in cached state", func() {
+			netconf.OrigVfState.EffectiveMAC = "aa:f3:8d:65:1b:d4"
+			targetNetNS, err := testutils.NewNS()
+			defer func() {
+				if targetNetNS != nil {
+					targetNetNS.Close()
+				}
+			}()
+			Expect(err).NotTo(HaveOccurred())
+			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "dummylink"}}
+			mocked := &mocks_utils.NetlinkManager{}
+
+			restoreMac, err := net.ParseMAC(netconf.OrigVfState.EffectiveMAC)
+			Expect(err).NotTo(HaveOccurred())
+			tempLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{
+				Index:        1000,
+				Name:         "enp175s6",
+				HardwareAddr: restoreMac,
+			}}
+
+			mocked.On("LinkByName", podifName).Return(fakeLink, nil)
+			mocked.On("LinkByName", netconf.OrigVfState.HostIFName).Return(tempLink, nil)
+			mocked.On("LinkSetDown", fakeLink).Return(nil)
+			mocked.On("LinkSetHardwareAddr", tempLink, restoreMac).Return(nil)
+			mocked.On("LinkSetName", fakeLink, netconf.OrigVfState.HostIFName).Return(nil)
+			mocked.On("LinkSetNsFd", fakeLink, mock.AnythingOfType("int")).Return(nil)
+			sm := sriovManager{nLink: mocked}
+			err = sm.ReleaseVF(netconf, podifName, targetNetNS)
+			Expect(err).NotTo(HaveOccurred())
+			mocked.AssertExpectations(t)
+		})
+	})
+	Context("Checking FillOriginalVfInfo function", func() {
+		var (
+			netconf *sriovtypes.NetConf
+		)
+
+		BeforeEach(func() {
+			netconf = &sriovtypes.NetConf{SriovNetConf: sriovtypes.SriovNetConf{
+				Master:   "enp175s0f1",
+				DeviceID: "0000:af:06.0",
+				VFID:     0,
+				OrigVfState: sriovtypes.VfState{
+					HostIFName: "enp175s6",
+				}},
+			}
+		})
+		It("Saves the current VF state", func() {
+			mocked := &mocks_utils.NetlinkManager{}
+			fakeMac, err := net.ParseMAC("6e:16:06:0e:b7:e9")
+			Expect(err).NotTo(HaveOccurred())
+
+			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{
+				Index:        1000,
+				Name:         netconf.Name,
+				HardwareAddr: fakeMac,
+				Vfs: []netlink.VfInfo{
+					{
+						ID:  0,
+						Mac: fakeMac,
+					},
+				},
+			}}
+
+			fakeVFLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{
+				Index: 1001,
+				Name:  netconf.OrigVfState.HostIFName,
+				MTU:   1500,
+			}}
+
+			mocked.On("LinkByName", netconf.Master).Return(fakeLink, nil)
+			mocked.On("LinkByName", netconf.OrigVfState.HostIFName).Return(fakeVFLink, nil)
+			sm := sriovManager{nLink: mocked}
+			err = sm.FillOriginalVfInfo(netconf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netconf.OrigVfState.MTU).To(Equal(1500))
+			mocked.AssertExpectations(t)
+		})
+
+		It("Saves the current VF GUIDs when a GUID is requested", func() {
+			mocked := &mocks_utils.NetlinkManager{}
+			mockedPciUtils := &mocks.PciUtils{}
+			netconf.GUID = "00:11:22:33:44:55:66:77"
+			netconf.OrigVfState.HostIFName = ""
+
+			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{
+				Index: 1000,
+				Name:  netconf.Master,
+				Vfs:   []netlink.VfInfo{{ID: 0}},
+			}}
+
+			mocked.On("LinkByName", netconf.Master).Return(fakeLink, nil)
+			mockedPciUtils.On("GetVFGUIDs", netconf.Master, netconf.VFID).Return("00:00:00:00:00:00:00:01", "00:00:00:00:00:00:00:02", nil)
+			sm := sriovManager{nLink: mocked, utils: mockedPciUtils}
+			err := sm.FillOriginalVfInfo(netconf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netconf.OrigVfState.NodeGUID).To(Equal("00:00:00:00:00:00:00:01"))
+			Expect(netconf.OrigVfState.PortGUID).To(Equal("00:00:00:00:00:00:00:02"))
+			mocked.AssertExpectations(t)
+		})
+	})
+	Context("Checking ResetVFConfig function - restore config no user params", func() {
+		var (
+			netconf *sriovtypes.NetConf
+		)
+
+		BeforeEach(func() {
+			netconf = &sriovtypes.NetConf{SriovNetConf: sriovtypes.SriovNetConf{
+				Master:   "enp175s0f1",
+				DeviceID: "0000:af:06.0",
+				VFID:     0,
+				OrigVfState: sriovtypes.VfState{
+					HostIFName: "enp175s6",
+				}},
+			}
+		})
+		It("Does not change VF config if it wasnt requested to be changed in netconf", func() {
+			mocked := &mocks_utils.NetlinkManager{}
+			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "dummylink"}}
+
+			mocked.On("LinkByName", netconf.Master).Return(fakeLink, nil)
+			sm := sriovManager{nLink: mocked}
+			err := sm.ResetVFConfig(netconf)
+			Expect(err).NotTo(HaveOccurred())
+			mocked.AssertExpectations(t)
+		})
+		It("Removes the VLAN trunk", func() {
+			netconf.VlanTrunk = "100-200,300"
+			mocked := &mocks_utils.NetlinkManager{}
+			mockedPciUtils := &mocks.PciUtils{}
+			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "dummylink"}}
+
+			mocked.On("LinkByName", netconf.Master).Return(fakeLink, nil)
+			mockedPciUtils.On("SetVFVlanTrunk", netconf.Master, netconf.VFID, "100-200,300", false).Return(nil)
+			sm := sriovManager{nLink: mocked, utils: mockedPciUtils}
+			err := sm.ResetVFConfig(netconf)
+			Expect(err).NotTo(HaveOccurred())
+			mockedPciUtils.AssertExpectations(t)
+		})
+		It("Does not touch the PF for a Scalable Function", func() {
+			sfNum := 88
+			netconf.SFNum = &sfNum
+			netconf.VFID = -1
+			netconf.OrigVfState.AdminMAC = "00:00:00:00:00:00"
+			mocked := &mocks_utils.NetlinkManager{}
+			sm := sriovManager{nLink: mocked}
+			err := sm.ResetVFConfig(netconf)
+			Expect(err).NotTo(HaveOccurred())
+			mocked.AssertExpectations(t)
+		})
+	})
+	Context("Checking ResetVFConfig function - restore MAC when not set via annotation but present in cached state", func() {
+		var (
+			netconf *sriovtypes.NetConf
+		)
+
+		BeforeEach(func() {
+			netconf = &sriovtypes.NetConf{SriovNetConf: sriovtypes.SriovNetConf{
+				Master:   "enp175s0f1",
+				DeviceID: "0000:af:06.0",
+				VFID:     0,
+				OrigVfState: sriovtypes.VfState{
+					HostIFName: "enp175s6",
+					AdminMAC:   "aa:f3:8d:65:1b:d4",
+				}},
+			}
+		})
+		It("Restores the original administrative MAC address even if conf.MAC is empty", func() {
+			origMac, err := net.ParseMAC(netconf.OrigVfState.AdminMAC)
+			Expect(err).NotTo(HaveOccurred())
+			mocked := &mocks_utils.NetlinkManager{}
+			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "dummylink", Vfs: []netlink.VfInfo{
+				{Mac: origMac},
+			}}}
+
+			mocked.On("LinkByName", netconf.Master).Return(fakeLink, nil)
+			mocked.On("LinkSetVfHardwareAddr", fakeLink, netconf.VFID, origMac).Return(nil)
+
+			sm := sriovManager{nLink: mocked}
+			err = sm.ResetVFConfig(netconf)
+			Expect(err).NotTo(HaveOccurred())
+			mocked.AssertExpectations(t)
+		})
+	})
+	Context("Checking ResetVFConfig function - restore config with user params", func() {
+		var (
+			netconf *sriovtypes.NetConf
+		)
+
+		BeforeEach(func() {
+			vlan := 6
+			vlanQos := 3
+			maxTxRate := 4000
+			minTxRate := 1000
+
+			netconf = &sriovtypes.NetConf{SriovNetConf: sriovtypes.SriovNetConf{
+				Master:    "enp175s0f1",
+				DeviceID:  "0000:af:06.0",
+				VFID:      0,
+				MAC:       "d2:fc:22:a7:0d:e8",
+				Vlan:      &vlan,
+				VlanQoS:   &vlanQos,
+				SpoofChk:  "on",
+				MaxTxRate: &maxTxRate,
+				MinTxRate: &minTxRate,
+				Trust:     "on",
+				LinkState: "enable",
+				OrigVfState: sriovtypes.VfState{
+					HostIFName:   "enp175s6",
+					SpoofChk:     false,
+					AdminMAC:     "aa:f3:8d:65:1b:d4",
+					EffectiveMAC: "aa:f3:8d:65:1b:d4",
+					Vlan:         1,
+					VlanQoS:      1,
+					MinTxRate:    0,
+					MaxTxRate:    0,
+					LinkState:    2, // disable
+					MTU:          1500,
+				}},
+			}
+		})
+		It("Restores original VF configurations", func() {
+			origMac, err := net.ParseMAC(netconf.OrigVfState.AdminMAC)
+			Expect(err).NotTo(HaveOccurred())
+			mocked := &mocks_utils.NetlinkManager{}
+			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "dummylink", Vfs: []netlink.VfInfo{
+				{Mac: origMac},
+			}}}
+
+			mocked.On("LinkByName", netconf.Master).Return(fakeLink, nil)
+			mocked.On("LinkSetVfVlanQosProto", fakeLink, netconf.VFID, netconf.OrigVfState.Vlan, netconf.OrigVfState.VlanQoS, sriovtypes.VlanProtoInt[sriovtypes.Proto8021q]).Return(nil)
+			mocked.On("LinkSetVfSpoofchk", fakeLink, netconf.VFID, netconf.OrigVfState.SpoofChk).Return(nil)
+			mocked.On("LinkSetVfHardwareAddr", fakeLink, netconf.VFID, origMac).Return(nil)
+			mocked.On("LinkSetVfTrust", fakeLink, netconf.VFID, false).Return(nil)
+			mocked.On("LinkSetVfRate", fakeLink, netconf.VFID, netconf.OrigVfState.MinTxRate, netconf.OrigVfState.MaxTxRate).Return(nil)
+			mocked.On("LinkSetVfState", fakeLink, netconf.VFID, netconf.OrigVfState.LinkState).Return(nil)
+
+			sm := sriovManager{nLink: mocked}
+			err = sm.ResetVFConfig(netconf)
+			Expect(err).NotTo(HaveOccurred())
+			mocked.AssertExpectations(t)
+		})
+
+		It("Restores original VF GUIDs", func() {
+			netconf = &sriovtypes.NetConf{SriovNetConf: sriovtypes.SriovNetConf{
+				Master: "enp175s0f1",
+				VFID:   0,
+				GUID:   "00:11:22:33:44:55:66:77",
+				OrigVfState: sriovtypes.VfState{
+					NodeGUID: "00:00:00:00:00:00:00:01",
+					PortGUID: "00:00:00:00:00:00:00:02",
+				}},
+			}
+			nodeGUID, err := net.ParseMAC(netconf.OrigVfState.NodeGUID)
+			Expect(err).NotTo(HaveOccurred())
+			portGUID, err := net.ParseMAC(netconf.OrigVfState.PortGUID)
+			Expect(err).NotTo(HaveOccurred())
+			mocked := &mocks_utils.NetlinkManager{}
+			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "dummylink"}}
+
+			mocked.On("LinkByName", netconf.Master).Return(fakeLink, nil)
+			mocked.On("LinkSetVfNodeGUID", fakeLink, netconf.VFID, nodeGUID).Return(nil)
+			mocked.On("LinkSetVfPortGUID", fakeLink, netconf.VFID, portGUID).Return(nil)
+
+			sm := sriovManager{nLink: mocked}
+			err = sm.ResetVFConfig(netconf)
+			Expect(err).NotTo(HaveOccurred())
+			mocked.AssertExpectations(t)
+		})
+	})
+	Context("Checking ApplyVFConfig function", func() {
+		var (
+			netconf *sriovtypes.NetConf
+		)
+
+		It("Return MTU from PF", func() {
+			mocked := &mocks_utils.NetlinkManager{}
+			mockedPciUtils := &mocks.PciUtils{}
+			vlan := 0
+			vlanProto := sriovtypes.Proto8021q
+			netconf = &sriovtypes.NetConf{SriovNetConf: sriovtypes.SriovNetConf{
+				Master:    "ens1s0",
+				Vlan:      &vlan,
+				VlanQoS:   &vlan,
+				VlanProto: &vlanProto}}
+			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{
+				Index: 1000,
+				Name:  "ens1s0",
+				MTU:   9000,
+			}}
+
+			mocked.On("LinkByName", "ens1s0").Return(fakeLink, nil)
+			mocked.On("LinkSetVfVlanQosProto", fakeLink, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+			sm := sriovManager{nLink: mocked, utils: mockedPciUtils}
+			err := sm.ApplyVFConfig(netconf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*netconf.MTU).To(Equal(9000))
+			mocked.AssertExpectations(t)
+		})
+
+		It("Rejects a requested MTU bigger than the PF MTU", func() {
+			mocked := &mocks_utils.NetlinkManager{}
+			mockedPciUtils := &mocks.PciUtils{}
+			requestedMTU := 9000
+			netconf = &sriovtypes.NetConf{SriovNetConf: sriovtypes.SriovNetConf{
+				Master:       "ens1s0",
+				RequestedMTU: &requestedMTU}}
+			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{
+				Index: 1000,
+				Name:  "ens1s0",
+				MTU:   1500,
+			}}
+
+			mocked.On("LinkByName", "ens1s0").Return(fakeLink, nil)
+			sm := sriovManager{nLink: mocked, utils: mockedPciUtils}
+			err := sm.ApplyVFConfig(netconf)
+			Expect(err).To(HaveOccurred())
+			Expect(netconf.MTU).To(BeNil())
+			mocked.AssertExpectations(t)
+		})
+	})
+	Context("Checking CheckVFConfig function", func() {
+		var (
+			netconf *sriovtypes.NetConf
+			mocked  *mocks_utils.NetlinkManager
+		)
+
+		BeforeEach(func() {
+			vlan := 100
+			vlanQoS := 0
+			vlanProto := sriovtypes.Proto8021q
+			netconf = &sriovtypes.NetConf{SriovNetConf: sriovtypes.SriovNetConf{
+				Master:    "enp175s0f1",
+				VFID:      0,
+				MAC:       "aa:f3:8d:65:1b:d4",
+				Vlan:      &vlan,
+				VlanQoS:   &vlanQoS,
+				VlanProto: &vlanProto,
+				SpoofChk:  "on",
+				Trust:     "on",
+				LinkState: "enable",
+			}}
+			mocked = &mocks_utils.NetlinkManager{}
+		})
+
+		It("Should not report drift when the VF matches the netconf", func() {
+			hwaddr, err := net.ParseMAC(netconf.MAC)
+			Expect(err).NotTo(HaveOccurred())
+			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "enp175s0f1", Vfs: []netlink.VfInfo{
+				{ID: 0, Mac: hwaddr, Vlan: 100, Spoofchk: true, Trust: 1, LinkState: netlink.VF_LINK_STATE_ENABLE},
+			}}}
+
+			mocked.On("LinkByName", netconf.Master).Return(fakeLink, nil)
+			sm := sriovManager{nLink: mocked}
+			drifted, err := sm.CheckVFConfig(netconf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drifted).To(BeEmpty())
+			mocked.AssertExpectations(t)
+		})
+
+		It("Should report every drifted field", func() {
+			hwaddr, err := net.ParseMAC("6e:16:06:0e:b7:e9")
+			Expect(err).NotTo(HaveOccurred())
+			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "enp175s0f1", Vfs: []netlink.VfInfo{
+				{ID: 0, Mac: hwaddr, Vlan: 200, Spoofchk: true, Trust: 0, LinkState: netlink.VF_LINK_STATE_ENABLE},
+			}}}
+
+			mocked.On("LinkByName", netconf.Master).Return(fakeLink, nil)
 			sm := sriovManager{nLink: mocked}
-			err = sm.ReleaseVF(netconf, podifName, targetNetNS)
+			drifted, err := sm.CheckVFConfig(netconf)
 			Expect(err).NotTo(HaveOccurred())
-			mocked.AssertExpectations(t)
+			Expect(drifted).To(ConsistOf(
+				"vlan: expected 100, found 200",
+				"mac: expected aa:f3:8d:65:1b:d4, found 6e:16:06:0e:b7:e9",
+				"trust: expected true, found false",
+			))
 		})
 
-		It("Restores Effective MAC address when present in cached state", func() {
-			netconf.OrigVfState.EffectiveMAC = "aa:f3:8d:65:1b:d4"
+		It("Should fail when the VF does not exist on the PF", func() {
+			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "enp175s0f1"}}
+
+			mocked.On("LinkByName", netconf.Master).Return(fakeLink, nil)
+			sm := sriovManager{nLink: mocked}
+			_, err := sm.CheckVFConfig(netconf)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Context("Checking CheckVF function", func() {
+		It("Should report a missing interface and drifted attributes", func() {
 			targetNetNS, err := testutils.NewNS()
 			defer func() {
 				if targetNetNS != nil {
@@ -523,32 +1512,37 @@ var _ = Describe("Sriov", func() {
 				}
 			}()
 			Expect(err).NotTo(HaveOccurred())
-			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "dummylink"}}
+			fakeMac, err := net.ParseMAC("6e:16:06:0e:b7:e9")
+			Expect(err).NotTo(HaveOccurred())
+			net1Link := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "net1", HardwareAddr: fakeMac, MTU: 1500}}
+
 			mocked := &mocks_utils.NetlinkManager{}
+			mocked.On("LinkByName", "net1").Return(net1Link, nil)
+			mocked.On("LinkByName", "net2").Return(nil, errors.New("link not found"))
+			sm := sriovManager{nLink: mocked}
 
-			restoreMac, err := net.ParseMAC(netconf.OrigVfState.EffectiveMAC)
+			drifted, err := sm.CheckVF(&current.Interface{Name: "net1", Mac: "6e:16:06:0e:b7:e9", Mtu: 1500}, targetNetNS)
 			Expect(err).NotTo(HaveOccurred())
-			tempLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{
-				Index:        1000,
-				Name:         "enp175s6",
-				HardwareAddr: restoreMac,
-			}}
+			Expect(drifted).To(BeEmpty())
 
-			mocked.On("LinkByName", podifName).Return(fakeLink, nil)
-			mocked.On("LinkByName", netconf.OrigVfState.HostIFName).Return(tempLink, nil)
-			mocked.On("LinkSetDown", fakeLink).Return(nil)
-			mocked.On("LinkSetHardwareAddr", tempLink, restoreMac).Return(nil)
-			mocked.On("LinkSetName", fakeLink, netconf.OrigVfState.HostIFName).Return(nil)
-			mocked.On("LinkSetNsFd", fakeLink, mock.AnythingOfType("int")).Return(nil)
-			sm := sriovManager{nLink: mocked}
-			err = sm.ReleaseVF(netconf, podifName, targetNetNS)
+			drifted, err = sm.CheckVF(&current.Interface{Name: "net1", Mac: "aa:f3:8d:65:1b:d4", Mtu: 9000}, targetNetNS)
 			Expect(err).NotTo(HaveOccurred())
-			mocked.AssertExpectations(t)
+			Expect(drifted).To(ConsistOf(
+				"mac: expected aa:f3:8d:65:1b:d4, found 6e:16:06:0e:b7:e9",
+				"mtu: expected 9000, found 1500",
+			))
+
+			drifted, err = sm.CheckVF(&current.Interface{Name: "net2"}, targetNetNS)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drifted).To(HaveLen(1))
+			Expect(drifted[0]).To(HavePrefix("name: interface net2 not found"))
 		})
 	})
-	Context("Checking FillOriginalVfInfo function", func() {
+	Context("Checking RestoreHostIFName function", func() {
 		var (
-			netconf *sriovtypes.NetConf
+			netconf        *sriovtypes.NetConf
+			mocked         *mocks_utils.NetlinkManager
+			mockedPciUtils *mocks.PciUtils
 		)
 
 		BeforeEach(func() {
@@ -560,42 +1554,44 @@ var _ = Describe("Sriov", func() {
 					HostIFName: "enp175s6",
 				}},
 			}
+			mocked = &mocks_utils.NetlinkManager{}
+			mockedPciUtils = &mocks.PciUtils{}
 		})
-		It("Saves the current VF state", func() {
-			mocked := &mocks_utils.NetlinkManager{}
-			fakeMac, err := net.ParseMAC("6e:16:06:0e:b7:e9")
-			Expect(err).NotTo(HaveOccurred())
 
-			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{
-				Index:        1000,
-				Name:         netconf.Name,
-				HardwareAddr: fakeMac,
-				Vfs: []netlink.VfInfo{
-					{
-						ID:  0,
-						Mac: fakeMac,
-					},
-				},
-			}}
+		It("Renames the VF netdev back to its host name", func() {
+			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "net1"}}
 
-			fakeVFLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{
-				Index: 1001,
-				Name:  netconf.OrigVfState.HostIFName,
-				MTU:   1500,
-			}}
+			mockedPciUtils.On("GetVFLinkNamesFromVFID", netconf.Master, netconf.VFID).Return([]string{"net1"}, nil)
+			mocked.On("LinkByName", "net1").Return(fakeLink, nil)
+			mocked.On("LinkSetDown", fakeLink).Return(nil)
+			mocked.On("LinkSetName", fakeLink, netconf.OrigVfState.HostIFName).Return(nil)
+			sm := sriovManager{nLink: mocked, utils: mockedPciUtils}
+			err := sm.RestoreHostIFName(netconf)
+			Expect(err).NotTo(HaveOccurred())
+			mocked.AssertExpectations(t)
+		})
 
-			mocked.On("LinkByName", netconf.Master).Return(fakeLink, nil)
-			mocked.On("LinkByName", netconf.OrigVfState.HostIFName).Return(fakeVFLink, nil)
-			sm := sriovManager{nLink: mocked}
-			err = sm.FillOriginalVfInfo(netconf)
+		It("Does nothing when the VF netdev already has its host name", func() {
+			mockedPciUtils.On("GetVFLinkNamesFromVFID", netconf.Master, netconf.VFID).Return([]string{"enp175s6"}, nil)
+			sm := sriovManager{nLink: mocked, utils: mockedPciUtils}
+			err := sm.RestoreHostIFName(netconf)
+			Expect(err).NotTo(HaveOccurred())
+			mocked.AssertExpectations(t)
+		})
+
+		It("Does nothing when the VF netdev is not in the init netns", func() {
+			mockedPciUtils.On("GetVFLinkNamesFromVFID", netconf.Master, netconf.VFID).Return(nil, errors.New("no such file or directory"))
+			sm := sriovManager{nLink: mocked, utils: mockedPciUtils}
+			err := sm.RestoreHostIFName(netconf)
 			Expect(err).NotTo(HaveOccurred())
-			Expect(netconf.OrigVfState.MTU).To(Equal(1500))
 			mocked.AssertExpectations(t)
 		})
 	})
-	Context("Checking ResetVFConfig function - restore config no user params", func() {
+	Context("Checking SetupVdpaDevice function", func() {
 		var (
-			netconf *sriovtypes.NetConf
+			netconf        *sriovtypes.NetConf
+			mocked         *mocks_utils.NetlinkManager
+			mockedPciUtils *mocks.PciUtils
 		)
 
 		BeforeEach(func() {
@@ -603,142 +1599,319 @@ var _ = Describe("Sriov", func() {
 				Master:   "enp175s0f1",
 				DeviceID: "0000:af:06.0",
 				VFID:     0,
+				VdpaType: sriovtypes.VdpaTypeVhost,
 				OrigVfState: sriovtypes.VfState{
 					HostIFName: "enp175s6",
 				}},
 			}
+			mocked = &mocks_utils.NetlinkManager{}
+			mockedPciUtils = &mocks.PciUtils{}
 		})
-		It("Does not change VF config if it wasnt requested to be changed in netconf", func() {
-			mocked := &mocks_utils.NetlinkManager{}
-			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "dummylink"}}
 
-			mocked.On("LinkByName", netconf.Master).Return(fakeLink, nil)
-			sm := sriovManager{nLink: mocked}
-			err := sm.ResetVFConfig(netconf)
+		It("Creates a vhost vDPA device when the VF has none", func() {
+			mockedPciUtils.On("GetVdpaDeviceName", netconf.DeviceID).Return("", nil)
+			mocked.On("VDPANewDev", "vdpa:0000:af:06.0", "pci", netconf.DeviceID, netlink.VDPANewDevParams{}).Return(nil)
+			mockedPciUtils.On("GetVdpaDriver", "vdpa:0000:af:06.0").Return("", nil)
+			mockedPciUtils.On("BindVdpaDriver", "vdpa:0000:af:06.0", utils.VhostVdpaDriver).Return(nil)
+			mockedPciUtils.On("GetVhostVdpaPath", "vdpa:0000:af:06.0").Return("/dev/vhost-vdpa-0", nil)
+			sm := sriovManager{nLink: mocked, utils: mockedPciUtils}
+			err := sm.SetupVdpaDevice(netconf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netconf.VdpaDevice).To(Equal("vdpa:0000:af:06.0"))
+			Expect(netconf.VdpaCreated).To(BeTrue())
+			Expect(netconf.VdpaPath).To(Equal("/dev/vhost-vdpa-0"))
+			Expect(netconf.OrigVfState.HostIFName).To(Equal("enp175s6"))
+			mocked.AssertExpectations(t)
+			mockedPciUtils.AssertExpectations(t)
+		})
+
+		It("Binds an existing vDPA device to virtio_vdpa and uses its netdev", func() {
+			netconf.VdpaType = sriovtypes.VdpaTypeVirtio
+			mockedPciUtils.On("GetVdpaDeviceName", netconf.DeviceID).Return("vdpa0", nil)
+			mockedPciUtils.On("GetVdpaDriver", "vdpa0").Return(utils.VhostVdpaDriver, nil)
+			mockedPciUtils.On("BindVdpaDriver", "vdpa0", utils.VirtioVdpaDriver).Return(nil)
+			mockedPciUtils.On("GetVirtioVdpaNetdev", "vdpa0").Return("eth0", nil)
+			sm := sriovManager{nLink: mocked, utils: mockedPciUtils}
+			err := sm.SetupVdpaDevice(netconf)
 			Expect(err).NotTo(HaveOccurred())
+			Expect(netconf.VdpaDevice).To(Equal("vdpa0"))
+			Expect(netconf.VdpaCreated).To(BeFalse())
+			Expect(netconf.OrigVfState.VdpaDriver).To(Equal(utils.VhostVdpaDriver))
+			Expect(netconf.OrigVfState.HostIFName).To(Equal("eth0"))
 			mocked.AssertExpectations(t)
+			mockedPciUtils.AssertExpectations(t)
+		})
+
+		It("Returns a netlink error code when creating the vDPA device fails", func() {
+			mockedPciUtils.On("GetVdpaDeviceName", netconf.DeviceID).Return("", nil)
+			mocked.On("VDPANewDev", "vdpa:0000:af:06.0", "pci", netconf.DeviceID, netlink.VDPANewDevParams{}).Return(errors.New("not supported"))
+			sm := sriovManager{nLink: mocked, utils: mockedPciUtils}
+			err := sm.SetupVdpaDevice(netconf)
+			Expect(err).To(HaveOccurred())
+			cniErr := &cnitypes.Error{}
+			Expect(errors.As(err, &cniErr)).To(BeTrue())
+			Expect(cniErr.Code).To(Equal(sriovtypes.ErrNetlinkFailure))
 		})
 	})
-	Context("Checking ResetVFConfig function - restore MAC when not set via annotation but present in cached state", func() {
+	Context("Checking ReleaseVdpaDevice function", func() {
 		var (
-			netconf *sriovtypes.NetConf
+			netconf        *sriovtypes.NetConf
+			mocked         *mocks_utils.NetlinkManager
+			mockedPciUtils *mocks.PciUtils
 		)
 
 		BeforeEach(func() {
 			netconf = &sriovtypes.NetConf{SriovNetConf: sriovtypes.SriovNetConf{
-				Master:   "enp175s0f1",
-				DeviceID: "0000:af:06.0",
-				VFID:     0,
-				OrigVfState: sriovtypes.VfState{
-					HostIFName: "enp175s6",
-					AdminMAC:   "aa:f3:8d:65:1b:d4",
-				}},
-			}
+				DeviceID:   "0000:af:06.0",
+				VdpaType:   sriovtypes.VdpaTypeVirtio,
+				VdpaDevice: "vdpa0",
+			}}
+			mocked = &mocks_utils.NetlinkManager{}
+			mockedPciUtils = &mocks.PciUtils{}
 		})
-		It("Restores the original administrative MAC address even if conf.MAC is empty", func() {
-			origMac, err := net.ParseMAC(netconf.OrigVfState.AdminMAC)
-			Expect(err).NotTo(HaveOccurred())
-			mocked := &mocks_utils.NetlinkManager{}
-			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "dummylink", Vfs: []netlink.VfInfo{
-				{Mac: origMac},
-			}}}
 
-			mocked.On("LinkByName", netconf.Master).Return(fakeLink, nil)
-			mocked.On("LinkSetVfHardwareAddr", fakeLink, netconf.VFID, origMac).Return(nil)
+		It("Deletes a vDPA device created by the plugin", func() {
+			netconf.VdpaCreated = true
+			mocked.On("VDPADelDev", "vdpa0").Return(nil)
+			sm := sriovManager{nLink: mocked, utils: mockedPciUtils}
+			Expect(sm.ReleaseVdpaDevice(netconf)).To(Succeed())
+			mocked.AssertExpectations(t)
+			mockedPciUtils.AssertExpectations(t)
+		})
 
-			sm := sriovManager{nLink: mocked}
-			err = sm.ResetVFConfig(netconf)
-			Expect(err).NotTo(HaveOccurred())
+		It("Binds an existing vDPA device back to its original driver", func() {
+			netconf.OrigVfState.VdpaDriver = utils.VhostVdpaDriver
+			mockedPciUtils.On("BindVdpaDriver", "vdpa0", utils.VhostVdpaDriver).Return(nil)
+			sm := sriovManager{nLink: mocked, utils: mockedPciUtils}
+			Expect(sm.ReleaseVdpaDevice(netconf)).To(Succeed())
+			mocked.AssertExpectations(t)
+			mockedPciUtils.AssertExpectations(t)
+		})
+
+		It("Does nothing without a vDPA device", func() {
+			netconf.VdpaDevice = ""
+			sm := sriovManager{nLink: mocked, utils: mockedPciUtils}
+			Expect(sm.ReleaseVdpaDevice(netconf)).To(Succeed())
 			mocked.AssertExpectations(t)
+			mockedPciUtils.AssertExpectations(t)
 		})
 	})
-	Context("Checking ResetVFConfig function - restore config with user params", func() {
+	Context("Checking setupRepresentor function", func() {
+		It("Brings a representor that was up back up when renaming it fails", func() {
+			netconf := &sriovtypes.NetConf{SriovNetConf: sriovtypes.SriovNetConf{
+				Master:            "enp175s0f1",
+				VFID:              0,
+				ContainerID:       "4e5a8b2c1d3f6e7a9b0c",
+				RenameRepresentor: true,
+			}}
+			repLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1001, Name: "enp175s0f1_0", Flags: net.FlagUp}}
+			mocked := &mocks_utils.NetlinkManager{}
+			mockedPciUtils := &mocks.PciUtils{}
+			mockedPciUtils.On("GetVFRepresentor", netconf.Master, netconf.VFID).Return("enp175s0f1_0", nil)
+			mocked.On("LinkByName", "enp175s0f1_0").Return(repLink, nil)
+			mocked.On("LinkSetDown", repLink).Return(nil)
+			mocked.On("LinkSetName", repLink, "4e5a8b2c1d_net1").Return(errors.New("device or resource busy"))
+			mocked.On("LinkSetUp", repLink).Return(nil)
+
+			sm := sriovManager{nLink: mocked, utils: mockedPciUtils}
+			Expect(sm.setupRepresentor(netconf, "net1")).NotTo(Succeed())
+			Expect(netconf.Representor).To(Equal("enp175s0f1_0"))
+			Expect(netconf.OrigVfState.RepresentorUp).To(BeTrue())
+			mocked.AssertExpectations(t)
+		})
+	})
+
+	Context("Checking RestoreRepresentor function", func() {
 		var (
 			netconf *sriovtypes.NetConf
+			mocked  *mocks_utils.NetlinkManager
+			repLink *utils.FakeLink
 		)
 
 		BeforeEach(func() {
-			vlan := 6
-			vlanQos := 3
-			maxTxRate := 4000
-			minTxRate := 1000
-
 			netconf = &sriovtypes.NetConf{SriovNetConf: sriovtypes.SriovNetConf{
-				Master:    "enp175s0f1",
-				DeviceID:  "0000:af:06.0",
-				VFID:      0,
-				MAC:       "d2:fc:22:a7:0d:e8",
-				Vlan:      &vlan,
-				VlanQoS:   &vlanQos,
-				SpoofChk:  "on",
-				MaxTxRate: &maxTxRate,
-				MinTxRate: &minTxRate,
-				Trust:     "on",
-				LinkState: "enable",
+				Master:      "enp175s0f1",
+				DeviceID:    "0000:af:06.0",
+				Representor: "4e5a8b2c1d_net1",
 				OrigVfState: sriovtypes.VfState{
-					HostIFName:   "enp175s6",
-					SpoofChk:     false,
-					AdminMAC:     "aa:f3:8d:65:1b:d4",
-					EffectiveMAC: "aa:f3:8d:65:1b:d4",
-					Vlan:         1,
-					VlanQoS:      1,
-					MinTxRate:    0,
-					MaxTxRate:    0,
-					LinkState:    2, // disable
-					MTU:          1500,
+					RepresentorName: "enp175s0f1_0",
 				}},
 			}
+			mocked = &mocks_utils.NetlinkManager{}
+			repLink = &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1001, Name: netconf.Representor}}
+			mocked.On("LinkByName", netconf.Representor).Return(repLink, nil)
 		})
-		It("Restores original VF configurations", func() {
-			origMac, err := net.ParseMAC(netconf.OrigVfState.AdminMAC)
-			Expect(err).NotTo(HaveOccurred())
-			mocked := &mocks_utils.NetlinkManager{}
-			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "dummylink", Vfs: []netlink.VfInfo{
-				{Mac: origMac},
-			}}}
 
-			mocked.On("LinkByName", netconf.Master).Return(fakeLink, nil)
-			mocked.On("LinkSetVfVlanQosProto", fakeLink, netconf.VFID, netconf.OrigVfState.Vlan, netconf.OrigVfState.VlanQoS, sriovtypes.VlanProtoInt[sriovtypes.Proto8021q]).Return(nil)
-			mocked.On("LinkSetVfSpoofchk", fakeLink, netconf.VFID, netconf.OrigVfState.SpoofChk).Return(nil)
-			mocked.On("LinkSetVfHardwareAddr", fakeLink, netconf.VFID, origMac).Return(nil)
-			mocked.On("LinkSetVfTrust", fakeLink, netconf.VFID, false).Return(nil)
-			mocked.On("LinkSetVfRate", fakeLink, netconf.VFID, netconf.OrigVfState.MinTxRate, netconf.OrigVfState.MaxTxRate).Return(nil)
-			mocked.On("LinkSetVfState", fakeLink, netconf.VFID, netconf.OrigVfState.LinkState).Return(nil)
+		It("Restores the name of a renamed representor", func() {
+			mocked.On("LinkSetDown", repLink).Return(nil)
+			mocked.On("LinkSetName", repLink, "enp175s0f1_0").Return(nil)
+			sm := sriovManager{nLink: mocked}
+			Expect(sm.RestoreRepresentor(netconf)).To(Succeed())
+			mocked.AssertExpectations(t)
+		})
 
+		It("Brings a renamed representor that was up back up", func() {
+			netconf.OrigVfState.RepresentorUp = true
+			mocked.On("LinkSetDown", repLink).Return(nil)
+			mocked.On("LinkSetName", repLink, "enp175s0f1_0").Return(nil)
+			mocked.On("LinkSetUp", repLink).Return(nil)
 			sm := sriovManager{nLink: mocked}
-			err = sm.ResetVFConfig(netconf)
-			Expect(err).NotTo(HaveOccurred())
+			Expect(sm.RestoreRepresentor(netconf)).To(Succeed())
+			mocked.AssertExpectations(t)
+		})
+
+		It("Leaves a representor that was up and not renamed untouched", func() {
+			netconf.Representor = "enp175s0f1_0"
+			netconf.OrigVfState.RepresentorUp = true
+			mocked = &mocks_utils.NetlinkManager{}
+			mocked.On("LinkByName", "enp175s0f1_0").Return(repLink, nil)
+			sm := sriovManager{nLink: mocked}
+			Expect(sm.RestoreRepresentor(netconf)).To(Succeed())
 			mocked.AssertExpectations(t)
 		})
 	})
-	Context("Checking ApplyVFConfig function", func() {
+
+	Context("Checking SetupBond function", func() {
 		var (
-			netconf *sriovtypes.NetConf
+			netconf   *sriovtypes.NetConf
+			mocked    *mocks_utils.NetlinkManager
+			bondLink  *utils.FakeLink
+			links     []*utils.FakeLink
+			targetNS  ns.NetNS
+			bondIfMAC net.HardwareAddr
 		)
 
-		It("Return MTU from PF", func() {
-			mocked := &mocks_utils.NetlinkManager{}
-			mockedPciUtils := &mocks.PciUtils{}
-			vlan := 0
-			vlanProto := sriovtypes.Proto8021q
+		BeforeEach(func() {
+			var err error
+			targetNS, err = testutils.NewNS()
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(func() {
+				targetNS.Close()
+				_ = testutils.UnmountNS(targetNS)
+			})
+
 			netconf = &sriovtypes.NetConf{SriovNetConf: sriovtypes.SriovNetConf{
-				Master:    "ens1s0",
-				Vlan:      &vlan,
-				VlanQoS:   &vlan,
-				VlanProto: &vlanProto}}
-			fakeLink := &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{
-				Index: 1000,
-				Name:  "ens1s0",
-				MTU:   9000,
+				Bond: &sriovtypes.BondConf{Mode: sriovtypes.BondMode8023AD},
 			}}
+			bondIfMAC, err = net.ParseMAC("6e:16:06:0e:b7:e9")
+			Expect(err).NotTo(HaveOccurred())
+			bondLink = &utils.FakeLink{LinkAttrs: netlink.LinkAttrs{Index: 1000, Name: "net1", HardwareAddr: bondIfMAC}}
+			links = []*utils.FakeLink{
+				{LinkAttrs: netlink.LinkAttrs{Index: 1001, Name: "net1_0"}},
+				{LinkAttrs: netlink.LinkAttrs{Index: 1002, Name: "net1_1"}},
+			}
+			mocked = &mocks_utils.NetlinkManager{}
+		})
 
-			mocked.On("LinkByName", "ens1s0").Return(fakeLink, nil)
-			mocked.On("LinkSetVfVlanQosProto", fakeLink, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
-			sm := sriovManager{nLink: mocked, utils: mockedPciUtils}
-			err := sm.ApplyVFConfig(netconf)
+		It("Creates the bond and enslaves its links", func() {
+			mocked.On("LinkAdd", mock.MatchedBy(func(bond *netlink.Bond) bool {
+				return bond.Name == "net1" && bond.Mode == netlink.BOND_MODE_802_3AD && bond.Miimon == 100
+			})).Return(nil)
+			mocked.On("LinkByName", "net1").Return(bondLink, nil)
+			for _, link := range links {
+				mocked.On("LinkByName", link.Name).Return(link, nil)
+				mocked.On("LinkSetDown", link).Return(nil)
+				mocked.On("LinkSetMaster", link, bondLink).Return(nil)
+			}
+			mocked.On("LinkSetUp", bondLink).Return(nil)
+
+			sm := sriovManager{nLink: mocked}
+			mac, err := sm.SetupBond(netconf, "net1", []string{"net1_0", "net1_1"}, targetNS)
 			Expect(err).NotTo(HaveOccurred())
-			Expect(*netconf.MTU).To(Equal(9000))
+			Expect(mac).To(Equal("6e:16:06:0e:b7:e9"))
+			mocked.AssertExpectations(t)
+		})
+
+		It("Uses the configured link monitoring interval", func() {
+			miimon := 0
+			netconf.Bond = &sriovtypes.BondConf{Mode: sriovtypes.BondModeActiveBackup, Miimon: &miimon}
+			mocked.On("LinkAdd", mock.MatchedBy(func(bond *netlink.Bond) bool {
+				return bond.Mode == netlink.BOND_MODE_ACTIVE_BACKUP && bond.Miimon == 0
+			})).Return(nil)
+			mocked.On("LinkByName", "net1").Return(bondLink, nil)
+			mocked.On("LinkByName", "net1_0").Return(links[0], nil)
+			mocked.On("LinkSetDown", links[0]).Return(nil)
+			mocked.On("LinkSetMaster", links[0], bondLink).Return(nil)
+			mocked.On("LinkSetUp", bondLink).Return(nil)
+
+			sm := sriovManager{nLink: mocked}
+			_, err := sm.SetupBond(netconf, "net1", []string{"net1_0"}, targetNS)
+			Expect(err).NotTo(HaveOccurred())
+			mocked.AssertExpectations(t)
+		})
+
+		It("Fails if a link can not be enslaved", func() {
+			mocked.On("LinkAdd", mock.Anything).Return(nil)
+			mocked.On("LinkByName", "net1").Return(bondLink, nil)
+			mocked.On("LinkByName", "net1_0").Return(links[0], nil)
+			mocked.On("LinkSetDown", links[0]).Return(nil)
+			mocked.On("LinkSetMaster", links[0], bondLink).Return(errors.New("device busy"))
+
+			sm := sriovManager{nLink: mocked}
+			_, err := sm.SetupBond(netconf, "net1", []string{"net1_0", "net1_1"}, targetNS)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("failed to enslave net1_0 to bond net1"))
+			mocked.AssertExpectations(t)
+		})
+
+		It("Deletes the bond", func() {
+			mocked.On("LinkByName", "net1").Return(bondLink, nil)
+			mocked.On("LinkDel", bondLink).Return(nil)
+
+			sm := sriovManager{nLink: mocked}
+			Expect(sm.ReleaseBond("net1", targetNS)).To(Succeed())
+			mocked.AssertExpectations(t)
+		})
+
+		It("Ignores a bond that was not created", func() {
+			mocked.On("LinkByName", "net1").Return(nil, netlink.LinkNotFoundError{})
+
+			sm := sriovManager{nLink: mocked}
+			Expect(sm.ReleaseBond("net1", targetNS)).To(Succeed())
 			mocked.AssertExpectations(t)
 		})
+
+		It("Checks the bond and its links", func() {
+			bond := netlink.NewLinkBond(netlink.LinkAttrs{Index: 1000, Name: "net1", Flags: net.FlagUp})
+			bond.Mode = netlink.BOND_MODE_802_3AD
+			links[0].MasterIndex = 1000
+			links[1].MasterIndex = 1000
+			mocked.On("LinkByName", "net1").Return(bond, nil)
+			mocked.On("LinkByName", "net1_0").Return(links[0], nil)
+			mocked.On("LinkByName", "net1_1").Return(links[1], nil)
+
+			sm := sriovManager{nLink: mocked}
+			drifted, err := sm.CheckBond(netconf, "net1", []string{"net1_0", "net1_1"}, targetNS)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drifted).To(BeEmpty())
+		})
+
+		It("Reports the drift of the bond and its links", func() {
+			bond := netlink.NewLinkBond(netlink.LinkAttrs{Index: 1000, Name: "net1"})
+			bond.Mode = netlink.BOND_MODE_ACTIVE_BACKUP
+			links[0].MasterIndex = 1000
+			mocked.On("LinkByName", "net1").Return(bond, nil)
+			mocked.On("LinkByName", "net1_0").Return(links[0], nil)
+			mocked.On("LinkByName", "net1_1").Return(links[1], nil)
+
+			sm := sriovManager{nLink: mocked}
+			drifted, err := sm.CheckBond(netconf, "net1", []string{"net1_0", "net1_1"}, targetNS)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drifted).To(Equal([]string{
+				"mode: expected 802.3ad, found active-backup",
+				"state: expected up, found down",
+				"links: link net1_1 is not enslaved",
+			}))
+		})
+
+		It("Reports a missing bond", func() {
+			mocked.On("LinkByName", "net1").Return(nil, netlink.LinkNotFoundError{})
+
+			sm := sriovManager{nLink: mocked}
+			drifted, err := sm.CheckBond(netconf, "net1", []string{"net1_0", "net1_1"}, targetNS)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drifted).To(HaveLen(1))
+			Expect(drifted[0]).To(HavePrefix("name: bond net1 not found"))
+		})
 	})
 })
@@ -14,6 +14,50 @@ const (
 	Proto8021ad = "802.1ad"
 )
 
+// vDPA device types a VF can be exposed as
+const (
+	VdpaTypeVhost  = "vhost"
+	VdpaTypeVirtio = "virtio"
+)
+
+// Mechanisms enforcing the tx rate of a VF: the rate limiter of the PF, or a tc qdisc on the VF netdevice in the pod
+const (
+	TxRateModeHardware = "hardware"
+	TxRateModeSoftware = "software"
+)
+
+// Bonding modes of a pod interface made of several VFs
+const (
+	BondModeActiveBackup = "active-backup"
+	BondMode8023AD       = "802.3ad"
+)
+
+// Identities of an attachment a MAC address can be generated from
+const (
+	MACSourceContainerID = "containerID"
+	MACSourcePod         = "pod"
+	MACSourceNetwork     = "network"
+)
+
+// Plugin specific error codes, see https://www.cni.dev/docs/spec/#error
+const (
+	// ErrPluginNotAvailable is the well known STATUS error code for a plugin that cannot service ADD requests.
+	// It is not defined by the CNI library yet.
+	ErrPluginNotAvailable uint = 50
+	// ErrVfConfigDrift is returned by CHECK when the VF no longer matches the cached NetConf
+	ErrVfConfigDrift uint = 100
+	// ErrVfAllocated is returned when the requested VF, or every VF of a pool, is already allocated
+	ErrVfAllocated uint = 101
+	// ErrLockTimeout is returned when the VF lock could not be acquired in time, the request can be retried
+	ErrLockTimeout uint = 102
+	// ErrPfNotFound is returned when the PF of the VF can not be found
+	ErrPfNotFound uint = 103
+	// ErrNetlinkFailure is returned when configuring the VF through netlink fails
+	ErrNetlinkFailure uint = 104
+	// ErrMACCollision is returned when the generated MAC address is already set on another VF of the PF
+	ErrMACCollision uint = 105
+)
+
 // VlanProtoInt maps VLAN protocol strings to their integer values
 // TODO: Temporary workaround for netlink bug on big-endian systems.
 // Remove once netlink is updated to a version containing https://github.com/vishvananda/netlink/pull/1155
@@ -24,6 +68,131 @@ var VlanProtoInt = func() map[string]int {
 	return map[string]int{Proto8021q: 0x8100, Proto8021ad: 0x88a8}
 }()
 
+// Device information types and version, see https://github.com/k8snetworkplumbingwg/device-info-spec
+const (
+	DeviceInfoTypePCI  = "pci"
+	DeviceInfoTypeVDPA = "vdpa"
+	DeviceInfoTypeAux  = "auxiliary"
+	DeviceInfoVersion  = "1.1.0"
+)
+
+// DeviceInfo is the device information of a network attachment published for the meta plugin
+type DeviceInfo struct {
+	Type    string          `json:"type"`
+	Version string          `json:"version"`
+	Pci     *PciDeviceInfo  `json:"pci,omitempty"`
+	Vdpa    *VdpaDeviceInfo `json:"vdpa,omitempty"`
+	Aux     *AuxDeviceInfo  `json:"auxiliary,omitempty"`
+	// information the device info spec has no key for
+	Sriov *SriovDeviceInfo `json:"sriov-cni,omitempty"`
+}
+
+// PciDeviceInfo describes the PCI device of a DeviceInfo
+type PciDeviceInfo struct {
+	PciAddress   string `json:"pci-address"`
+	PfPciAddress string `json:"pf-pci-address,omitempty"`
+	RdmaDevice   string `json:"rdma-device,omitempty"`
+	Representor  string `json:"representor-device,omitempty"`
+}
+
+// SriovDeviceInfo describes the VF of a DeviceInfo beyond the keys of the device info spec
+type SriovDeviceInfo struct {
+	PfName      string `json:"pf-name,omitempty"`
+	VfID        int    `json:"vf-id"`
+	Dpdk        bool   `json:"dpdk"`
+	TxRateLimit string `json:"tx-rate-limiter,omitempty"`
+	// pci addresses of the VFs of the links of a bond, in link order
+	BondLinks []string `json:"bond-links,omitempty"`
+}
+
+// VdpaDeviceInfo describes the vDPA device of a DeviceInfo
+type VdpaDeviceInfo struct {
+	ParentDevice string `json:"parent-device,omitempty"`
+	Driver       string `json:"driver"`
+	Path         string `json:"path,omitempty"`
+	PciAddress   string `json:"pci-address,omitempty"`
+}
+
+// AuxDeviceInfo describes the auxiliary device, e.g. a Scalable Function, of a DeviceInfo
+type AuxDeviceInfo struct {
+	DeviceID     string `json:"device-id"`
+	PfPciAddress string `json:"pf-pci-address,omitempty"`
+	PfName       string `json:"pf-name,omitempty"`
+	SfNum        int    `json:"sf-num"`
+}
+
+// EthtoolConf holds ethtool features, ring sizes and channel counts of a netdevice
+type EthtoolConf struct {
+	Features map[string]bool  `json:"features,omitempty"`
+	Rings    *EthtoolRings    `json:"rings,omitempty"`
+	Channels *EthtoolChannels `json:"channels,omitempty"`
+}
+
+// EthtoolRings holds ring sizes of a netdevice, a nil size is left untouched
+type EthtoolRings struct {
+	Rx *uint32 `json:"rx,omitempty"`
+	Tx *uint32 `json:"tx,omitempty"`
+}
+
+// EthtoolChannels holds channel counts of a netdevice, a nil count is left untouched
+type EthtoolChannels struct {
+	Rx       *uint32 `json:"rx,omitempty"`
+	Tx       *uint32 `json:"tx,omitempty"`
+	Other    *uint32 `json:"other,omitempty"`
+	Combined *uint32 `json:"combined,omitempty"`
+}
+
+// VlanConf is an 802.1Q sub-interface created on top of the VF netdevice in the pod
+type VlanConf struct {
+	ID   int             `json:"id"`
+	Name string          `json:"name,omitempty"` // defaults to <pod interface name>.<id>
+	IPAM json.RawMessage `json:"ipam,omitempty"` // IPAM configuration of the sub-interface
+}
+
+// IfName returns the name of the sub-interface of the pod interface podIfName
+func (v *VlanConf) IfName(podIfName string) string {
+	if v.Name != "" {
+		return v.Name
+	}
+	return fmt.Sprintf("%s.%d", podIfName, v.ID)
+}
+
+// IPAMType returns the IPAM plugin type of the sub-interface, or an empty string if it has no IPAM configuration
+func (v *VlanConf) IPAMType() string {
+	ipam := types.IPAM{}
+	if len(v.IPAM) == 0 || json.Unmarshal(v.IPAM, &ipam) != nil {
+		return ""
+	}
+	return ipam.Type
+}
+
+// BondConf bonds the VFs of several links into a single pod interface
+type BondConf struct {
+	Mode   string     `json:"mode"`             // active-backup|802.3ad
+	Miimon *int       `json:"miimon,omitempty"` // link monitoring interval in ms, 100 if not set
+	Links  []BondLink `json:"links"`
+}
+
+// BondLink selects the VF of a bond link the same way the VF of a single pod interface is selected
+type BondLink struct {
+	DeviceID string   `json:"deviceID,omitempty"`
+	Master   string   `json:"master,omitempty"`
+	VFIndex  *int     `json:"vfIndex,omitempty"`
+	PFNames  []string `json:"pfNames,omitempty"`
+	VFRange  string   `json:"vfRange,omitempty"`
+}
+
+// LinkIfName returns the pod interface name of the i-th link of the bond bondIfName
+func (b *BondConf) LinkIfName(bondIfName string, i int) string {
+	return fmt.Sprintf("%.12s_%d", bondIfName, i)
+}
+
+// MACGenerationConf derives the MAC address of the VF from the identity of the attachment
+type MACGenerationConf struct {
+	Prefix string `json:"prefix,omitempty"` // leading octets of the MAC address, e.g. "0a:58", "02" if not set
+	Source string `json:"source"`           // containerID|pod|network
+}
+
 // VfState represents the state of the VF
 type VfState struct {
 	HostIFName   string
@@ -38,6 +207,14 @@ type VfState struct {
 	MaxTxRate    int
 	LinkState    uint32
 	MTU          int
+	NodeGUID     string
+	PortGUID     string
+	VdpaDriver   string
+	// Representor name and admin state before the VF was set up, if the PF eswitch is in switchdev mode
+	RepresentorName string
+	RepresentorUp   bool
+	// Ethtool settings of the VF netdevice before the ethtool configuration was applied
+	Ethtool *EthtoolConf
 }
 
 // FillFromVfInfo - Fill attributes according to the provided netlink.VfInfo struct
@@ -64,22 +241,89 @@ type SriovNetConf struct {
 	DPDKMode      bool    `json:"-"`
 	Master        string
 	MAC           string
-	MTU           *int    // interface MTU
-	Vlan          *int    `json:"vlan"`
-	VlanQoS       *int    `json:"vlanQoS"`
-	VlanProto     *string `json:"vlanProto"` // 802.1ad|802.1q
-	DeviceID      string  `json:"deviceID"`  // PCI address of a VF in valid sysfs format
+	GUID          string   `json:"guid,omitempty"` // node and port GUID of an InfiniBand VF
+	MTU           *int     // interface MTU
+	RequestedMTU  *int     `json:"mtu,omitempty"` // MTU to set on the VF, must not exceed the PF MTU
+	Vlan          *int     `json:"vlan"`
+	VlanQoS       *int     `json:"vlanQoS"`
+	VlanProto     *string  `json:"vlanProto"`         // 802.1ad|802.1q
+	DeviceID      string   `json:"deviceID"`          // PCI address of a VF in valid sysfs format
+	VFIndex       *int     `json:"vfIndex"`           // index of a VF of Master, used when no deviceID is given
+	PFNames       []string `json:"pfNames,omitempty"` // PFs to pick a free VF from when no deviceID is given
+	VFRange       string   `json:"vfRange,omitempty"` // range of VF indexes of Master to pick a free VF from, e.g. "0-7"
 	VFID          int
+	SFNum         *int   // number of the Scalable Function given as deviceID, nil for a VF
+	RdmaDevice    string // RDMA device of the VF, if any
+	VdpaType      string `json:"vdpaType,omitempty"` // vhost|virtio, expose the VF through a vDPA device
+	VdpaDevice    string // vDPA device of the VF
+	VdpaCreated   bool   // the vDPA device was created by the plugin and is deleted on DEL
+	VdpaPath      string // vhost-vdpa character device of the VF
+	Representor   string // representor netdevice of the VF, if the PF eswitch is in switchdev mode
 	MinTxRate     *int   `json:"min_tx_rate"`          // Mbps, 0 = disable rate limiting
 	MaxTxRate     *int   `json:"max_tx_rate"`          // Mbps, 0 = disable rate limiting
+	TxRateMode    string `json:"txRateMode,omitempty"` // hardware|software, how min_tx_rate and max_tx_rate are enforced
+	TxRateLimiter string // mechanism enforcing the tx rate of the VF, hardware or software
 	SpoofChk      string `json:"spoofchk,omitempty"`   // on|off
 	Trust         string `json:"trust,omitempty"`      // on|off
+	AllMulti      string `json:"allmulti,omitempty"`   // on|off, allmulticast mode of the VF in the pod, requires trust on
+	Promisc       string `json:"promisc,omitempty"`    // on|off, promiscuous mode of the VF in the pod, requires trust on
 	LinkState     string `json:"link_state,omitempty"` // auto|enable|disable
 	RuntimeConfig struct {
-		Mac string `json:"mac,omitempty"`
+		Mac  string `json:"mac,omitempty"`
+		GUID string `json:"guid,omitempty"`
+		// file the meta plugin reads the device information from, instead of the default directory
+		CNIDeviceInfoFile string `json:"CNIDeviceInfoFile,omitempty"`
 	} `json:"runtimeConfig,omitempty"`
 	LogLevel string `json:"logLevel,omitempty"`
 	LogFile  string `json:"logFile,omitempty"`
+	// RenameRepresentor gives the VF representor a name derived from the container ID
+	RenameRepresentor bool `json:"renameRepresentor,omitempty"`
+	// Ethtool settings applied to the VF netdevice in the pod
+	Ethtool *EthtoolConf `json:"ethtool,omitempty"`
+	// VLAN sub-interfaces created on top of the VF netdevice in the pod
+	Vlans []VlanConf `json:"vlans,omitempty"`
+	// VLANs the VF is allowed to send and receive tagged, e.g. "100-200,300", instead of a single port VLAN
+	VlanTrunk string `json:"vlanTrunk,omitempty"`
+	// Links bonded into the pod interface, each of them a VF set up like a single pod interface
+	Bond *BondConf `json:"bond,omitempty"`
+	// Pod bond the VF is a link of, if any
+	BondIfName string
+	// Configure the IPs of prevResult that are not bound to an interface on the VF, when chained after a plugin
+	// that allocated them
+	UsePrevResultIPs bool `json:"usePrevResultIPs,omitempty"`
+	// Reject MAC addresses that are not locally administered, so that they can not clash with the burned-in
+	// addresses of other devices
+	LocalMACOnly bool `json:"localMACOnly,omitempty"`
+	// Fail on the keys of the netconf the plugin does not know, instead of ignoring them
+	Strict bool `json:"strict,omitempty"`
+	// Generate the MAC address of the VF when none is requested
+	MACGeneration *MACGenerationConf `json:"macGeneration,omitempty"`
+	// MAC was generated, it must not be set on another VF of the PF yet
+	MACGenerated bool
+	// Attachment the NetConf was cached for, used to garbage collect stale cache entries
+	ContainerID string
+	IfName      string
+	NetNS       string
+	// Pod of the attachment, if the runtime passed it in CNI_ARGS
+	PodNamespace string
+	PodName      string
+	PodUID       string
+}
+
+// HasSoftwareTxRate returns true if the max tx rate of the VF is enforced by a tc qdisc in the pod
+func (n *NetConf) HasSoftwareTxRate() bool {
+	return n.TxRateLimiter == TxRateModeSoftware && n.MaxTxRate != nil && *n.MaxTxRate > 0
+}
+
+// HasPodNetdev returns true if the VF is handed to the pod as a kernel netdevice, either its own
+// or the one of its virtio vDPA device
+func (n *NetConf) HasPodNetdev() bool {
+	return !n.DPDKMode && n.VdpaType != VdpaTypeVhost
+}
+
+// IsSF returns true if the device is a Scalable Function rather than a VF
+func (n *NetConf) IsSF() bool {
+	return n.SFNum != nil
 }
 
 func (n *NetConf) MarshalJSON() ([]byte, error) {
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"github.com/safchain/ethtool"
+)
+
+// Mocked ethtool interface, this is required for unit tests
+
+// EthtoolManager is an interface to mock ethtool library
+type EthtoolManager interface {
+	Features(string) (map[string]bool, error)
+	Change(string, map[string]bool) error
+	GetRing(string) (ethtool.Ring, error)
+	SetRing(string, ethtool.Ring) (ethtool.Ring, error)
+	GetChannels(string) (ethtool.Channels, error)
+	SetChannels(string, ethtool.Channels) (ethtool.Channels, error)
+}
+
+// MyEthtool EthtoolManager
+// Every call opens its own ethtool socket, so that it operates on the netns the caller is in.
+type MyEthtool struct {
+	EthtoolManager
+}
+
+var ethtoolLib EthtoolManager = &MyEthtool{}
+
+func GetEthtoolManager() EthtoolManager {
+	return ethtoolLib
+}
+
+// Features implements EthtoolManager
+func (e *MyEthtool) Features(intf string) (map[string]bool, error) {
+	et, err := ethtool.NewEthtool()
+	if err != nil {
+		return nil, err
+	}
+	defer et.Close()
+	return et.Features(intf)
+}
+
+// Change implements EthtoolManager
+func (e *MyEthtool) Change(intf string, config map[string]bool) error {
+	et, err := ethtool.NewEthtool()
+	if err != nil {
+		return err
+	}
+	defer et.Close()
+	return et.Change(intf, config)
+}
+
+// GetRing implements EthtoolManager
+func (e *MyEthtool) GetRing(intf string) (ethtool.Ring, error) {
+	et, err := ethtool.NewEthtool()
+	if err != nil {
+		return ethtool.Ring{}, err
+	}
+	defer et.Close()
+	return et.GetRing(intf)
+}
+
+// SetRing implements EthtoolManager
+func (e *MyEthtool) SetRing(intf string, ring ethtool.Ring) (ethtool.Ring, error) {
+	et, err := ethtool.NewEthtool()
+	if err != nil {
+		return ethtool.Ring{}, err
+	}
+	defer et.Close()
+	return et.SetRing(intf, ring)
+}
+
+// GetChannels implements EthtoolManager
+func (e *MyEthtool) GetChannels(intf string) (ethtool.Channels, error) {
+	et, err := ethtool.NewEthtool()
+	if err != nil {
+		return ethtool.Channels{}, err
+	}
+	defer et.Close()
+	return et.GetChannels(intf)
+}
+
+// SetChannels implements EthtoolManager
+func (e *MyEthtool) SetChannels(intf string, channels ethtool.Channels) (ethtool.Channels, error) {
+	et, err := ethtool.NewEthtool()
+	if err != nil {
+		return ethtool.Channels{}, err
+	}
+	defer et.Close()
+	return et.SetChannels(intf, channels)
+}
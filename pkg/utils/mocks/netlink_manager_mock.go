@@ -15,6 +15,24 @@ type NetlinkManager struct {
 	mock.Mock
 }
 
+// LinkAdd provides a mock function with given fields: _a0
+func (_m *NetlinkManager) LinkAdd(_a0 netlink.Link) error {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LinkAdd")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(netlink.Link) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // LinkByName provides a mock function with given fields: _a0
 func (_m *NetlinkManager) LinkByName(_a0 string) (netlink.Link, error) {
 	ret := _m.Called(_a0)
@@ -45,6 +63,24 @@ func (_m *NetlinkManager) LinkByName(_a0 string) (netlink.Link, error) {
 	return r0, r1
 }
 
+// LinkDel provides a mock function with given fields: _a0
+func (_m *NetlinkManager) LinkDel(_a0 netlink.Link) error {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LinkDel")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(netlink.Link) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // LinkDelAltName provides a mock function with given fields: _a0, _a1
 func (_m *NetlinkManager) LinkDelAltName(_a0 netlink.Link, _a1 string) error {
 	ret := _m.Called(_a0, _a1)
@@ -63,6 +99,42 @@ func (_m *NetlinkManager) LinkDelAltName(_a0 netlink.Link, _a1 string) error {
 	return r0
 }
 
+// LinkSetAllmulticastOff provides a mock function with given fields: _a0
+func (_m *NetlinkManager) LinkSetAllmulticastOff(_a0 netlink.Link) error {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LinkSetAllmulticastOff")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(netlink.Link) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// LinkSetAllmulticastOn provides a mock function with given fields: _a0
+func (_m *NetlinkManager) LinkSetAllmulticastOn(_a0 netlink.Link) error {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LinkSetAllmulticastOn")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(netlink.Link) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // LinkSetDown provides a mock function with given fields: _a0
 func (_m *NetlinkManager) LinkSetDown(_a0 netlink.Link) error {
 	ret := _m.Called(_a0)
@@ -117,6 +189,24 @@ func (_m *NetlinkManager) LinkSetMTU(_a0 netlink.Link, _a1 int) error {
 	return r0
 }
 
+// LinkSetMaster provides a mock function with given fields: _a0, _a1
+func (_m *NetlinkManager) LinkSetMaster(_a0 netlink.Link, _a1 netlink.Link) error {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LinkSetMaster")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(netlink.Link, netlink.Link) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // LinkSetName provides a mock function with given fields: _a0, _a1
 func (_m *NetlinkManager) LinkSetName(_a0 netlink.Link, _a1 string) error {
 	ret := _m.Called(_a0, _a1)
@@ -189,6 +279,42 @@ func (_m *NetlinkManager) LinkSetVfHardwareAddr(_a0 netlink.Link, _a1 int, _a2 n
 	return r0
 }
 
+// LinkSetVfNodeGUID provides a mock function with given fields: _a0, _a1, _a2
+func (_m *NetlinkManager) LinkSetVfNodeGUID(_a0 netlink.Link, _a1 int, _a2 net.HardwareAddr) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LinkSetVfNodeGUID")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(netlink.Link, int, net.HardwareAddr) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// LinkSetVfPortGUID provides a mock function with given fields: _a0, _a1, _a2
+func (_m *NetlinkManager) LinkSetVfPortGUID(_a0 netlink.Link, _a1 int, _a2 net.HardwareAddr) error {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	if len(ret) == 0 {
+		panic("no return value specified for LinkSetVfPortGUID")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(netlink.Link, int, net.HardwareAddr) error); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // LinkSetVfRate provides a mock function with given fields: _a0, _a1, _a2, _a3
 func (_m *NetlinkManager) LinkSetVfRate(_a0 netlink.Link, _a1 int, _a2 int, _a3 int) error {
 	ret := _m.Called(_a0, _a1, _a2, _a3)
@@ -279,6 +405,190 @@ func (_m *NetlinkManager) LinkSetVfVlanQosProto(_a0 netlink.Link, _a1 int, _a2 i
 	return r0
 }
 
+// QdiscDel provides a mock function with given fields: _a0
+func (_m *NetlinkManager) QdiscDel(_a0 netlink.Qdisc) error {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for QdiscDel")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(netlink.Qdisc) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// QdiscReplace provides a mock function with given fields: _a0
+func (_m *NetlinkManager) QdiscReplace(_a0 netlink.Qdisc) error {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for QdiscReplace")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(netlink.Qdisc) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RdmaLinkByName provides a mock function with given fields: _a0
+func (_m *NetlinkManager) RdmaLinkByName(_a0 string) (*netlink.RdmaLink, error) {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RdmaLinkByName")
+	}
+
+	var r0 *netlink.RdmaLink
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*netlink.RdmaLink, error)); ok {
+		return rf(_a0)
+	}
+	if rf, ok := ret.Get(0).(func(string) *netlink.RdmaLink); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*netlink.RdmaLink)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RdmaLinkSetNsFd provides a mock function with given fields: _a0, _a1
+func (_m *NetlinkManager) RdmaLinkSetNsFd(_a0 *netlink.RdmaLink, _a1 uint32) error {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RdmaLinkSetNsFd")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*netlink.RdmaLink, uint32) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RdmaSystemGetNetnsMode provides a mock function with no fields
+func (_m *NetlinkManager) RdmaSystemGetNetnsMode() (string, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for RdmaSystemGetNetnsMode")
+	}
+
+	var r0 string
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (string, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetPromiscOff provides a mock function with given fields: _a0
+func (_m *NetlinkManager) SetPromiscOff(_a0 netlink.Link) error {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetPromiscOff")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(netlink.Link) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetPromiscOn provides a mock function with given fields: _a0
+func (_m *NetlinkManager) SetPromiscOn(_a0 netlink.Link) error {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetPromiscOn")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(netlink.Link) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// VDPADelDev provides a mock function with given fields: _a0
+func (_m *NetlinkManager) VDPADelDev(_a0 string) error {
+	ret := _m.Called(_a0)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VDPADelDev")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// VDPANewDev provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *NetlinkManager) VDPANewDev(_a0 string, _a1 string, _a2 string, _a3 netlink.VDPANewDevParams) error {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VDPANewDev")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string, netlink.VDPANewDevParams) error); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewNetlinkManager creates a new instance of NetlinkManager. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewNetlinkManager(t interface {
@@ -11,6 +11,8 @@ import (
 // NetlinkManager is an interface to mock nelink library
 type NetlinkManager interface {
 	LinkByName(string) (netlink.Link, error)
+	LinkAdd(netlink.Link) error
+	LinkDel(netlink.Link) error
 	LinkSetVfVlanQosProto(netlink.Link, int, int, int, int) error
 	LinkSetVfHardwareAddr(netlink.Link, int, net.HardwareAddr) error
 	LinkSetHardwareAddr(netlink.Link, net.HardwareAddr) error
@@ -22,8 +24,22 @@ type NetlinkManager interface {
 	LinkSetVfSpoofchk(netlink.Link, int, bool) error
 	LinkSetVfTrust(netlink.Link, int, bool) error
 	LinkSetVfState(netlink.Link, int, uint32) error
+	LinkSetVfNodeGUID(netlink.Link, int, net.HardwareAddr) error
+	LinkSetVfPortGUID(netlink.Link, int, net.HardwareAddr) error
 	LinkSetMTU(netlink.Link, int) error
+	LinkSetMaster(netlink.Link, netlink.Link) error
+	LinkSetAllmulticastOn(netlink.Link) error
+	LinkSetAllmulticastOff(netlink.Link) error
+	SetPromiscOn(netlink.Link) error
+	SetPromiscOff(netlink.Link) error
+	QdiscReplace(netlink.Qdisc) error
+	QdiscDel(netlink.Qdisc) error
 	LinkDelAltName(netlink.Link, string) error
+	RdmaSystemGetNetnsMode() (string, error)
+	RdmaLinkByName(string) (*netlink.RdmaLink, error)
+	RdmaLinkSetNsFd(*netlink.RdmaLink, uint32) error
+	VDPANewDev(string, string, string, netlink.VDPANewDevParams) error
+	VDPADelDev(string) error
 }
 
 // MyNetlink NetlinkManager
@@ -42,6 +58,16 @@ func (n *MyNetlink) LinkByName(name string) (netlink.Link, error) {
 	return netlink.LinkByName(name)
 }
 
+// LinkAdd using NetlinkManager
+func (n *MyNetlink) LinkAdd(link netlink.Link) error {
+	return netlink.LinkAdd(link)
+}
+
+// LinkDel using NetlinkManager
+func (n *MyNetlink) LinkDel(link netlink.Link) error {
+	return netlink.LinkDel(link)
+}
+
 // LinkSetVfVlanQosProto sets VLAN ID, QoS and Proto field for given VF using NetlinkManager
 func (n *MyNetlink) LinkSetVfVlanQosProto(link netlink.Link, vf, vlan, qos, proto int) error {
 	return netlink.LinkSetVfVlanQosProto(link, vf, vlan, qos, proto)
@@ -97,12 +123,82 @@ func (n *MyNetlink) LinkSetVfState(link netlink.Link, vf int, state uint32) erro
 	return netlink.LinkSetVfState(link, vf, state)
 }
 
+// LinkSetVfNodeGUID using NetlinkManager
+func (n *MyNetlink) LinkSetVfNodeGUID(link netlink.Link, vf int, nodeGUID net.HardwareAddr) error {
+	return netlink.LinkSetVfNodeGUID(link, vf, nodeGUID)
+}
+
+// LinkSetVfPortGUID using NetlinkManager
+func (n *MyNetlink) LinkSetVfPortGUID(link netlink.Link, vf int, portGUID net.HardwareAddr) error {
+	return netlink.LinkSetVfPortGUID(link, vf, portGUID)
+}
+
 // LinkSetMTU using NetlinkManager
 func (n *MyNetlink) LinkSetMTU(link netlink.Link, mtu int) error {
 	return netlink.LinkSetMTU(link, mtu)
 }
 
+// LinkSetMaster using NetlinkManager
+func (n *MyNetlink) LinkSetMaster(link, master netlink.Link) error {
+	return netlink.LinkSetMaster(link, master)
+}
+
+// LinkSetAllmulticastOn using NetlinkManager
+func (n *MyNetlink) LinkSetAllmulticastOn(link netlink.Link) error {
+	return netlink.LinkSetAllmulticastOn(link)
+}
+
+// LinkSetAllmulticastOff using NetlinkManager
+func (n *MyNetlink) LinkSetAllmulticastOff(link netlink.Link) error {
+	return netlink.LinkSetAllmulticastOff(link)
+}
+
+// SetPromiscOn using NetlinkManager
+func (n *MyNetlink) SetPromiscOn(link netlink.Link) error {
+	return netlink.SetPromiscOn(link)
+}
+
+// SetPromiscOff using NetlinkManager
+func (n *MyNetlink) SetPromiscOff(link netlink.Link) error {
+	return netlink.SetPromiscOff(link)
+}
+
 // LinkDelAltName using NetlinkManager
 func (n *MyNetlink) LinkDelAltName(link netlink.Link, altName string) error {
 	return netlink.LinkDelAltName(link, altName)
 }
+
+// RdmaSystemGetNetnsMode using NetlinkManager
+func (n *MyNetlink) RdmaSystemGetNetnsMode() (string, error) {
+	return netlink.RdmaSystemGetNetnsMode()
+}
+
+// RdmaLinkByName using NetlinkManager
+func (n *MyNetlink) RdmaLinkByName(name string) (*netlink.RdmaLink, error) {
+	return netlink.RdmaLinkByName(name)
+}
+
+// RdmaLinkSetNsFd using NetlinkManager
+func (n *MyNetlink) RdmaLinkSetNsFd(link *netlink.RdmaLink, fd uint32) error {
+	return netlink.RdmaLinkSetNsFd(link, fd)
+}
+
+// VDPANewDev using NetlinkManager
+func (n *MyNetlink) VDPANewDev(name, mgmtBus, mgmtName string, params netlink.VDPANewDevParams) error {
+	return netlink.VDPANewDev(name, mgmtBus, mgmtName, params)
+}
+
+// VDPADelDev using NetlinkManager
+func (n *MyNetlink) VDPADelDev(name string) error {
+	return netlink.VDPADelDev(name)
+}
+
+// QdiscReplace using NetlinkManager
+func (n *MyNetlink) QdiscReplace(qdisc netlink.Qdisc) error {
+	return netlink.QdiscReplace(qdisc)
+}
+
+// QdiscDel using NetlinkManager
+func (n *MyNetlink) QdiscDel(qdisc netlink.Qdisc) error {
+	return netlink.QdiscDel(qdisc)
+}
@@ -1,15 +1,19 @@
 package utils
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"time"
 
+	"github.com/containernetworking/cni/pkg/types"
 	"github.com/containernetworking/plugins/pkg/ns"
 	"golang.org/x/sys/unix"
 
 	"github.com/k8snetworkplumbingwg/sriov-cni/pkg/logging"
+	sriovtypes "github.com/k8snetworkplumbingwg/sriov-cni/pkg/types"
 )
 
 const pciLockAcquireTimeout = 60 * time.Second
@@ -22,19 +26,21 @@ type PCIAllocation interface {
 
 type PCIAllocator struct {
 	dataDir string
+	// file descriptors of the locks held by this allocator
+	locks map[string]int
 }
 
 // NewPCIAllocator returns a new PCI allocator
 // it will use the <dataDir>/pci folder to store the information about allocated PCI addresses
 func NewPCIAllocator(dataDir string) *PCIAllocator {
-	return &PCIAllocator{dataDir: filepath.Join(dataDir, "pci")}
+	return &PCIAllocator{dataDir: filepath.Join(dataDir, "pci"), locks: make(map[string]int)}
 }
 
-// Lock gets an exclusive lock on the given PCI address, ensuring there is no other process configuring / or de-configuring the same device.
-func (p *PCIAllocator) Lock(pciAddress string) error {
+// openLockFile opens the lock file of the given PCI address, creating it if needed
+func (p *PCIAllocator) openLockFile(pciAddress string) (int, string, error) {
 	lockDir := filepath.Join(p.dataDir, "vf_lock")
 	if err := os.MkdirAll(lockDir, 0o600); err != nil {
-		return fmt.Errorf("failed to create the sriov lock directory(%q): %v", lockDir, err)
+		return -1, "", fmt.Errorf("failed to create the sriov lock directory(%q): %v", lockDir, err)
 	}
 
 	lockPath := filepath.Join(lockDir, fmt.Sprintf("%s.lock", pciAddress))
@@ -44,7 +50,17 @@ func (p *PCIAllocator) Lock(pciAddress string) error {
 	// unix.O_CLOEXEC - Automatically close the file on exit. This is useful to keep the flock until the process exits
 	fd, err := unix.Open(lockPath, unix.O_CREAT|unix.O_RDONLY|unix.O_CLOEXEC, 0o600)
 	if err != nil {
-		return fmt.Errorf("failed to open PCI file [%s] for locking: %w", lockPath, err)
+		return -1, "", fmt.Errorf("failed to open PCI file [%s] for locking: %w", lockPath, err)
+	}
+
+	return fd, lockPath, nil
+}
+
+// Lock gets an exclusive lock on the given PCI address, ensuring there is no other process configuring / or de-configuring the same device.
+func (p *PCIAllocator) Lock(pciAddress string) error {
+	fd, lockPath, err := p.openLockFile(pciAddress)
+	if err != nil {
+		return err
 	}
 
 	errCh := make(chan error)
@@ -58,35 +74,133 @@ func (p *PCIAllocator) Lock(pciAddress string) error {
 		if err != nil {
 			return fmt.Errorf("failed to flock PCI file [%s]: %w", lockPath, err)
 		}
+		p.locks[pciAddress] = fd
 		return nil
 
 	case <-time.After(pciLockAcquireTimeout):
-		return fmt.Errorf("time out while waiting to acquire exclusive lock on [%s]", lockPath)
+		return types.NewError(sriovtypes.ErrLockTimeout, fmt.Sprintf("time out while waiting to acquire exclusive lock on [%s]", lockPath), "")
+	}
+}
+
+// TryLock gets an exclusive lock on the given PCI address without waiting.
+// It returns false if another process holds the lock.
+func (p *PCIAllocator) TryLock(pciAddress string) (bool, error) {
+	fd, lockPath, err := p.openLockFile(pciAddress)
+	if err != nil {
+		return false, err
+	}
+
+	// unix.LOCK_NB - Return instead of blocking if the lock is held
+	if err = unix.Flock(fd, unix.LOCK_EX|unix.LOCK_NB); err != nil {
+		_ = unix.Close(fd)
+		if errors.Is(err, unix.EWOULDBLOCK) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to flock PCI file [%s]: %w", lockPath, err)
 	}
+
+	p.locks[pciAddress] = fd
+	return true, nil
+}
+
+// Unlock releases a lock on the given PCI address obtained by Lock or TryLock
+func (p *PCIAllocator) Unlock(pciAddress string) error {
+	fd, ok := p.locks[pciAddress]
+	if !ok {
+		return fmt.Errorf("no lock held on PCI address %s", pciAddress)
+	}
+	delete(p.locks, pciAddress)
+
+	if err := unix.Flock(fd, unix.LOCK_UN); err != nil {
+		_ = unix.Close(fd)
+		return fmt.Errorf("failed to unlock PCI address %s: %w", pciAddress, err)
+	}
+
+	return unix.Close(fd)
+}
+
+// PCIAllocationInfo is the attachment a PCI address is allocated to.
+//
+// The file named after the PCI address holds the network namespace path only, the format older versions of the
+// plugin read: they check the namespace with ns.GetNS and would release the PCI address on any other content, e.g.
+// after a downgrade. The rest of the allocation is kept in a metadata file next to it, "<pci address>.json", that
+// older versions ignore. A metadata file is only valid for the network namespace of the allocation file.
+type PCIAllocationInfo struct {
+	// network namespace the PCI address is allocated to, the allocation is released once it is gone
+	NetNS        string `json:"netns"`
+	ContainerID  string `json:"containerID,omitempty"`
+	IfName       string `json:"ifName,omitempty"`
+	PodNamespace string `json:"podNamespace,omitempty"`
+	PodName      string `json:"podName,omitempty"`
+	PodUID       string `json:"podUID,omitempty"`
+}
+
+// metadataPath returns the path of the metadata file of the allocation of the PCI address
+func (p *PCIAllocator) metadataPath(pciAddress string) string {
+	return filepath.Join(p.dataDir, pciAddress+".json")
 }
 
 // SaveAllocatedPCI creates a file with the pci address as a name and the network namespace as the content
 // return error if the file was not created
 func (p *PCIAllocator) SaveAllocatedPCI(pciAddress, netNS string) error {
+	return p.SaveAllocation(pciAddress, &PCIAllocationInfo{NetNS: netNS})
+}
+
+// SaveAllocation creates a file with the pci address as a name and the network namespace as the content, and the
+// metadata file with the attachment the pci address is allocated to
+// return error if the files were not created
+func (p *PCIAllocator) SaveAllocation(pciAddress string, allocation *PCIAllocationInfo) error {
 	if err := os.MkdirAll(p.dataDir, 0o600); err != nil {
 		return fmt.Errorf("failed to create the sriov data directory(%q): %v", p.dataDir, err)
 	}
 
 	pciPath := filepath.Join(p.dataDir, pciAddress)
-	err := os.WriteFile(pciPath, []byte(netNS), 0o600)
+	err := os.WriteFile(pciPath, []byte(allocation.NetNS), 0o600)
 	if err != nil {
 		return fmt.Errorf("failed to write used PCI address lock file in the path(%q): %v", pciPath, err)
 	}
 
-	return err
+	dat, err := json.Marshal(allocation)
+	if err != nil {
+		return fmt.Errorf("failed to serialize the allocation of PCI address %s: %v", pciAddress, err)
+	}
+	metadataPath := p.metadataPath(pciAddress)
+	if err = os.WriteFile(metadataPath, dat, 0o600); err != nil {
+		return fmt.Errorf("failed to write the PCI address metadata file in the path(%q): %v", metadataPath, err)
+	}
+
+	return nil
 }
 
-// DeleteAllocatedPCI Remove the allocated PCI file
-// return error if the file doesn't exist
+// ReadAllocation returns the attachment the PCI address is allocated to. Only the network namespace is known for
+// allocations written by older versions of the plugin, which leave no metadata file or a stale one behind.
+func (p *PCIAllocator) ReadAllocation(pciAddress string) (*PCIAllocationInfo, error) {
+	pciPath := filepath.Join(p.dataDir, pciAddress)
+	dat, err := os.ReadFile(pciPath) //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("failed to read for pci address file for %s: %w", pciPath, err)
+	}
+	netNS := string(dat)
+
+	metadata, err := os.ReadFile(p.metadataPath(pciAddress))
+	if err == nil {
+		allocation := &PCIAllocationInfo{}
+		if json.Unmarshal(metadata, allocation) == nil && allocation.NetNS == netNS {
+			return allocation, nil
+		}
+	}
+	return &PCIAllocationInfo{NetNS: netNS}, nil
+}
+
+// DeleteAllocatedPCI Remove the allocated PCI file and its metadata file
+// return error if the allocated PCI file doesn't exist
 func (p *PCIAllocator) DeleteAllocatedPCI(pciAddress string) error {
 	pciPath := filepath.Join(p.dataDir, pciAddress)
 	if err := os.Remove(pciPath); err != nil {
-		return fmt.Errorf("error removing PCI address lock file %s: %v", pciPath, err)
+		return fmt.Errorf("error removing PCI address lock file %s: %w", pciPath, err)
+	}
+	if err := os.Remove(p.metadataPath(pciAddress)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error removing PCI address metadata file %s: %w", p.metadataPath(pciAddress), err)
 	}
 	return nil
 }
@@ -105,19 +219,21 @@ func (p *PCIAllocator) IsAllocated(pciAddress string) (bool, error) {
 		return false, fmt.Errorf("failed to check for pci address file for %s: %v", pciPath, err)
 	}
 
-	dat, err := os.ReadFile(pciPath) //nolint:gosec
+	allocation, err := p.ReadAllocation(pciAddress)
 	if err != nil {
-		return false, fmt.Errorf("failed to read for pci address file for %s: %v", pciPath, err)
+		return false, err
 	}
 
 	// To prevent a locking of a PCI address for every pciAddress file we also add the netns path where it's been used
 	// This way if for some reason the cmdDel command was not called but the pod namespace doesn't exist anymore
 	// we release the PCI address
-	networkNamespace, err := ns.GetNS(string(dat))
+	networkNamespace, err := ns.GetNS(allocation.NetNS)
 	if err != nil {
 		logging.Debug("Mark the PCI address as released",
 			"func", "IsAllocated",
-			"pciAddress", pciAddress)
+			"pciAddress", pciAddress,
+			"podNamespace", allocation.PodNamespace,
+			"podName", allocation.PodName)
 		err = p.DeleteAllocatedPCI(pciAddress)
 		if err != nil {
 			return false, fmt.Errorf("error deleting the pci allocation for vf pci address %s: %v", pciAddress, err)
@@ -129,7 +245,7 @@ func (p *PCIAllocator) IsAllocated(pciAddress string) (bool, error) {
 	// Close the network namespace
 	if err := networkNamespace.Close(); err != nil {
 		logging.Error("Failed to close network namespace",
-			"namespace", string(dat),
+			"namespace", allocation.NetNS,
 			"error", err)
 	}
 	return true, nil
@@ -1,6 +1,9 @@
 package utils
 
 import (
+	"os"
+	"path/filepath"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
@@ -57,4 +60,93 @@ var _ = Describe("PCIAllocator", func() {
 			Expect(isAllocated).To(BeFalse())
 		})
 	})
+
+	Context("SaveAllocation", func() {
+		It("Assuming the pod of the allocation is read back", func() {
+			allocator := NewPCIAllocator(ts.dirRoot)
+			allocation := &PCIAllocationInfo{
+				NetNS:        "/var/run/netns/test",
+				ContainerID:  "cid",
+				IfName:       "net1",
+				PodNamespace: "default",
+				PodName:      "pod1",
+				PodUID:       "8f9c4a2e-6b1d-4f3a-9e7c-2d5b8a1c0f34",
+			}
+			Expect(allocator.SaveAllocation("0000:af:00.4", allocation)).To(Succeed())
+
+			read, err := allocator.ReadAllocation("0000:af:00.4")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(read).To(Equal(allocation))
+
+			Expect(allocator.DeleteAllocatedPCI("0000:af:00.4")).To(Succeed())
+			Expect(filepath.Join(ts.dirRoot, "pci", "0000:af:00.4.json")).ToNot(BeAnExistingFile())
+		})
+
+		It("Assuming the allocation file keeps the format of older versions", func() {
+			allocator := NewPCIAllocator(ts.dirRoot)
+			Expect(allocator.SaveAllocation("0000:af:00.6", &PCIAllocationInfo{NetNS: "/var/run/netns/test", PodName: "pod1"})).To(Succeed())
+
+			dat, err := os.ReadFile(filepath.Join(ts.dirRoot, "pci", "0000:af:00.6"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(dat)).To(Equal("/var/run/netns/test"))
+		})
+
+		It("Assuming a metadata file left behind by an older version", func() {
+			allocator := NewPCIAllocator(ts.dirRoot)
+			Expect(allocator.SaveAllocation("0000:af:00.7", &PCIAllocationInfo{NetNS: "/var/run/netns/old", PodName: "pod1"})).To(Succeed())
+			// an older version allocates the PCI address to another pod, without touching the metadata file
+			Expect(os.WriteFile(filepath.Join(ts.dirRoot, "pci", "0000:af:00.7"), []byte("/var/run/netns/new"), 0o600)).To(Succeed())
+
+			read, err := allocator.ReadAllocation("0000:af:00.7")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(read).To(Equal(&PCIAllocationInfo{NetNS: "/var/run/netns/new"}))
+		})
+
+		It("Assuming an allocation file of an older version holding the netns path only", func() {
+			targetNetNS, err = testutils.NewNS()
+			Expect(err).NotTo(HaveOccurred())
+			allocator := NewPCIAllocator(ts.dirRoot)
+			Expect(os.MkdirAll(filepath.Join(ts.dirRoot, "pci"), 0o700)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(ts.dirRoot, "pci", "0000:af:00.5"), []byte(targetNetNS.Path()), 0o600)).To(Succeed())
+
+			read, err := allocator.ReadAllocation("0000:af:00.5")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(read).To(Equal(&PCIAllocationInfo{NetNS: targetNetNS.Path()}))
+
+			isAllocated, err := allocator.IsAllocated("0000:af:00.5")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(isAllocated).To(BeTrue())
+		})
+	})
+
+	Context("TryLock", func() {
+		It("Assuming PCI address is not locked", func() {
+			allocator := NewPCIAllocator(ts.dirRoot)
+			locked, err := allocator.TryLock("0000:af:00.2")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(locked).To(BeTrue())
+			Expect(allocator.Unlock("0000:af:00.2")).To(Succeed())
+		})
+
+		It("Assuming PCI address is locked by another allocator", func() {
+			allocator := NewPCIAllocator(ts.dirRoot)
+			Expect(allocator.Lock("0000:af:00.3")).To(Succeed())
+
+			other := NewPCIAllocator(ts.dirRoot)
+			locked, err := other.TryLock("0000:af:00.3")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(locked).To(BeFalse())
+
+			Expect(allocator.Unlock("0000:af:00.3")).To(Succeed())
+			locked, err = other.TryLock("0000:af:00.3")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(locked).To(BeTrue())
+			Expect(other.Unlock("0000:af:00.3")).To(Succeed())
+		})
+
+		It("Assuming PCI address was never locked", func() {
+			allocator := NewPCIAllocator(ts.dirRoot)
+			Expect(allocator.Unlock("0000:af:00.4")).ToNot(Succeed())
+		})
+	})
 })
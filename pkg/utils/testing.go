@@ -33,33 +33,57 @@ var ts = tmpSysFs{
 		"sys/class/net",
 		"sys/bus/pci/devices",
 		"sys/devices/pci0000:ae/0000:ae:00.0/0000:af:00.1/net/enp175s0f1",
+		"sys/devices/pci0000:ae/0000:ae:00.0/0000:af:00.1/net/enp175s0f1_1",
+		"sys/devices/pci0000:ae/0000:ae:00.0/0000:af:00.1/sriov/0",
+		"sys/devices/pci0000:ae/0000:ae:00.0/0000:af:00.1/sriov/1",
 		"sys/devices/pci0000:ae/0000:ae:00.0/0000:af:06.0/net/enp175s6",
 		"sys/devices/pci0000:ae/0000:ae:00.0/0000:af:06.1/net/enp175s7",
+		"sys/devices/pci0000:ae/0000:ae:00.0/0000:af:06.1/infiniband/mlx5_1",
+		"sys/devices/pci0000:ae/0000:ae:00.0/0000:af:06.1/vdpa0/vhost-vdpa-0",
+		"sys/devices/pci0000:ae/0000:ae:00.0/0000:af:00.1/mlx5_core.sf.2/net/enp175s0f1s88",
+		"sys/bus/auxiliary/devices",
+		"sys/bus/vdpa/devices",
+		"sys/bus/vdpa/drivers/vhost_vdpa",
+		"sys/bus/vdpa/drivers/virtio_vdpa",
 		"sys/devices/pci0000:00/0000:00:02.0/0000:05:00.0/net/ens1",
 		"sys/devices/pci0000:00/0000:00:02.0/0000:05:00.0/net/ens1d1",
 	},
 	fileList: map[string][]byte{
-		"sys/devices/pci0000:ae/0000:ae:00.0/0000:af:00.1/sriov_numvfs": []byte("2"),
-		"sys/devices/pci0000:00/0000:00:02.0/0000:05:00.0/sriov_numvfs": []byte("0"),
+		"sys/devices/pci0000:ae/0000:ae:00.0/0000:af:00.1/sriov_numvfs":                    []byte("2"),
+		"sys/devices/pci0000:ae/0000:ae:00.0/0000:af:00.1/sriov/0/node":                    []byte("00:11:22:33:44:55:66:77\n"),
+		"sys/devices/pci0000:ae/0000:ae:00.0/0000:af:00.1/sriov/0/port":                    []byte("00:11:22:33:44:55:66:78\n"),
+		"sys/devices/pci0000:ae/0000:ae:00.0/0000:af:00.1/sriov/1/trunk":                   []byte(""),
+		"sys/devices/pci0000:ae/0000:ae:00.0/0000:af:00.1/net/enp175s0f1/phys_switch_id":   []byte("1c34da0300fa3e0a\n"),
+		"sys/devices/pci0000:ae/0000:ae:00.0/0000:af:00.1/net/enp175s0f1/phys_port_name":   []byte("p1\n"),
+		"sys/devices/pci0000:ae/0000:ae:00.0/0000:af:00.1/net/enp175s0f1_1/phys_switch_id": []byte("1c34da0300fa3e0a\n"),
+		"sys/devices/pci0000:ae/0000:ae:00.0/0000:af:00.1/net/enp175s0f1_1/phys_port_name": []byte("pf1vf1\n"),
+		"sys/devices/pci0000:00/0000:00:02.0/0000:05:00.0/sriov_numvfs":                    []byte("0"),
+		"sys/devices/pci0000:ae/0000:ae:00.0/0000:af:00.1/mlx5_core.sf.2/sfnum":            []byte("88\n"),
 	},
 	netSymlinks: map[string]string{
-		"sys/class/net/enp175s0f1": "sys/devices/pci0000:ae/0000:ae:00.0/0000:af:00.1/net/enp175s0f1",
-		"sys/class/net/enp175s6":   "sys/devices/pci0000:ae/0000:ae:00.0/0000:af:06.0/net/enp175s6",
-		"sys/class/net/enp175s7":   "sys/devices/pci0000:ae/0000:ae:00.0/0000:af:06.1/net/enp175s7",
-		"sys/class/net/ens1":       "sys/devices/pci0000:00/0000:00:02.0/0000:05:00.0/net/ens1",
-		"sys/class/net/ens1d1":     "sys/devices/pci0000:00/0000:00:02.0/0000:05:00.0/net/ens1d1",
+		"sys/class/net/enp175s0f1":   "sys/devices/pci0000:ae/0000:ae:00.0/0000:af:00.1/net/enp175s0f1",
+		"sys/class/net/enp175s0f1_1": "sys/devices/pci0000:ae/0000:ae:00.0/0000:af:00.1/net/enp175s0f1_1",
+		"sys/class/net/enp175s6":     "sys/devices/pci0000:ae/0000:ae:00.0/0000:af:06.0/net/enp175s6",
+		"sys/class/net/enp175s7":     "sys/devices/pci0000:ae/0000:ae:00.0/0000:af:06.1/net/enp175s7",
+		"sys/class/net/ens1":         "sys/devices/pci0000:00/0000:00:02.0/0000:05:00.0/net/ens1",
+		"sys/class/net/ens1d1":       "sys/devices/pci0000:00/0000:00:02.0/0000:05:00.0/net/ens1d1",
 	},
 	devSymlinks: map[string]string{
-		"sys/class/net/enp175s0f1/device": "sys/devices/pci0000:ae/0000:ae:00.0/0000:af:00.1",
-		"sys/class/net/enp175s6/device":   "sys/devices/pci0000:ae/0000:ae:00.0/0000:af:06.0",
-		"sys/class/net/enp175s7/device":   "sys/devices/pci0000:ae/0000:ae:00.0/0000:af:06.1",
-		"sys/class/net/ens1/device":       "sys/devices/pci0000:00/0000:00:02.0/0000:05:00.0",
-		"sys/class/net/ens1d1/device":     "sys/devices/pci0000:00/0000:00:02.0/0000:05:00.0",
+		"sys/class/net/enp175s0f1/device":   "sys/devices/pci0000:ae/0000:ae:00.0/0000:af:00.1",
+		"sys/class/net/enp175s0f1_1/device": "sys/devices/pci0000:ae/0000:ae:00.0/0000:af:00.1",
+		"sys/class/net/enp175s6/device":     "sys/devices/pci0000:ae/0000:ae:00.0/0000:af:06.0",
+		"sys/class/net/enp175s7/device":     "sys/devices/pci0000:ae/0000:ae:00.0/0000:af:06.1",
+		"sys/class/net/ens1/device":         "sys/devices/pci0000:00/0000:00:02.0/0000:05:00.0",
+		"sys/class/net/ens1d1/device":       "sys/devices/pci0000:00/0000:00:02.0/0000:05:00.0",
 
 		"sys/bus/pci/devices/0000:af:00.1": "sys/devices/pci0000:ae/0000:ae:00.0/0000:af:00.1",
 		"sys/bus/pci/devices/0000:af:06.0": "sys/devices/pci0000:ae/0000:ae:00.0/0000:af:06.0",
 		"sys/bus/pci/devices/0000:af:06.1": "sys/devices/pci0000:ae/0000:ae:00.0/0000:af:06.1",
 		"sys/bus/pci/devices/0000:05:00.0": "sys/devices/pci0000:00/0000:00:02.0/0000:05:00.0",
+
+		"sys/bus/auxiliary/devices/mlx5_core.sf.2":                      "sys/devices/pci0000:ae/0000:ae:00.0/0000:af:00.1/mlx5_core.sf.2",
+		"sys/bus/vdpa/devices/vdpa0":                                    "sys/devices/pci0000:ae/0000:ae:00.0/0000:af:06.1/vdpa0",
+		"sys/devices/pci0000:ae/0000:ae:00.0/0000:af:06.1/vdpa0/driver": "sys/bus/vdpa/drivers/vhost_vdpa",
 	},
 	vfSymlinks: map[string]string{
 		"sys/devices/pci0000:ae/0000:ae:00.0/0000:af:00.1/virtfn0": "sys/devices/pci0000:ae/0000:ae:00.0/0000:af:06.0",
@@ -114,6 +138,8 @@ func CreateTmpSysFs() error {
 
 	SysBusPci = filepath.Join(ts.dirRoot, SysBusPci)
 	NetDirectory = filepath.Join(ts.dirRoot, NetDirectory)
+	SysBusVdpa = filepath.Join(ts.dirRoot, SysBusVdpa)
+	SysBusAux = filepath.Join(ts.dirRoot, SysBusAux)
 	return nil
 }
 
@@ -285,16 +311,96 @@ func (p *pfMockNetlinkLib) LinkSetVfState(pfLink netlink.Link, vfIndex int, stat
 	return nil
 }
 
+func (p *pfMockNetlinkLib) LinkSetVfNodeGUID(pfLink netlink.Link, vfIndex int, nodeGUID net.HardwareAddr) error {
+	p.recordMethodCallf("LinkSetVfNodeGUID %s %d %s", pfLink.Attrs().Name, vfIndex, nodeGUID.String())
+	return nil
+}
+
+func (p *pfMockNetlinkLib) LinkSetVfPortGUID(pfLink netlink.Link, vfIndex int, portGUID net.HardwareAddr) error {
+	p.recordMethodCallf("LinkSetVfPortGUID %s %d %s", pfLink.Attrs().Name, vfIndex, portGUID.String())
+	return nil
+}
+
 func (p *pfMockNetlinkLib) LinkSetMTU(link netlink.Link, mtu int) error {
 	p.recordMethodCallf("LinkSetMTU %s %d", link.Attrs().Name, mtu)
 	return netlink.LinkSetMTU(link, mtu)
 }
 
+func (p *pfMockNetlinkLib) LinkSetMaster(link, master netlink.Link) error {
+	p.recordMethodCallf("LinkSetMaster %s %s", link.Attrs().Name, master.Attrs().Name)
+	return netlink.LinkSetMaster(link, master)
+}
+
+func (p *pfMockNetlinkLib) LinkSetAllmulticastOn(link netlink.Link) error {
+	p.recordMethodCallf("LinkSetAllmulticastOn %s", link.Attrs().Name)
+	return netlink.LinkSetAllmulticastOn(link)
+}
+
+func (p *pfMockNetlinkLib) LinkSetAllmulticastOff(link netlink.Link) error {
+	p.recordMethodCallf("LinkSetAllmulticastOff %s", link.Attrs().Name)
+	return netlink.LinkSetAllmulticastOff(link)
+}
+
+func (p *pfMockNetlinkLib) SetPromiscOn(link netlink.Link) error {
+	p.recordMethodCallf("SetPromiscOn %s", link.Attrs().Name)
+	return netlink.SetPromiscOn(link)
+}
+
+func (p *pfMockNetlinkLib) SetPromiscOff(link netlink.Link) error {
+	p.recordMethodCallf("SetPromiscOff %s", link.Attrs().Name)
+	return netlink.SetPromiscOff(link)
+}
+
 func (p *pfMockNetlinkLib) LinkDelAltName(link netlink.Link, name string) error {
 	p.recordMethodCallf("LinkDelAltName %s %s", link.Attrs().Name, name)
 	return netlink.LinkDelAltName(link, name)
 }
 
+func (p *pfMockNetlinkLib) RdmaSystemGetNetnsMode() (string, error) {
+	p.recordMethodCallf("RdmaSystemGetNetnsMode")
+	return netlink.RdmaSystemGetNetnsMode()
+}
+
+func (p *pfMockNetlinkLib) RdmaLinkByName(name string) (*netlink.RdmaLink, error) {
+	p.recordMethodCallf("RdmaLinkByName %s", name)
+	return netlink.RdmaLinkByName(name)
+}
+
+func (p *pfMockNetlinkLib) RdmaLinkSetNsFd(link *netlink.RdmaLink, fd uint32) error {
+	p.recordMethodCallf("RdmaLinkSetNsFd %s %d", link.Attrs.Name, fd)
+	return netlink.RdmaLinkSetNsFd(link, fd)
+}
+
+func (p *pfMockNetlinkLib) VDPANewDev(name, mgmtBus, mgmtName string, _ netlink.VDPANewDevParams) error {
+	p.recordMethodCallf("VDPANewDev %s %s/%s", name, mgmtBus, mgmtName)
+	return nil
+}
+
+func (p *pfMockNetlinkLib) VDPADelDev(name string) error {
+	p.recordMethodCallf("VDPADelDev %s", name)
+	return nil
+}
+
+func (p *pfMockNetlinkLib) LinkAdd(link netlink.Link) error {
+	p.recordMethodCallf("LinkAdd %s", link.Attrs().Name)
+	return netlink.LinkAdd(link)
+}
+
+func (p *pfMockNetlinkLib) LinkDel(link netlink.Link) error {
+	p.recordMethodCallf("LinkDel %s", link.Attrs().Name)
+	return netlink.LinkDel(link)
+}
+
+func (p *pfMockNetlinkLib) QdiscReplace(qdisc netlink.Qdisc) error {
+	p.recordMethodCallf("QdiscReplace %s", qdisc.Type())
+	return netlink.QdiscReplace(qdisc)
+}
+
+func (p *pfMockNetlinkLib) QdiscDel(qdisc netlink.Qdisc) error {
+	p.recordMethodCallf("QdiscDel %s", qdisc.Type())
+	return netlink.QdiscDel(qdisc)
+}
+
 func (p *pfMockNetlinkLib) recordMethodCallf(format string, a ...any) {
 	message := fmt.Sprintf(format+"\n", a...)
 	//nolint:gosec
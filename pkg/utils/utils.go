@@ -2,11 +2,14 @@ package utils
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -20,14 +23,24 @@ var (
 	NetDirectory = "/sys/class/net"
 	// SysBusPci is sysfs pci device directory
 	SysBusPci = "/sys/bus/pci/devices"
+	// SysBusAux is sysfs auxiliary device directory
+	SysBusAux = "/sys/bus/auxiliary/devices"
 	// SysV4ArpNotify is the sysfs IPv4 ARP Notify directory
 	SysV4ArpNotify = "/proc/sys/net/ipv4/conf/"
 	// SysV6NdiscNotify is the sysfs IPv6 Neighbor Discovery Notify directory
 	SysV6NdiscNotify = "/proc/sys/net/ipv6/conf/"
 	// UserspaceDrivers is a list of driver names that don't have netlink representation for their devices
 	UserspaceDrivers = []string{"vfio-pci", "uio_pci_generic", "igb_uio"}
+
+	// pfPortNameRe matches the phys_port_name of a PF uplink, e.g. p0
+	pfPortNameRe = regexp.MustCompile(`^p(\d+)$`)
+	// vfRepresentorPortNameRe matches the phys_port_name of a VF representor, e.g. pf0vf1, c1pf0vf1 or vf1
+	vfRepresentorPortNameRe = regexp.MustCompile(`^(?:c\d+)?(?:pf(\d+))?vf(\d+)$`)
 )
 
+// maxIfNameLen is the maximum length of a netdevice name (IFNAMSIZ without the terminating NUL)
+const maxIfNameLen = 15
+
 // EnableArpAndNdiscNotify enables IPv4 arp_notify and IPv6 ndisc_notify for netdev
 func EnableArpAndNdiscNotify(ifName string) error {
 	/* For arp_notify, when a value of "1" is set then a Gratuitous ARP request will be sent
@@ -88,6 +101,25 @@ func GetSriovNumVfs(ifName string) (int, error) {
 	return vfTotal, nil
 }
 
+// GetVFRdmaDevices returns the names of the RDMA devices of the VF with the given pci address,
+// or an empty list if the VF has none
+func GetVFRdmaDevices(pciAddr string) ([]string, error) {
+	rdmaDir := filepath.Join(SysBusPci, pciAddr, "infiniband")
+	entries, err := os.ReadDir(rdmaDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read RDMA devices of VF %s: %v", pciAddr, err)
+	}
+
+	rdmaDevices := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		rdmaDevices = append(rdmaDevices, entry.Name())
+	}
+	return rdmaDevices, nil
+}
+
 // GetVfid takes in VF's PCI address(addr) and pfName as string and returns VF's ID as int
 func GetVfid(addr, pfName string) (int, error) {
 	var id int
@@ -133,6 +165,71 @@ func GetPfName(vf string) (string, error) {
 	return strings.TrimSpace(files[0].Name()), nil
 }
 
+// IsAuxDevice returns true if deviceID is the name of an auxiliary bus device, e.g. a Scalable Function
+func IsAuxDevice(deviceID string) bool {
+	_, err := os.Stat(filepath.Join(SysBusAux, deviceID))
+	return err == nil
+}
+
+// GetSfInfo returns the PF netdevice name and the SF number of a Scalable Function given its auxiliary device name
+func GetSfInfo(auxDev string) (string, int, error) {
+	sfPath, err := filepath.EvalSymlinks(filepath.Join(SysBusAux, auxDev))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to resolve sysfs path of SF %s: %v", auxDev, err)
+	}
+
+	// a Scalable Function is a child device of the PCI device of its PF
+	pfNetDir := filepath.Join(filepath.Dir(sfPath), "net")
+	files, err := os.ReadDir(pfNetDir)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read the PF net dir of SF %s: %v", auxDev, err)
+	}
+	if len(files) < 1 {
+		return "", 0, fmt.Errorf("PF network device of SF %s not found", auxDev)
+	}
+
+	data, err := os.ReadFile(filepath.Join(sfPath, "sfnum")) //nolint:gosec
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read sfnum of SF %s: %v", auxDev, err)
+	}
+	sfNum, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to convert sfnum of SF %s to int: %v", auxDev, err)
+	}
+
+	return files[0].Name(), sfNum, nil
+}
+
+// GetSfPfPciAddress returns the PF pci address of a Scalable Function given its auxiliary device name
+func GetSfPfPciAddress(auxDev string) (string, error) {
+	sfPath, err := filepath.EvalSymlinks(filepath.Join(SysBusAux, auxDev))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve sysfs path of SF %s: %v", auxDev, err)
+	}
+	return filepath.Base(filepath.Dir(sfPath)), nil
+}
+
+// GetSFLinkName returns the netdevice name of a Scalable Function given its auxiliary device name
+func GetSFLinkName(auxDev string) (string, error) {
+	files, err := os.ReadDir(filepath.Join(SysBusAux, auxDev, "net"))
+	if err != nil {
+		return "", err
+	}
+	if len(files) < 1 {
+		return "", fmt.Errorf("SF device %s has no entries", auxDev)
+	}
+	return files[0].Name(), nil
+}
+
+// GetPfPciAddress returns the PF pci address of a given VF pci address
+func GetPfPciAddress(vf string) (string, error) {
+	pfLink, err := os.Readlink(filepath.Join(SysBusPci, vf, "physfn"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read the physfn link of VF %s: %v", vf, err)
+	}
+	return filepath.Base(pfLink), nil
+}
+
 // GetPciAddress takes in a interface(ifName) and VF id and returns its pci addr as string
 func GetPciAddress(ifName string, vf int) (string, error) {
 	var pciaddr string
@@ -230,6 +327,178 @@ func GetVFLinkNamesFromVFID(pfName string, vfID int) ([]string, error) {
 	return names, nil
 }
 
+// GetVFGUIDs returns the node and port GUIDs of an InfiniBand VF given its PF name and VF id,
+// as exposed by the PF driver in the sriov/<vf id> sysfs directory
+func GetVFGUIDs(pfName string, vfID int) (nodeGUID, portGUID string, err error) {
+	vfDir := filepath.Join(NetDirectory, pfName, "device", "sriov", strconv.Itoa(vfID))
+	readGUID := func(name string) (string, error) {
+		data, err := os.ReadFile(filepath.Join(vfDir, name)) //nolint:gosec
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s GUID of vf %d of device %q: %v", name, vfID, pfName, err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	if nodeGUID, err = readGUID("node"); err != nil {
+		return "", "", err
+	}
+	if portGUID, err = readGUID("port"); err != nil {
+		return "", "", err
+	}
+	return nodeGUID, portGUID, nil
+}
+
+// ParseVlanTrunk parses a VLAN trunk, a comma separated list of VLAN IDs and ranges, e.g. "100-200,300",
+// into a list of ranges
+func ParseVlanTrunk(trunk string) ([][2]int, error) {
+	var ranges [][2]int
+	for _, item := range strings.Split(trunk, ",") {
+		bounds := strings.SplitN(strings.TrimSpace(item), "-", 2)
+		start, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid VLAN ID %q in trunk %q", bounds[0], trunk)
+		}
+		end := start
+		if len(bounds) == 2 {
+			if end, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, fmt.Errorf("invalid VLAN ID %q in trunk %q", bounds[1], trunk)
+			}
+		}
+		if start < 1 || end > 4094 || start > end {
+			return nil, fmt.Errorf("invalid VLAN range %q in trunk %q: VLAN IDs must be in the range 1-4094", item, trunk)
+		}
+		ranges = append(ranges, [2]int{start, end})
+	}
+	return ranges, nil
+}
+
+// vfTrunkPath returns the sysfs file the VLAN trunk of a VF is configured through
+func vfTrunkPath(pfName string, vfID int) string {
+	return filepath.Join(NetDirectory, pfName, "device", "sriov", strconv.Itoa(vfID), "trunk")
+}
+
+// pfDriverName returns the name of the driver of a PF, or an empty string if it can not be read
+func pfDriverName(pfName string) string {
+	driverPath, err := os.Readlink(filepath.Join(NetDirectory, pfName, "device", "driver"))
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(driverPath)
+}
+
+// CheckVFVlanTrunkSupport returns an error if the driver of the PF does not expose the VLAN trunk of its VFs in sysfs
+func CheckVFVlanTrunkSupport(pfName string, vfID int) error {
+	if _, err := os.Stat(vfTrunkPath(pfName, vfID)); err != nil {
+		driver := pfDriverName(pfName)
+		if driver == "" {
+			driver = "unknown"
+		}
+		return fmt.Errorf("driver %s of PF %s does not support VLAN trunks on VFs: %v", driver, pfName, err)
+	}
+	return nil
+}
+
+// SetVFVlanTrunk adds the VLANs of trunk to the allowed VLANs of a VF, or removes them if add is false
+func SetVFVlanTrunk(pfName string, vfID int, trunk string, add bool) error {
+	ranges, err := ParseVlanTrunk(trunk)
+	if err != nil {
+		return err
+	}
+	if err = CheckVFVlanTrunkSupport(pfName, vfID); err != nil {
+		return err
+	}
+
+	op := "rem"
+	if add {
+		op = "add"
+	}
+
+	// mlx5 takes one range per write as "<op> <start> <end>", i40e and ice take the list as "<op> <trunk>"
+	var cmds []string
+	if pfDriverName(pfName) == "mlx5_core" {
+		for _, r := range ranges {
+			cmds = append(cmds, fmt.Sprintf("%s %d %d", op, r[0], r[1]))
+		}
+	} else {
+		cmds = append(cmds, fmt.Sprintf("%s %s", op, strings.ReplaceAll(trunk, " ", "")))
+	}
+
+	trunkPath := vfTrunkPath(pfName, vfID)
+	for _, cmd := range cmds {
+		if err = os.WriteFile(trunkPath, []byte(cmd), os.ModeAppend); err != nil {
+			return fmt.Errorf("failed to write %q to %s: %v", cmd, trunkPath, err)
+		}
+	}
+	return nil
+}
+
+// GetVFRepresentor returns the name of the representor netdevice of a VF given its PF name and VF id.
+// An empty string is returned if the PF eswitch is not in switchdev mode and the VF has no representor.
+func GetVFRepresentor(pfName string, vfID int) (string, error) {
+	// phys_switch_id can only be read from netdevices of an eswitch in switchdev mode
+	pfSwitchID, err := readNetdevAttr(pfName, "phys_switch_id")
+	if err != nil || pfSwitchID == "" {
+		return "", nil
+	}
+
+	pfIndex := ""
+	if portName, err := readNetdevAttr(pfName, "phys_port_name"); err == nil {
+		if m := pfPortNameRe.FindStringSubmatch(portName); m != nil {
+			pfIndex = m[1]
+		}
+	}
+
+	entries, err := os.ReadDir(NetDirectory)
+	if err != nil {
+		return "", fmt.Errorf("failed to read netdevices: %v", err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if name == pfName {
+			continue
+		}
+		if switchID, err := readNetdevAttr(name, "phys_switch_id"); err != nil || switchID != pfSwitchID {
+			continue
+		}
+		portName, err := readNetdevAttr(name, "phys_port_name")
+		if err != nil {
+			continue
+		}
+		m := vfRepresentorPortNameRe.FindStringSubmatch(portName)
+		if m == nil || (m[1] != "" && pfIndex != "" && m[1] != pfIndex) {
+			continue
+		}
+		if m[2] == strconv.Itoa(vfID) {
+			return name, nil
+		}
+	}
+
+	return "", nil
+}
+
+// RepresentorName returns the predictable name of a VF representor for a pod interface of a container,
+// made of the beginning of the container ID and the pod interface name. If that does not fit into a netdevice name,
+// the pod interface name is replaced with a hash of the container ID and the pod interface name, so that cutting it
+// does not give two pod interfaces of a container, e.g. net1 and net10, the same name.
+func RepresentorName(containerID, podIfName string) string {
+	name := fmt.Sprintf("%.10s_%s", containerID, podIfName)
+	if len(name) <= maxIfNameLen {
+		return name
+	}
+	sum := sha256.Sum256([]byte(containerID + "/" + podIfName))
+	return fmt.Sprintf("%.6s_%s", containerID, hex.EncodeToString(sum[:4]))
+}
+
+// readNetdevAttr returns the trimmed content of a sysfs attribute of a netdevice
+func readNetdevAttr(ifName, attr string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(NetDirectory, ifName, attr)) //nolint:gosec
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
 // HasDpdkDriver checks if a device is attached to dpdk supported driver
 func HasDpdkDriver(pciAddr string) (bool, error) {
 	driverLink := filepath.Join(SysBusPci, pciAddr, "driver")
@@ -280,6 +549,54 @@ func saveScratchNetConf(containerID, dataDir string, netconf []byte) error {
 	return err
 }
 
+// SaveDeviceInfo writes the device information of a network attachment to path,
+// from where the meta plugin (e.g. Multus) publishes it in the network-status annotation
+func SaveDeviceInfo(path string, devInfo *sriovtypes.DeviceInfo) error {
+	devInfoBytes, err := json.Marshal(devInfo)
+	if err != nil {
+		return fmt.Errorf("error serializing device info: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create the device info directory(%q): %v", filepath.Dir(path), err)
+	}
+
+	if err := os.WriteFile(path, devInfoBytes, 0o600); err != nil {
+		return fmt.Errorf("failed to write device info in the path(%q): %v", path, err)
+	}
+	return nil
+}
+
+// CleanDeviceInfo removes the device information of a network attachment saved by SaveDeviceInfo
+func CleanDeviceInfo(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error removing device info file %s: %v", path, err)
+	}
+	return nil
+}
+
+// DeviceInfoPath returns the path of the device information of a network attachment in dataDir
+func DeviceInfoPath(dataDir, networkName, cid, podIfName string) string {
+	return filepath.Join(dataDir, fmt.Sprintf("%s-%s-%s-device-info.json", networkName, cid, podIfName))
+}
+
+// CheckDirWritable verifies that files can be created in dir, creating dir if needed
+func CheckDirWritable(dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create the directory(%q): %v", dir, err)
+	}
+
+	f, err := os.CreateTemp(dir, ".status-")
+	if err != nil {
+		return fmt.Errorf("failed to create a file in the directory(%q): %v", dir, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close file %q: %v", f.Name(), err)
+	}
+
+	return os.Remove(f.Name())
+}
+
 // ReadScratchNetConf takes in container ID, Pod interface name and data dir as string and returns a pointer to Conf
 func ReadScratchNetConf(cRefPath string) ([]byte, error) {
 	data, err := os.ReadFile(cRefPath) //nolint:gosec
@@ -55,6 +55,15 @@ var _ = Describe("Utils", func() {
 			Expect(err).To(HaveOccurred(), "Not existing VF should return an error")
 		})
 	})
+	Context("Checking GetPfPciAddress function", func() {
+		It("Assuming existing vf", func() {
+			Expect(GetPfPciAddress("0000:af:06.0")).To(Equal("0000:af:00.1"))
+		})
+		It("Assuming not existing vf", func() {
+			_, err := GetPfPciAddress("0000:af:07.0")
+			Expect(err).To(HaveOccurred())
+		})
+	})
 	Context("Checking GetPciAddress function", func() {
 		It("Assuming existing interface and vf", func() {
 			Expect(GetPciAddress("enp175s0f1", 0)).To(Equal("0000:af:06.0"), "Existing PF and VF id should return correct VF pci address")
@@ -69,6 +78,136 @@ var _ = Describe("Utils", func() {
 			Expect(err).To(HaveOccurred(), "Not existing VF id should return an error")
 		})
 	})
+	Context("Checking GetVFRdmaDevices function", func() {
+		It("Assuming VF with RDMA device", func() {
+			Expect(GetVFRdmaDevices("0000:af:06.1")).To(Equal([]string{"mlx5_1"}))
+		})
+		It("Assuming VF without RDMA device", func() {
+			Expect(GetVFRdmaDevices("0000:af:06.0")).To(BeEmpty())
+		})
+	})
+	Context("Checking GetVFGUIDs function", func() {
+		It("Assuming existing interface and vf", func() {
+			nodeGUID, portGUID, err := GetVFGUIDs("enp175s0f1", 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(nodeGUID).To(Equal("00:11:22:33:44:55:66:77"))
+			Expect(portGUID).To(Equal("00:11:22:33:44:55:66:78"))
+		})
+		It("Assuming vf without GUIDs", func() {
+			_, _, err := GetVFGUIDs("enp175s0f1", 1)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Context("Checking ParseVlanTrunk function", func() {
+		It("Assuming VLAN IDs and ranges", func() {
+			Expect(ParseVlanTrunk("100-200, 300")).To(Equal([][2]int{{100, 200}, {300, 300}}))
+		})
+		DescribeTable("Assuming invalid trunk",
+			func(trunk string) {
+				_, err := ParseVlanTrunk(trunk)
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("empty", ""),
+			Entry("not a number", "100-abc"),
+			Entry("reversed range", "200-100"),
+			Entry("VLAN ID 0", "0-10"),
+			Entry("VLAN ID out of range", "4095"),
+		)
+	})
+	Context("Checking CheckVFVlanTrunkSupport function", func() {
+		It("Assuming vf with trunk sysfs file", func() {
+			Expect(CheckVFVlanTrunkSupport("enp175s0f1", 1)).To(Succeed())
+		})
+		It("Assuming vf without trunk sysfs file", func() {
+			Expect(CheckVFVlanTrunkSupport("enp175s0f1", 0)).To(MatchError(ContainSubstring("does not support VLAN trunks")))
+		})
+	})
+	Context("Checking SetVFVlanTrunk function", func() {
+		trunkPath := func() string {
+			return filepath.Join(NetDirectory, "enp175s0f1", "device", "sriov", "1", "trunk")
+		}
+		It("Assuming PF driver taking the trunk as a list", func() {
+			Expect(SetVFVlanTrunk("enp175s0f1", 1, "100-200,300", true)).To(Succeed())
+			Expect(os.ReadFile(trunkPath())).To(BeEquivalentTo("add 100-200,300"))
+			Expect(SetVFVlanTrunk("enp175s0f1", 1, "100-200,300", false)).To(Succeed())
+			Expect(os.ReadFile(trunkPath())).To(BeEquivalentTo("rem 100-200,300"))
+		})
+		It("Assuming mlx5 PF driver taking one range per write", func() {
+			driverLink := filepath.Join(NetDirectory, "enp175s0f1", "device", "driver")
+			Expect(os.Symlink("../../../bus/pci/drivers/mlx5_core", driverLink)).To(Succeed())
+			DeferCleanup(os.Remove, driverLink)
+
+			Expect(SetVFVlanTrunk("enp175s0f1", 1, "100-200,300", true)).To(Succeed())
+			Expect(os.ReadFile(trunkPath())).To(BeEquivalentTo("add 300 300"))
+		})
+		It("Assuming vf without trunk sysfs file", func() {
+			Expect(SetVFVlanTrunk("enp175s0f1", 0, "100", true)).To(HaveOccurred())
+		})
+	})
+	Context("Checking IsAuxDevice function", func() {
+		It("Assuming existing SF", func() {
+			Expect(IsAuxDevice("mlx5_core.sf.2")).To(BeTrue())
+		})
+		It("Assuming VF pci address", func() {
+			Expect(IsAuxDevice("0000:af:06.0")).To(BeFalse())
+		})
+	})
+	Context("Checking GetSfInfo function", func() {
+		It("Assuming existing SF", func() {
+			pfName, sfNum, err := GetSfInfo("mlx5_core.sf.2")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pfName).To(Equal("enp175s0f1"))
+			Expect(sfNum).To(Equal(88))
+		})
+		It("Assuming not existing SF", func() {
+			_, _, err := GetSfInfo("mlx5_core.sf.3")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Context("Checking GetSfPfPciAddress function", func() {
+		It("Assuming existing SF", func() {
+			Expect(GetSfPfPciAddress("mlx5_core.sf.2")).To(Equal("0000:af:00.1"))
+		})
+	})
+	Context("Checking GetSFLinkName function", func() {
+		It("Assuming existing SF", func() {
+			Expect(GetSFLinkName("mlx5_core.sf.2")).To(Equal("enp175s0f1s88"))
+		})
+		It("Assuming not existing SF", func() {
+			_, err := GetSFLinkName("mlx5_core.sf.3")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Context("Checking GetVFRepresentor function", func() {
+		It("Assuming VF with a representor", func() {
+			Expect(GetVFRepresentor("enp175s0f1", 1)).To(Equal("enp175s0f1_1"))
+		})
+		It("Assuming VF without a representor", func() {
+			Expect(GetVFRepresentor("enp175s0f1", 0)).To(BeEmpty())
+		})
+		It("Assuming PF not in switchdev mode", func() {
+			Expect(GetVFRepresentor("ens1", 0)).To(BeEmpty())
+		})
+	})
+	Context("Checking RepresentorName function", func() {
+		It("Assuming short pod interface name", func() {
+			Expect(RepresentorName("4e5a8b2c1d3f6e7a9b0c", "net1")).To(Equal("4e5a8b2c1d_net1"))
+		})
+		It("Assuming long pod interface name", func() {
+			name := RepresentorName("4e5a8b2c1d3f6e7a9b0c", "eth-long")
+			Expect(name).To(MatchRegexp(`^4e5a8b_[0-9a-f]{8}$`))
+			Expect(RepresentorName("4e5a8b2c1d3f6e7a9b0c", "eth-long")).To(Equal(name))
+		})
+		It("Assuming pod interface names that share a prefix", func() {
+			names := map[string]bool{}
+			for _, podIfName := range []string{"net1", "net10", "net11", "eth-long", "eth-longer"} {
+				name := RepresentorName("4e5a8b2c1d3f6e7a9b0c", podIfName)
+				Expect(len(name)).To(BeNumerically("<=", 15))
+				names[name] = true
+			}
+			Expect(names).To(HaveLen(5))
+		})
+	})
 	Context("Checking GetSharedPF function", func() {
 		/* TO-DO */
 		// It("Assuming existing interface", func() {
@@ -191,6 +330,38 @@ var _ = Describe("Utils", func() {
 		})
 	})
 
+	Context("Checking SaveDeviceInfo and CleanDeviceInfo functions", func() {
+		It("should save the device info and remove it", func() {
+			tmpDir := GinkgoT().TempDir()
+			devInfo := &sriovtypes.DeviceInfo{
+				Type:    sriovtypes.DeviceInfoTypePCI,
+				Version: sriovtypes.DeviceInfoVersion,
+				Pci:     &sriovtypes.PciDeviceInfo{PciAddress: "0000:af:06.0"},
+				Sriov:   &sriovtypes.SriovDeviceInfo{PfName: "enp175s0f1", Dpdk: true},
+			}
+			path := DeviceInfoPath(tmpDir, "mynet", "test", "net1")
+			Expect(path).To(Equal(filepath.Join(tmpDir, "mynet-test-net1-device-info.json")))
+			err := SaveDeviceInfo(path, devInfo)
+			Expect(err).ToNot(HaveOccurred())
+
+			data, err := os.ReadFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(MatchJSON(`{"type": "pci", "version": "1.1.0", "pci": {"pci-address": "0000:af:06.0"},
+				"sriov-cni": {"pf-name": "enp175s0f1", "vf-id": 0, "dpdk": true}}`))
+
+			Expect(CleanDeviceInfo(path)).To(Succeed())
+			_, err = os.Stat(path)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+		It("should create the directory of the file given by the meta plugin", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "multus", "devinfo.json")
+			Expect(SaveDeviceInfo(path, &sriovtypes.DeviceInfo{Type: sriovtypes.DeviceInfoTypePCI, Version: sriovtypes.DeviceInfoVersion})).To(Succeed())
+			Expect(path).To(BeAnExistingFile())
+		})
+		It("should not fail to remove a missing device info", func() {
+			Expect(CleanDeviceInfo(DeviceInfoPath(GinkgoT().TempDir(), "mynet", "test", "net1"))).To(Succeed())
+		})
+	})
 	Context("Checking SaveNetConf function", func() {
 		var tmpDir string
 
@@ -233,4 +404,18 @@ var _ = Describe("Utils", func() {
 			Expect(netconf.DNS.Domain).To(Equal(newNetConf.DNS.Domain))
 		})
 	})
+	Context("Checking CheckDirWritable function", func() {
+		It("Assuming writable directory", func() {
+			dir := filepath.Join(GinkgoT().TempDir(), "sriov")
+			Expect(CheckDirWritable(dir)).To(Succeed())
+			entries, err := os.ReadDir(dir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entries).To(BeEmpty())
+		})
+		It("Assuming directory can not be created", func() {
+			dir := GinkgoT().TempDir()
+			Expect(os.WriteFile(filepath.Join(dir, "file"), []byte{}, 0o600)).To(Succeed())
+			Expect(CheckDirWritable(filepath.Join(dir, "file", "sriov"))).ToNot(Succeed())
+		})
+	})
 })
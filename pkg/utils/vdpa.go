@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// SysBusVdpa is sysfs vdpa bus directory
+	SysBusVdpa = "/sys/bus/vdpa"
+)
+
+// vDPA bus drivers
+const (
+	VhostVdpaDriver  = "vhost_vdpa"
+	VirtioVdpaDriver = "virtio_vdpa"
+)
+
+// GetVdpaDeviceName returns the name of the vDPA device created on the VF with the given pci address,
+// or an empty string if the VF has none
+func GetVdpaDeviceName(pciAddr string) (string, error) {
+	vfPath, err := filepath.EvalSymlinks(filepath.Join(SysBusPci, pciAddr))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve sysfs path of VF %s: %v", pciAddr, err)
+	}
+
+	devicesDir := filepath.Join(SysBusVdpa, "devices")
+	entries, err := os.ReadDir(devicesDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// vdpa bus is not available, no vDPA device can exist
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read vDPA devices: %v", err)
+	}
+
+	// vDPA devices are children of the VF, or of an auxiliary device of the VF
+	for _, entry := range entries {
+		devPath, err := filepath.EvalSymlinks(filepath.Join(devicesDir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		if strings.HasPrefix(devPath, vfPath+"/") {
+			return entry.Name(), nil
+		}
+	}
+	return "", nil
+}
+
+// GetVdpaDriver returns the name of the driver the vDPA device is bound to, or an empty string if it is not bound
+func GetVdpaDriver(name string) (string, error) {
+	driverPath, err := os.Readlink(filepath.Join(SysBusVdpa, "devices", name, "driver"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read driver of vDPA device %s: %v", name, err)
+	}
+	return filepath.Base(driverPath), nil
+}
+
+// BindVdpaDriver binds the vDPA device to the given driver, unbinding it from its current driver first
+func BindVdpaDriver(name, driver string) error {
+	current, err := GetVdpaDriver(name)
+	if err != nil {
+		return err
+	}
+	if current == driver {
+		return nil
+	}
+
+	if current != "" {
+		unbindPath := filepath.Join(SysBusVdpa, "devices", name, "driver", "unbind")
+		if err := os.WriteFile(unbindPath, []byte(name), os.ModeAppend); err != nil {
+			return fmt.Errorf("failed to unbind vDPA device %s from driver %s: %v", name, current, err)
+		}
+	}
+
+	bindPath := filepath.Join(SysBusVdpa, "drivers", driver, "bind")
+	if err := os.WriteFile(bindPath, []byte(name), os.ModeAppend); err != nil {
+		return fmt.Errorf("failed to bind vDPA device %s to driver %s: %v", name, driver, err)
+	}
+	return nil
+}
+
+// GetVhostVdpaPath returns the path of the vhost-vdpa character device of a vDPA device bound to vhost_vdpa
+func GetVhostVdpaPath(name string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(SysBusVdpa, "devices", name, "vhost-vdpa-*"))
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no vhost-vdpa device found for vDPA device %s", name)
+	}
+	return filepath.Join("/dev", filepath.Base(matches[0])), nil
+}
+
+// GetVirtioVdpaNetdev returns the name of the virtio netdevice of a vDPA device bound to virtio_vdpa
+func GetVirtioVdpaNetdev(name string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(SysBusVdpa, "devices", name, "virtio*", "net", "*"))
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no virtio netdevice found for vDPA device %s", name)
+	}
+	return filepath.Base(matches[0]), nil
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("vDPA", func() {
+	Context("Checking GetVdpaDeviceName function", func() {
+		It("Assuming VF with a vDPA device", func() {
+			Expect(GetVdpaDeviceName("0000:af:06.1")).To(Equal("vdpa0"))
+		})
+		It("Assuming VF without a vDPA device", func() {
+			Expect(GetVdpaDeviceName("0000:af:06.0")).To(BeEmpty())
+		})
+		It("Assuming not existing VF", func() {
+			_, err := GetVdpaDeviceName("0000:af:07.0")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Context("Checking GetVdpaDriver function", func() {
+		It("Assuming bound vDPA device", func() {
+			Expect(GetVdpaDriver("vdpa0")).To(Equal(VhostVdpaDriver))
+		})
+		It("Assuming not existing vDPA device", func() {
+			Expect(GetVdpaDriver("vdpa1")).To(BeEmpty())
+		})
+	})
+	Context("Checking BindVdpaDriver function", func() {
+		It("Assuming vDPA device already bound to the driver", func() {
+			Expect(BindVdpaDriver("vdpa0", VhostVdpaDriver)).To(Succeed())
+			Expect(filepath.Join(SysBusVdpa, "drivers", VhostVdpaDriver, "unbind")).ToNot(BeAnExistingFile())
+		})
+		It("Assuming vDPA device bound to another driver", func() {
+			Expect(BindVdpaDriver("vdpa0", VirtioVdpaDriver)).To(Succeed())
+			DeferCleanup(func() {
+				_ = os.Remove(filepath.Join(SysBusVdpa, "drivers", VhostVdpaDriver, "unbind"))
+				_ = os.Remove(filepath.Join(SysBusVdpa, "drivers", VirtioVdpaDriver, "bind"))
+			})
+
+			Expect(os.ReadFile(filepath.Join(SysBusVdpa, "drivers", VhostVdpaDriver, "unbind"))).To(BeEquivalentTo("vdpa0"))
+			Expect(os.ReadFile(filepath.Join(SysBusVdpa, "drivers", VirtioVdpaDriver, "bind"))).To(BeEquivalentTo("vdpa0"))
+		})
+	})
+	Context("Checking GetVhostVdpaPath function", func() {
+		It("Assuming vDPA device bound to vhost_vdpa", func() {
+			Expect(GetVhostVdpaPath("vdpa0")).To(Equal("/dev/vhost-vdpa-0"))
+		})
+		It("Assuming not existing vDPA device", func() {
+			_, err := GetVhostVdpaPath("vdpa1")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Context("Checking GetVirtioVdpaNetdev function", func() {
+		It("Assuming vDPA device bound to vhost_vdpa", func() {
+			_, err := GetVirtioVdpaNetdev("vdpa0")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
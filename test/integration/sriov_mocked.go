@@ -2,6 +2,7 @@ package main
 
 import (
 	"os"
+	"path/filepath"
 	"runtime"
 
 	"github.com/containernetworking/cni/pkg/skel"
@@ -23,6 +24,7 @@ func main() {
 	customCNIDir, ok := os.LookupEnv("DEFAULT_CNI_DIR")
 	if ok {
 		config.DefaultCNIDir = customCNIDir
+		config.DefaultDeviceInfoDir = filepath.Join(customCNIDir, "devinfo")
 	}
 
 	err := utils.CreateTmpSysFs()
@@ -41,9 +43,11 @@ func main() {
 	defer cancel()
 
 	cniFuncs := skel.CNIFuncs{
-		Add:   cnicommands.CmdAdd,
-		Del:   cnicommands.CmdDel,
-		Check: cnicommands.CmdCheck,
+		Add:    cnicommands.CmdAdd,
+		Del:    cnicommands.CmdDel,
+		Check:  cnicommands.CmdCheck,
+		GC:     cnicommands.CmdGC,
+		Status: cnicommands.CmdStatus,
 	}
 	skel.PluginMainFuncs(cniFuncs, version.All, "")
 }
@@ -104,3 +104,43 @@ EOM
   assert invoke_sriov_cni
   assert 'ip netns exec test_root_ns ip link show enp175s6 | grep 3333'
 }
+
+test_bond() {
+
+  create_network_ns "container_1"
+
+  export CNI_IFNAME=net1
+
+  read -r -d '' CNI_INPUT <<- EOM
+  {
+    "type": "sriov",
+    "cniVersion": "0.3.1",
+    "name": "sriov-network",
+    "ipam": {
+      "type": "test-ipam-cni"
+    },
+    "bond": {
+      "mode": "active-backup",
+      "links": [
+        {"deviceID": "0000:af:06.0"},
+        {"deviceID": "0000:af:06.1"}
+      ]
+    },
+    "mac": "60:00:00:00:00:E1",
+    "logFile": "${DEFAULT_CNI_DIR}/sriov.log",
+    "logLevel": "debug"
+  }
+EOM
+
+  export CNI_COMMAND=ADD
+  assert invoke_sriov_cni
+  assert 'ip netns exec container_1 ip link show net1 | grep -i 60:00:00:00:00:E1'
+  assert 'ip netns exec container_1 ip link show net1_0 | grep "master net1"'
+  assert 'ip netns exec container_1 ip link show net1_1 | grep "master net1"'
+
+  export CNI_COMMAND=DEL
+  assert invoke_sriov_cni
+  assert_fail 'ip netns exec container_1 ip link show net1'
+  assert 'ip netns exec test_root_ns ip link show enp175s6'
+  assert 'ip netns exec test_root_ns ip link show enp175s7'
+}